@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
 
@@ -34,6 +35,8 @@ navigation, pomodoro timer, goal tracking, and more.`,
 	rootCmd.PersistentFlags().StringVar(&themeName, "theme", "", "theme to use (corsair-light, corsair-dark)")
 
 	rootCmd.AddCommand(versionCmd())
+	rootCmd.AddCommand(newCmd())
+	rootCmd.AddCommand(undoCmd())
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
@@ -50,11 +53,24 @@ func run(cmd *cobra.Command, args []string) error {
 
 	logging.Info("Starting LazyObsidian v%s", version)
 
+	cfg, err := setup()
+	if err != nil {
+		return err
+	}
+
+	// Start the TUI application
+	logging.Info("Starting TUI...")
+	return ui.Run(cfg)
+}
+
+// setup loads the configuration, applies command-line overrides and the
+// vault's Obsidian settings, and checks that the vault exists.
+func setup() (*config.Config, error) {
 	// Load configuration
 	cfg, err := loadConfig()
 	if err != nil {
 		logging.Error("Failed to load config: %v", err)
-		return fmt.Errorf("failed to load config: %w", err)
+		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
 	logging.Info("Config loaded successfully")
 
@@ -73,7 +89,7 @@ func run(cmd *cobra.Command, args []string) error {
 	// Validate vault path
 	if cfg.Vault.Path == "" {
 		logging.Error("Vault path is empty")
-		return fmt.Errorf("vault path is required. Use --vault flag or set it in config file")
+		return nil, fmt.Errorf("vault path is required. Use --vault flag or set it in config file")
 	}
 
 	// Expand home directory
@@ -83,12 +99,20 @@ func run(cmd *cobra.Command, args []string) error {
 	// Check if vault exists
 	if _, err := os.Stat(cfg.Vault.Path); os.IsNotExist(err) {
 		logging.Error("Vault path does not exist: %s", cfg.Vault.Path)
-		return fmt.Errorf("vault path does not exist: %s", cfg.Vault.Path)
+		return nil, fmt.Errorf("vault path does not exist: %s", cfg.Vault.Path)
 	}
 
-	// Start the TUI application
-	logging.Info("Starting TUI...")
-	return ui.Run(cfg)
+	// Fill in folders and formats from Obsidian's own settings
+	obsidian, err := config.ReadObsidianSettings(cfg.Vault.Path)
+	if err != nil {
+		logging.Warn("Failed to read Obsidian settings: %v", err)
+	}
+	applied := cfg.ApplyObsidianSettings(obsidian)
+	if len(applied) > 0 {
+		logging.Info("Applied Obsidian settings: %s", strings.Join(applied, ", "))
+	}
+
+	return cfg, nil
 }
 
 func loadConfig() (*config.Config, error) {
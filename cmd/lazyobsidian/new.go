@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/BioWare/lazyobsidian/internal/logging"
+	"github.com/BioWare/lazyobsidian/internal/vault"
+	"github.com/BioWare/lazyobsidian/pkg/types"
+)
+
+// notePeriods maps the periodic note kinds of "new" to their periods.
+var notePeriods = map[string]types.GoalPeriod{
+	"week":    types.GoalPeriodWeek,
+	"month":   types.GoalPeriodMonth,
+	"quarter": types.GoalPeriodQuarter,
+	"year":    types.GoalPeriodYear,
+}
+
+// noteKinds maps the titled note kinds of "new" to their file types.
+var noteKinds = map[string]types.FileType{
+	"goal":   types.FileTypeGoal,
+	"course": types.FileTypeCourse,
+	"book":   types.FileTypeBook,
+}
+
+func newCmd() *cobra.Command {
+	var vars []string
+
+	cmd := &cobra.Command{
+		Use:   "new <daily|week|month|quarter|year> [YYYY-MM-DD] | new <goal|course|book> <title>",
+		Short: "Create a note from its template",
+		Long: `Create a daily, weekly, monthly, quarterly or yearly note for a date
+(today by default), or a goal, course or book note with a title. Notes are
+created from the templates set in the config or in Obsidian; template
+prompts are asked on the terminal.`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := logging.Init(true); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to initialize logging: %v\n", err)
+			}
+			defer logging.Close()
+
+			cfg, err := setup()
+			if err != nil {
+				return err
+			}
+			parser := vault.NewParser(cfg.Vault.Path, cfg)
+			writer := vault.NewWriter(cfg.Vault.Path, cfg, parser)
+			writer.Prompt = promptTerminal
+
+			kind := strings.ToLower(args[0])
+			var note *vault.CreatedNote
+
+			switch {
+			case kind == "daily" || notePeriods[kind] != "":
+				date := time.Now()
+				if len(args) == 2 {
+					date, err = time.ParseInLocation("2006-01-02", args[1], time.Local)
+					if err != nil {
+						return fmt.Errorf("invalid date %q, expected YYYY-MM-DD", args[1])
+					}
+				}
+				if kind == "daily" {
+					note, err = writer.CreateDailyNote(parser.DailyNotePath(date))
+				} else {
+					note, err = writer.CreatePeriodicNote(notePeriods[kind], date)
+				}
+
+			case noteKinds[kind] != "":
+				if len(args) < 2 {
+					return fmt.Errorf("a title is required for a new %s", kind)
+				}
+				values, verr := parseVars(vars)
+				if verr != nil {
+					return verr
+				}
+				note, err = writer.CreateNote(noteKinds[kind], args[1], values)
+
+			default:
+				return fmt.Errorf("unknown note kind %q", args[0])
+			}
+			if err != nil {
+				return err
+			}
+
+			if note.Existed {
+				fmt.Printf("%s already exists\n", note.Path)
+				return nil
+			}
+			if len(note.Cursors) > 0 {
+				fmt.Printf("%s:%d:%d\n", note.Path, note.Cursors[0].Line, note.Cursors[0].Column)
+				return nil
+			}
+			fmt.Println(note.Path)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringArrayVar(&vars, "var", nil, "template variable as key=value, available as {{key}} (repeatable)")
+	return cmd
+}
+
+// parseVars reads key=value template variables.
+func parseVars(vars []string) (map[string]string, error) {
+	values := make(map[string]string)
+	for _, v := range vars {
+		key, value, ok := strings.Cut(v, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("invalid variable %q, expected key=value", v)
+		}
+		values[strings.TrimSpace(key)] = value
+	}
+	return values, nil
+}
+
+var stdin = bufio.NewReader(os.Stdin)
+
+// promptTerminal asks a template prompt on the terminal. An empty answer
+// takes the default.
+func promptTerminal(question, defaultValue string) (string, error) {
+	if defaultValue != "" {
+		fmt.Printf("%s [%s]: ", question, defaultValue)
+	} else {
+		fmt.Printf("%s: ", question)
+	}
+	// Without input, such as at the end of piped input, the default is used
+	answer, _ := stdin.ReadString('\n')
+	answer = strings.TrimRight(answer, "\r\n")
+	if answer == "" {
+		return defaultValue, nil
+	}
+	return answer, nil
+}
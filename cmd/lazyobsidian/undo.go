@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/BioWare/lazyobsidian/internal/journal"
+	"github.com/BioWare/lazyobsidian/internal/logging"
+	"github.com/BioWare/lazyobsidian/internal/vault"
+)
+
+func undoCmd() *cobra.Command {
+	var redo bool
+
+	cmd := &cobra.Command{
+		Use:   "undo [N]",
+		Short: "Undo the last changes made to the vault",
+		Long: `Undo the last N changes (1 by default) LazyObsidian made to the vault,
+newest first, or redo the last N undone ones with --redo. Changes are kept
+in .lazyobsidian/journal.jsonl, so they can be undone after a restart. A
+change whose lines were edited since is left alone, and nothing older is
+undone.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			n := 1
+			if len(args) == 1 {
+				var err error
+				n, err = strconv.Atoi(args[0])
+				if err != nil || n < 1 {
+					return fmt.Errorf("invalid count %q, expected a positive number", args[0])
+				}
+			}
+
+			if err := logging.Init(true); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to initialize logging: %v\n", err)
+			}
+			defer logging.Close()
+
+			cfg, err := setup()
+			if err != nil {
+				return err
+			}
+			parser := vault.NewParser(cfg.Vault.Path, cfg)
+			writer := vault.NewWriter(cfg.Vault.Path, cfg, parser)
+
+			apply, verb := writer.Undo, "Undid"
+			if redo {
+				apply, verb = writer.Redo, "Redid"
+			}
+			entries, err := apply(n)
+			for _, e := range entries {
+				fmt.Printf("%s %s (%s, %s)\n", verb, e.Op, e.Time.Format("2006-01-02 15:04"), changedFiles(e))
+			}
+			if err != nil {
+				return err
+			}
+			if len(entries) == 0 {
+				if redo {
+					fmt.Println("Nothing to redo")
+				} else {
+					fmt.Println("Nothing to undo")
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&redo, "redo", false, "redo changes undone before instead")
+	return cmd
+}
+
+// changedFiles lists the files of an operation, once each.
+func changedFiles(e journal.Entry) string {
+	var files string
+	seen := make(map[string]bool)
+	for _, c := range e.Changes {
+		if seen[c.Path] {
+			continue
+		}
+		seen[c.Path] = true
+		if files != "" {
+			files += ", "
+		}
+		files += c.Path
+	}
+	return files
+}
@@ -31,19 +31,69 @@ func (m *Manager) All() []*types.Book {
 func (m *Manager) CurrentlyReading() []*types.Book {
 	var reading []*types.Book
 	for _, b := range m.books {
-		if b.CurrentPage > 0 && b.CurrentPage < b.TotalPages {
+		if b.Status == types.BookReading {
 			reading = append(reading, b)
 		}
 	}
 	return reading
 }
 
-// CalculateProgress calculates progress percentage for a book.
+// StatusOrder is the order in which the library lists reading statuses.
+var StatusOrder = []types.BookStatus{
+	types.BookReading,
+	types.BookPaused,
+	types.BookToRead,
+	types.BookFinished,
+	types.BookAbandoned,
+}
+
+// Group is the books sharing a reading status.
+type Group struct {
+	Status types.BookStatus
+	Books  []*types.Book
+}
+
+// GroupByStatus groups books by status in StatusOrder, keeping their
+// order within each group. Empty groups are left out.
+func GroupByStatus(books []types.Book) []Group {
+	var groups []Group
+	for _, status := range StatusOrder {
+		group := Group{Status: status}
+		for i := range books {
+			if books[i].Status == status {
+				group.Books = append(group.Books, &books[i])
+			}
+		}
+		if len(group.Books) > 0 {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+// CalculateProgress calculates progress percentage for a book, from its
+// pages or, without a page count, its chapters.
 func CalculateProgress(book *types.Book) float64 {
-	if book.TotalPages == 0 {
+	if book.Status == types.BookFinished {
+		return 1
+	}
+	if book.TotalPages > 0 {
+		progress := float64(book.CurrentPage) / float64(book.TotalPages)
+		if progress > 1 {
+			progress = 1
+		}
+		return progress
+	}
+	if len(book.Chapters) == 0 {
 		return 0
 	}
-	return float64(book.CurrentPage) / float64(book.TotalPages)
+	completed := 0
+	for _, ch := range book.Chapters {
+		if ch.Status == "done" {
+			completed++
+		}
+	}
+	return float64(completed) / float64(len(book.Chapters))
 }
 
 // EstimateTimeLeft estimates reading time remaining based on pages and reading speed.
@@ -4,6 +4,7 @@ package cache
 import (
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -50,17 +51,13 @@ func (c *Cache) initSchema() error {
 		title TEXT NOT NULL,
 		frontmatter_json TEXT,
 		tags TEXT,
+		headings_json TEXT,
+		size INTEGER,
+		hash TEXT,
 		updated_at DATETIME NOT NULL
 	);
 
-	CREATE TABLE IF NOT EXISTS links (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		source_id INTEGER NOT NULL,
-		target_id INTEGER NOT NULL,
-		type TEXT NOT NULL,
-		FOREIGN KEY (source_id) REFERENCES files(id),
-		FOREIGN KEY (target_id) REFERENCES files(id)
-	);
+	` + linksTable + `
 
 	CREATE TABLE IF NOT EXISTS tasks (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -71,9 +68,34 @@ func (c *Cache) initSchema() error {
 		parent_id INTEGER,
 		has_note BOOLEAN DEFAULT FALSE,
 		comment TEXT,
+		section TEXT,
+		section_line INTEGER,
+		due_date DATE,
+		scheduled_date DATE,
+		start_date DATE,
+		created_date DATE,
+		done_date DATE,
+		cancelled_date DATE,
+		priority INTEGER DEFAULT 0,
+		recurrence TEXT,
+		ident TEXT,
+		depends_on TEXT,
+		block_id TEXT,
+		fingerprint TEXT,
+		pomodoros INTEGER DEFAULT 0,
 		FOREIGN KEY (file_id) REFERENCES files(id)
 	);
 
+	CREATE TABLE IF NOT EXISTS fields (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		file_id INTEGER NOT NULL,
+		task_id INTEGER,
+		key TEXT NOT NULL,
+		value TEXT NOT NULL,
+		FOREIGN KEY (file_id) REFERENCES files(id),
+		FOREIGN KEY (task_id) REFERENCES tasks(id)
+	);
+
 	CREATE TABLE IF NOT EXISTS pomodoro (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		file_id INTEGER,
@@ -97,13 +119,135 @@ func (c *Cache) initSchema() error {
 	CREATE INDEX IF NOT EXISTS idx_files_type ON files(type);
 	CREATE INDEX IF NOT EXISTS idx_tasks_file ON tasks(file_id);
 	CREATE INDEX IF NOT EXISTS idx_tasks_status ON tasks(status);
+	CREATE INDEX IF NOT EXISTS idx_fields_key ON fields(key, value);
+	CREATE INDEX IF NOT EXISTS idx_fields_file ON fields(file_id);
 	CREATE INDEX IF NOT EXISTS idx_pomodoro_date ON pomodoro(started_at);
 	`
 
-	_, err := c.db.Exec(schema)
+	if _, err := c.db.Exec(schema); err != nil {
+		return err
+	}
+
+	if err := c.migrate(); err != nil {
+		return err
+	}
+
+	_, err := c.db.Exec(`
+	CREATE INDEX IF NOT EXISTS idx_tasks_due ON tasks(due_date);
+	CREATE INDEX IF NOT EXISTS idx_tasks_priority ON tasks(priority);
+	CREATE INDEX IF NOT EXISTS idx_links_source ON links(source_id);
+	CREATE INDEX IF NOT EXISTS idx_links_target ON links(target_path);
+	CREATE INDEX IF NOT EXISTS idx_links_status ON links(status);
+	`)
 	return err
 }
 
+// linksTable holds one row per link. target_id and target_path are NULL
+// for unresolved links; ambiguous links keep their best-guess target.
+const linksTable = `CREATE TABLE IF NOT EXISTS links (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		source_id INTEGER NOT NULL,
+		target_id INTEGER,
+		type TEXT NOT NULL,
+		target TEXT NOT NULL DEFAULT '',
+		heading TEXT,
+		block TEXT,
+		display TEXT,
+		line INTEGER,
+		target_path TEXT,
+		status TEXT NOT NULL DEFAULT 'unresolved',
+		FOREIGN KEY (source_id) REFERENCES files(id),
+		FOREIGN KEY (target_id) REFERENCES files(id)
+	);`
+
+// columnMigrations lists columns added after the initial schema.
+// CREATE TABLE IF NOT EXISTS leaves existing databases untouched, so
+// these are added with ALTER TABLE when missing.
+var columnMigrations = []struct {
+	table  string
+	column string
+	def    string
+}{
+	{"tasks", "due_date", "DATE"},
+	{"tasks", "scheduled_date", "DATE"},
+	{"tasks", "start_date", "DATE"},
+	{"tasks", "created_date", "DATE"},
+	{"tasks", "done_date", "DATE"},
+	{"tasks", "cancelled_date", "DATE"},
+	{"tasks", "priority", "INTEGER DEFAULT 0"},
+	{"tasks", "recurrence", "TEXT"},
+	{"tasks", "ident", "TEXT"},
+	{"tasks", "depends_on", "TEXT"},
+	{"tasks", "section", "TEXT"},
+	{"tasks", "section_line", "INTEGER"},
+	{"tasks", "block_id", "TEXT"},
+	{"tasks", "fingerprint", "TEXT"},
+	{"tasks", "pomodoros", "INTEGER DEFAULT 0"},
+	{"files", "headings_json", "TEXT"},
+	{"files", "size", "INTEGER"},
+	{"files", "hash", "TEXT"},
+}
+
+// migrate adds any missing columns to tables created by older versions.
+func (c *Cache) migrate() error {
+	// The original links table required a target_id, so it could not hold
+	// unresolved links. Nothing ever wrote to it; recreate it instead.
+	linkCols, err := c.tableColumns("links")
+	if err != nil {
+		return err
+	}
+	if !linkCols["status"] {
+		if _, err := c.db.Exec("DROP TABLE links; " + linksTable); err != nil {
+			return err
+		}
+	}
+
+	existing := make(map[string]map[string]bool)
+
+	for _, m := range columnMigrations {
+		cols, ok := existing[m.table]
+		if !ok {
+			var err error
+			cols, err = c.tableColumns(m.table)
+			if err != nil {
+				return err
+			}
+			existing[m.table] = cols
+		}
+
+		if cols[m.column] {
+			continue
+		}
+		if _, err := c.db.Exec("ALTER TABLE " + m.table + " ADD COLUMN " + m.column + " " + m.def); err != nil {
+			return err
+		}
+		cols[m.column] = true
+	}
+
+	return nil
+}
+
+// tableColumns returns the set of column names in a table.
+func (c *Cache) tableColumns(table string) (map[string]bool, error) {
+	rows, err := c.db.Query("PRAGMA table_info(" + table + ")")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cols := make(map[string]bool)
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dflt, &pk); err != nil {
+			return nil, err
+		}
+		cols[name] = true
+	}
+	return cols, rows.Err()
+}
+
 // Close closes the database connection.
 func (c *Cache) Close() error {
 	return c.db.Close()
@@ -121,72 +265,435 @@ func (c *Cache) SaveFile(file *types.File) (int64, error) {
 		}
 	}
 
+	// Marshal the heading outline to JSON
+	var headingsJSON []byte
+	if len(file.Headings) > 0 {
+		headingsJSON, err = json.Marshal(file.Headings)
+		if err != nil {
+			return 0, err
+		}
+	}
+
 	// Convert tags to comma-separated string
 	tags := strings.Join(file.Tags, ",")
 
-	// Upsert file record
-	result, err := c.db.Exec(`
-		INSERT INTO files (path, type, title, frontmatter_json, tags, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?)
+	// A file's row and everything read from it are replaced together, so
+	// a failed or cancelled save leaves the file as it was cached before
+	tx, err := c.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Upsert file record. LastInsertId is not the file's ID when the
+	// upsert updates, so the ID is returned by the statement itself
+	var fileID int64
+	err = tx.QueryRow(`
+		INSERT INTO files (path, type, title, frontmatter_json, tags, headings_json, size, hash, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(path) DO UPDATE SET
 			type = excluded.type,
 			title = excluded.title,
 			frontmatter_json = excluded.frontmatter_json,
 			tags = excluded.tags,
+			headings_json = excluded.headings_json,
+			size = excluded.size,
+			hash = excluded.hash,
 			updated_at = excluded.updated_at
-	`, file.Path, string(file.Type), file.Title, string(frontmatterJSON), tags, file.ModifiedAt)
+		RETURNING id
+	`, file.Path, string(file.Type), file.Title, string(frontmatterJSON), tags, string(headingsJSON),
+		file.Size, file.Hash, file.ModifiedAt).Scan(&fileID)
 	if err != nil {
 		return 0, err
 	}
 
-	// Get the file ID
+	// Delete existing tasks and fields for this file and re-insert
+	_, err = tx.Exec("DELETE FROM fields WHERE file_id = ?", fileID)
+	if err != nil {
+		return fileID, err
+	}
+	_, err = tx.Exec("DELETE FROM tasks WHERE file_id = ?", fileID)
+	if err != nil {
+		return fileID, err
+	}
+
+	// Save note-level inline fields
+	err = saveFields(tx, file.Fields, fileID, nil)
+	if err != nil {
+		return fileID, err
+	}
+
+	// Save tasks recursively
+	err = saveTasks(tx, file.Tasks, fileID, nil)
+	if err != nil {
+		return fileID, err
+	}
+
+	// Save outgoing links
+	err = saveLinks(tx, file.Links, fileID)
+	if err != nil {
+		return fileID, err
+	}
+
+	// Links saved before this file was cached can now point at it
+	_, err = tx.Exec(`
+		UPDATE links SET target_id = ? WHERE target_path = ? AND target_id IS NULL
+	`, fileID, file.Path)
+	if err != nil {
+		return fileID, err
+	}
+
+	return fileID, tx.Commit()
+}
+
+// execer runs statements, on the database or inside a transaction.
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// saveLinks replaces the outgoing links of a file.
+func saveLinks(db execer, links []types.Link, fileID int64) error {
+	_, err := db.Exec("DELETE FROM links WHERE source_id = ?", fileID)
+	if err != nil {
+		return err
+	}
+
+	for _, link := range links {
+		status := link.Status
+		if status == "" {
+			status = types.LinkUnresolved
+		}
+		var targetPath interface{}
+		if link.TargetPath != "" {
+			targetPath = link.TargetPath
+		}
+
+		_, err := db.Exec(`
+			INSERT INTO links (source_id, target_id, type, target, heading, block, display, line, target_path, status)
+			VALUES (?, (SELECT id FROM files WHERE path = ?), ?, ?, ?, ?, ?, ?, ?, ?)
+		`, fileID, targetPath, string(link.Type), link.Target, link.Heading, link.Block,
+			link.Display, link.Line, targetPath, string(status))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SaveLinks replaces the outgoing links of a cached file, for example
+// after they were resolved again.
+func (c *Cache) SaveLinks(path string, links []types.Link) error {
 	var fileID int64
-	if file.ID > 0 {
-		fileID = file.ID
-	} else {
-		fileID, err = result.LastInsertId()
+	err := c.db.QueryRow("SELECT id FROM files WHERE path = ?", path).Scan(&fileID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return saveLinks(c.db, links, fileID)
+}
+
+// linkColumns is the column list read by scanLink.
+const linkColumns = `l.source_id, l.target_id, l.type, l.target, l.heading, l.block,
+	l.display, l.line, l.target_path, l.status`
+
+func scanLink(row interface{ Scan(...interface{}) error }, extra ...interface{}) (types.Link, error) {
+	var link types.Link
+	var targetID sql.NullInt64
+	var heading, block, display, targetPath sql.NullString
+	var line sql.NullInt64
+	var linkType, status string
+
+	dest := []interface{}{&link.SourceID, &targetID, &linkType, &link.Target, &heading, &block,
+		&display, &line, &targetPath, &status}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return link, err
+	}
+
+	link.TargetID = targetID.Int64
+	link.Type = types.LinkType(linkType)
+	link.Heading = heading.String
+	link.Block = block.String
+	link.Display = display.String
+	link.Line = int(line.Int64)
+	link.TargetPath = targetPath.String
+	link.Status = types.LinkStatus(status)
+	return link, nil
+}
+
+// queryLinks runs a query selecting linkColumns.
+func (c *Cache) queryLinks(query string, args ...interface{}) ([]types.Link, error) {
+	rows, err := c.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var links []types.Link
+	for rows.Next() {
+		link, err := scanLink(rows)
 		if err != nil {
-			// If INSERT ON CONFLICT did UPDATE, LastInsertId returns 0
-			// We need to query for the ID
-			err = c.db.QueryRow("SELECT id FROM files WHERE path = ?", file.Path).Scan(&fileID)
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	return links, rows.Err()
+}
+
+// GetLinks retrieves the outgoing links of a file.
+func (c *Cache) GetLinks(path string) ([]types.Link, error) {
+	return c.queryLinks(`
+		SELECT `+linkColumns+`
+		FROM links l
+		JOIN files f ON l.source_id = f.id
+		WHERE f.path = ?
+		ORDER BY l.line, l.id
+	`, path)
+}
+
+// GetBacklinks retrieves the links pointing at a file, including
+// ambiguous links whose best guess is the file.
+func (c *Cache) GetBacklinks(path string) ([]types.Link, error) {
+	return c.queryLinks(`
+		SELECT `+linkColumns+`
+		FROM links l
+		WHERE l.target_path = ?
+		ORDER BY l.source_id, l.line
+	`, path)
+}
+
+// GetUnresolvedLinkSources returns the paths of files that have
+// unresolved or ambiguous links.
+func (c *Cache) GetUnresolvedLinkSources() ([]string, error) {
+	rows, err := c.db.Query(`
+		SELECT DISTINCT f.path
+		FROM links l
+		JOIN files f ON l.source_id = f.id
+		WHERE l.status != ?
+	`, string(types.LinkResolved))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var paths []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, rows.Err()
+}
+
+// saveFields saves inline fields for a file, or for a task when taskID is set.
+// Fields set more than once are stored as one row per value.
+func saveFields(db execer, fields map[string]interface{}, fileID int64, taskID *int64) error {
+	for key, value := range fields {
+		values, ok := value.([]interface{})
+		if !ok {
+			values = []interface{}{value}
+		}
+		for _, v := range values {
+			_, err := db.Exec(`
+				INSERT INTO fields (file_id, task_id, key, value) VALUES (?, ?, ?, ?)
+			`, fileID, taskID, key, fmt.Sprint(v))
 			if err != nil {
-				return 0, err
+				return err
 			}
 		}
 	}
+	return nil
+}
 
-	// Delete existing tasks for this file and re-insert
-	_, err = c.db.Exec("DELETE FROM tasks WHERE file_id = ?", fileID)
+// loadFields loads inline fields for a file, or for a task when taskID is set.
+func (c *Cache) loadFields(fileID int64, taskID *int64) (map[string]interface{}, error) {
+	var rows *sql.Rows
+	var err error
+
+	if taskID == nil {
+		rows, err = c.db.Query(`
+			SELECT key, value FROM fields WHERE file_id = ? AND task_id IS NULL ORDER BY id
+		`, fileID)
+	} else {
+		rows, err = c.db.Query(`
+			SELECT key, value FROM fields WHERE task_id = ? ORDER BY id
+		`, *taskID)
+	}
 	if err != nil {
-		return fileID, err
+		return nil, err
+	}
+	defer rows.Close()
+
+	var fields map[string]interface{}
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, err
+		}
+		if fields == nil {
+			fields = make(map[string]interface{})
+		}
+		switch existing := fields[key].(type) {
+		case nil:
+			fields[key] = value
+		case []interface{}:
+			fields[key] = append(existing, value)
+		default:
+			fields[key] = []interface{}{existing, value}
+		}
 	}
 
-	// Save tasks recursively
-	err = c.saveTasks(file.Tasks, fileID, nil)
-	if err != nil {
-		return fileID, err
+	return fields, rows.Err()
+}
+
+// decodeFrontmatter decodes cached frontmatter JSON.
+// Numbers are restored as int when they are integral so that cached files
+// expose the same types as freshly parsed ones.
+func decodeFrontmatter(data string) (map[string]interface{}, error) {
+	dec := json.NewDecoder(strings.NewReader(data))
+	dec.UseNumber()
+
+	var fm map[string]interface{}
+	if err := dec.Decode(&fm); err != nil {
+		return nil, err
+	}
+	for k, v := range fm {
+		fm[k] = restoreNumbers(v)
+	}
+	return fm, nil
+}
+
+// restoreNumbers converts json.Number values back to int or float64.
+func restoreNumbers(v interface{}) interface{} {
+	switch val := v.(type) {
+	case json.Number:
+		if n, err := val.Int64(); err == nil {
+			return int(n)
+		}
+		f, _ := val.Float64()
+		return f
+	case map[string]interface{}:
+		for k, item := range val {
+			val[k] = restoreNumbers(item)
+		}
+		return val
+	case []interface{}:
+		for i, item := range val {
+			val[i] = restoreNumbers(item)
+		}
+		return val
+	}
+	return v
+}
+
+// taskColumns are the task columns read back by scanTask, in order.
+var taskColumns = []string{
+	"id", "file_id", "line", "text", "status", "has_note", "comment", "section", "section_line",
+	"due_date", "scheduled_date", "start_date", "created_date", "done_date", "cancelled_date",
+	"priority", "recurrence", "ident", "depends_on", "block_id", "fingerprint", "pomodoros",
+}
+
+// taskSelect returns the task column list, each prefixed with alias.
+func taskSelect(alias string) string {
+	cols := make([]string, len(taskColumns))
+	for i, col := range taskColumns {
+		cols[i] = alias + col
+	}
+	return strings.Join(cols, ", ")
+}
+
+// scanTask scans a row selected with taskSelect into a task.
+// Any extra destinations are scanned after the task columns.
+func scanTask(row interface{ Scan(...interface{}) error }, extra ...interface{}) (types.Task, error) {
+	var task types.Task
+	var comment, section, recurrence, ident, dependsOn, blockID, fingerprint sql.NullString
+	var sectionLine sql.NullInt64
+	var due, scheduled, start, created, done, cancelled sql.NullString
+	var priority, pomodoros sql.NullInt64
+
+	dest := []interface{}{
+		&task.ID, &task.FileID, &task.Line, &task.Text, &task.Status, &task.HasNote, &comment, &section, &sectionLine,
+		&due, &scheduled, &start, &created, &done, &cancelled,
+		&priority, &recurrence, &ident, &dependsOn, &blockID, &fingerprint, &pomodoros,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return task, err
+	}
+
+	task.Comment = comment.String
+	task.Section = section.String
+	task.SectionLine = int(sectionLine.Int64)
+	task.DueDate = parseDate(due)
+	task.ScheduledDate = parseDate(scheduled)
+	task.StartDate = parseDate(start)
+	task.CreatedDate = parseDate(created)
+	task.DoneDate = parseDate(done)
+	task.CancelledDate = parseDate(cancelled)
+	task.Priority = types.TaskPriority(priority.Int64)
+	task.Recurrence = recurrence.String
+	task.TaskID = ident.String
+	task.BlockID = blockID.String
+	task.Fingerprint = fingerprint.String
+	task.Pomodoros = int(pomodoros.Int64)
+	if dependsOn.Valid && dependsOn.String != "" {
+		task.DependsOn = strings.Split(dependsOn.String, ",")
+	}
+
+	return task, nil
+}
+
+// formatDate converts an optional date to its cached representation.
+func formatDate(t *time.Time) interface{} {
+	if t == nil {
+		return nil
 	}
+	return t.Format("2006-01-02")
+}
 
-	return fileID, nil
+// parseDate converts a cached date back into an optional time.
+// The sqlite driver hands DATE columns back as full timestamps, so only
+// the leading date part is used.
+func parseDate(s sql.NullString) *time.Time {
+	if !s.Valid || len(s.String) < len("2006-01-02") {
+		return nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", s.String[:len("2006-01-02")], time.Local)
+	if err != nil {
+		return nil
+	}
+	return &t
 }
 
 // saveTasks recursively saves tasks and their subtasks.
-func (c *Cache) saveTasks(tasks []types.Task, fileID int64, parentID *int64) error {
+func saveTasks(db execer, tasks []types.Task, fileID int64, parentID *int64) error {
 	for _, task := range tasks {
-		result, err := c.db.Exec(`
-			INSERT INTO tasks (file_id, line, text, status, parent_id, has_note, comment)
-			VALUES (?, ?, ?, ?, ?, ?, ?)
-		`, fileID, task.Line, task.Text, task.Status, parentID, task.HasNote, task.Comment)
+		result, err := db.Exec(`
+			INSERT INTO tasks (file_id, line, text, status, parent_id, has_note, comment, section, section_line,
+				due_date, scheduled_date, start_date, created_date, done_date, cancelled_date,
+				priority, recurrence, ident, depends_on, block_id, fingerprint, pomodoros)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, fileID, task.Line, task.Text, task.Status, parentID, task.HasNote, task.Comment, task.Section, task.SectionLine,
+			formatDate(task.DueDate), formatDate(task.ScheduledDate), formatDate(task.StartDate),
+			formatDate(task.CreatedDate), formatDate(task.DoneDate), formatDate(task.CancelledDate),
+			int(task.Priority), task.Recurrence, task.TaskID, strings.Join(task.DependsOn, ","),
+			task.BlockID, task.Fingerprint, task.Pomodoros)
 		if err != nil {
 			return err
 		}
 
-		if len(task.Subtasks) > 0 {
+		if len(task.Subtasks) > 0 || len(task.Fields) > 0 {
 			taskID, err := result.LastInsertId()
 			if err != nil {
 				return err
 			}
-			err = c.saveTasks(task.Subtasks, fileID, &taskID)
+			err = saveFields(db, task.Fields, fileID, &taskID)
+			if err != nil {
+				return err
+			}
+			err = saveTasks(db, task.Subtasks, fileID, &taskID)
 			if err != nil {
 				return err
 			}
@@ -195,31 +702,30 @@ func (c *Cache) saveTasks(tasks []types.Task, fileID int64, parentID *int64) err
 	return nil
 }
 
-// GetFile retrieves a cached file by path.
-func (c *Cache) GetFile(path string) (*types.File, error) {
-	row := c.db.QueryRow(`
-		SELECT id, path, type, title, frontmatter_json, tags, updated_at
-		FROM files WHERE path = ?
-	`, path)
+// fileColumns is the column list read by scanFile.
+const fileColumns = `id, path, type, title, frontmatter_json, tags, headings_json, size, hash, updated_at`
 
+// scanFile scans a row selected with fileColumns into a file, without
+// its tasks, fields or links.
+func scanFile(row interface{ Scan(...interface{}) error }) (*types.File, error) {
 	var file types.File
-	var frontmatterJSON sql.NullString
-	var tags sql.NullString
+	var frontmatterJSON, tags, headingsJSON, hash sql.NullString
+	var size sql.NullInt64
 	var fileType string
 
-	err := row.Scan(&file.ID, &file.Path, &fileType, &file.Title, &frontmatterJSON, &tags, &file.ModifiedAt)
-	if err == sql.ErrNoRows {
-		return nil, nil
-	}
+	err := row.Scan(&file.ID, &file.Path, &fileType, &file.Title, &frontmatterJSON, &tags, &headingsJSON,
+		&size, &hash, &file.ModifiedAt)
 	if err != nil {
 		return nil, err
 	}
 
 	file.Type = types.FileType(fileType)
+	file.Size = size.Int64
+	file.Hash = hash.String
 
 	// Parse frontmatter JSON
 	if frontmatterJSON.Valid && frontmatterJSON.String != "" {
-		err = json.Unmarshal([]byte(frontmatterJSON.String), &file.Frontmatter)
+		file.Frontmatter, err = decodeFrontmatter(frontmatterJSON.String)
 		if err != nil {
 			return nil, err
 		}
@@ -230,13 +736,43 @@ func (c *Cache) GetFile(path string) (*types.File, error) {
 		file.Tags = strings.Split(tags.String, ",")
 	}
 
-	// Load tasks for this file
+	// Parse heading outline
+	if headingsJSON.Valid && headingsJSON.String != "" {
+		if err := json.Unmarshal([]byte(headingsJSON.String), &file.Headings); err != nil {
+			return nil, err
+		}
+	}
+
+	return &file, nil
+}
+
+// GetFile retrieves a cached file by path.
+func (c *Cache) GetFile(path string) (*types.File, error) {
+	row := c.db.QueryRow(`SELECT `+fileColumns+` FROM files WHERE path = ?`, path)
+
+	file, err := scanFile(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Load inline fields and tasks for this file
+	file.Fields, err = c.loadFields(file.ID, nil)
+	if err != nil {
+		return nil, err
+	}
 	file.Tasks, err = c.getTasksForFile(file.ID, nil)
 	if err != nil {
 		return nil, err
 	}
+	file.Links, err = c.GetLinks(file.Path)
+	if err != nil {
+		return nil, err
+	}
 
-	return &file, nil
+	return file, nil
 }
 
 // getTasksForFile retrieves tasks for a file, optionally filtered by parent.
@@ -246,13 +782,13 @@ func (c *Cache) getTasksForFile(fileID int64, parentID *int64) ([]types.Task, er
 
 	if parentID == nil {
 		rows, err = c.db.Query(`
-			SELECT id, line, text, status, has_note, comment
+			SELECT `+taskSelect("")+`
 			FROM tasks WHERE file_id = ? AND parent_id IS NULL
 			ORDER BY line
 		`, fileID)
 	} else {
 		rows, err = c.db.Query(`
-			SELECT id, line, text, status, has_note, comment
+			SELECT `+taskSelect("")+`
 			FROM tasks WHERE file_id = ? AND parent_id = ?
 			ORDER BY line
 		`, fileID, *parentID)
@@ -265,18 +801,15 @@ func (c *Cache) getTasksForFile(fileID int64, parentID *int64) ([]types.Task, er
 
 	var tasks []types.Task
 	for rows.Next() {
-		var task types.Task
-		var comment sql.NullString
-
-		err = rows.Scan(&task.ID, &task.Line, &task.Text, &task.Status, &task.HasNote, &comment)
+		task, err := scanTask(rows)
 		if err != nil {
 			return nil, err
 		}
 
-		if comment.Valid {
-			task.Comment = comment.String
+		task.Fields, err = c.loadFields(fileID, &task.ID)
+		if err != nil {
+			return nil, err
 		}
-		task.FileID = fileID
 
 		// Recursively get subtasks
 		task.Subtasks, err = c.getTasksForFile(fileID, &task.ID)
@@ -292,54 +825,32 @@ func (c *Cache) getTasksForFile(fileID int64, parentID *int64) ([]types.Task, er
 
 // GetFilesByType retrieves all files of a given type.
 func (c *Cache) GetFilesByType(fileType types.FileType) ([]*types.File, error) {
-	rows, err := c.db.Query(`
-		SELECT id, path, type, title, frontmatter_json, tags, updated_at
+	files, err := c.queryFiles(`
+		SELECT `+fileColumns+`
 		FROM files WHERE type = ?
 		ORDER BY updated_at DESC
 	`, string(fileType))
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	var files []*types.File
-	for rows.Next() {
-		var file types.File
-		var frontmatterJSON sql.NullString
-		var tags sql.NullString
-		var ft string
-
-		err = rows.Scan(&file.ID, &file.Path, &ft, &file.Title, &frontmatterJSON, &tags, &file.ModifiedAt)
-		if err != nil {
-			return nil, err
-		}
-
-		file.Type = types.FileType(ft)
-
-		if frontmatterJSON.Valid && frontmatterJSON.String != "" {
-			json.Unmarshal([]byte(frontmatterJSON.String), &file.Frontmatter)
-		}
-
-		if tags.Valid && tags.String != "" {
-			file.Tags = strings.Split(tags.String, ",")
-		}
-
-		// Load tasks
+	// Load inline fields, tasks and links
+	for _, file := range files {
+		file.Fields, _ = c.loadFields(file.ID, nil)
 		file.Tasks, _ = c.getTasksForFile(file.ID, nil)
-
-		files = append(files, &file)
+		file.Links, _ = c.GetLinks(file.Path)
 	}
 
-	return files, rows.Err()
+	return files, nil
 }
 
 // GetPendingTasks retrieves all incomplete tasks across all files.
 func (c *Cache) GetPendingTasks() ([]types.Task, error) {
 	rows, err := c.db.Query(`
-		SELECT t.id, t.file_id, t.line, t.text, t.status, t.has_note, t.comment, f.path, f.title
+		SELECT ` + taskSelect("t.") + `
 		FROM tasks t
 		JOIN files f ON t.file_id = f.id
-		WHERE t.status != 'x' AND t.parent_id IS NULL
+		WHERE t.status NOT IN ('done', 'cancelled') AND t.parent_id IS NULL
 		ORDER BY f.updated_at DESC, t.line
 	`)
 	if err != nil {
@@ -349,27 +860,130 @@ func (c *Cache) GetPendingTasks() ([]types.Task, error) {
 
 	var tasks []types.Task
 	for rows.Next() {
-		var task types.Task
-		var comment sql.NullString
-		var filePath, fileTitle string
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
 
-		err = rows.Scan(&task.ID, &task.FileID, &task.Line, &task.Text, &task.Status,
-			&task.HasNote, &comment, &filePath, &fileTitle)
+		// Load inline fields and subtasks
+		task.Fields, _ = c.loadFields(task.FileID, &task.ID)
+		task.Subtasks, _ = c.getTasksForFile(task.FileID, &task.ID)
+
+		tasks = append(tasks, task)
+	}
+
+	return tasks, rows.Err()
+}
+
+// GetTasksDueBetween retrieves incomplete tasks whose due date falls in
+// [start, end], ordered by due date and then by priority.
+func (c *Cache) GetTasksDueBetween(start, end time.Time) ([]types.Task, error) {
+	rows, err := c.db.Query(`
+		SELECT `+taskSelect("")+`
+		FROM tasks
+		WHERE status NOT IN ('done', 'cancelled')
+			AND due_date IS NOT NULL AND due_date >= ? AND due_date <= ?
+		ORDER BY due_date, priority DESC, line
+	`, start.Format("2006-01-02"), end.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []types.Task
+	for rows.Next() {
+		task, err := scanTask(rows)
 		if err != nil {
 			return nil, err
 		}
+		tasks = append(tasks, task)
+	}
 
-		if comment.Valid {
-			task.Comment = comment.String
+	return tasks, rows.Err()
+}
+
+// FindFilesByField retrieves files with an inline field key set to value.
+// An empty value matches any file that has the field at all.
+func (c *Cache) FindFilesByField(key, value string) ([]*types.File, error) {
+	query := `
+		SELECT DISTINCT f.path
+		FROM fields fd
+		JOIN files f ON fd.file_id = f.id
+		WHERE fd.key = ?`
+	args := []interface{}{key}
+	if value != "" {
+		query += " AND fd.value = ?"
+		args = append(args, value)
+	}
+
+	rows, err := c.db.Query(query+" ORDER BY f.updated_at DESC", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			rows.Close()
+			return nil, err
 		}
+		paths = append(paths, path)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-		// Load subtasks
-		task.Subtasks, _ = c.getTasksForFile(task.FileID, &task.ID)
+	var files []*types.File
+	for _, path := range paths {
+		file, err := c.GetFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if file != nil {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
 
+// FindTasksByField retrieves tasks with an inline field key set to value.
+// An empty value matches any task that has the field at all.
+func (c *Cache) FindTasksByField(key, value string) ([]types.Task, error) {
+	query := `
+		SELECT DISTINCT ` + taskSelect("t.") + `
+		FROM fields fd
+		JOIN tasks t ON fd.task_id = t.id
+		WHERE fd.key = ?`
+	args := []interface{}{key}
+	if value != "" {
+		query += " AND fd.value = ?"
+		args = append(args, value)
+	}
+
+	rows, err := c.db.Query(query+" ORDER BY t.file_id, t.line", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []types.Task
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
 		tasks = append(tasks, task)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-	return tasks, rows.Err()
+	for i := range tasks {
+		tasks[i].Fields, _ = c.loadFields(tasks[i].FileID, &tasks[i].ID)
+	}
+	return tasks, nil
 }
 
 // SavePomodoroSession logs a completed pomodoro session.
@@ -484,7 +1098,24 @@ func (c *Cache) InvalidateFile(path string) error {
 		return err
 	}
 
-	// Delete tasks for this file
+	// Delete fields, tasks and outgoing links for this file
+	_, err = c.db.Exec("DELETE FROM fields WHERE file_id = ?", fileID)
+	if err != nil {
+		return err
+	}
+	_, err = c.db.Exec("DELETE FROM links WHERE source_id = ?", fileID)
+	if err != nil {
+		return err
+	}
+
+	// Links into this file no longer resolve
+	_, err = c.db.Exec(`
+		UPDATE links SET target_id = NULL, target_path = NULL, status = ?
+		WHERE target_id = ? OR target_path = ?
+	`, string(types.LinkUnresolved), fileID, path)
+	if err != nil {
+		return err
+	}
 	_, err = c.db.Exec("DELETE FROM tasks WHERE file_id = ?", fileID)
 	if err != nil {
 		return err
@@ -495,14 +1126,66 @@ func (c *Cache) InvalidateFile(path string) error {
 	return err
 }
 
+// FileStamp identifies the version of a file the cache holds.
+type FileStamp struct {
+	ModifiedAt time.Time
+	Size       int64
+	Hash       string
+}
+
+// GetFileStamps returns the stamp of every cached file, keyed by path.
+func (c *Cache) GetFileStamps() (map[string]FileStamp, error) {
+	rows, err := c.db.Query(`SELECT path, updated_at, size, hash FROM files`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stamps := make(map[string]FileStamp)
+	for rows.Next() {
+		var path string
+		var stamp FileStamp
+		var size sql.NullInt64
+		var hash sql.NullString
+		if err := rows.Scan(&path, &stamp.ModifiedAt, &size, &hash); err != nil {
+			return nil, err
+		}
+		stamp.Size = size.Int64
+		stamp.Hash = hash.String
+		stamps[path] = stamp
+	}
+	return stamps, rows.Err()
+}
+
+// TouchFile records a new modification time for a cached file whose
+// content has not changed.
+func (c *Cache) TouchFile(path string, modifiedAt time.Time) error {
+	_, err := c.db.Exec("UPDATE files SET updated_at = ? WHERE path = ?", modifiedAt, path)
+	return err
+}
+
 // GetRecentFiles retrieves the most recently modified files.
 func (c *Cache) GetRecentFiles(limit int) ([]*types.File, error) {
-	rows, err := c.db.Query(`
-		SELECT id, path, type, title, frontmatter_json, tags, updated_at
+	return c.queryFiles(`
+		SELECT `+fileColumns+`
 		FROM files
 		ORDER BY updated_at DESC
 		LIMIT ?
 	`, limit)
+}
+
+// GetAllFiles retrieves every cached file without its tasks, fields or links.
+func (c *Cache) GetAllFiles() ([]*types.File, error) {
+	return c.queryFiles(`
+		SELECT `+fileColumns+`
+		FROM files
+		ORDER BY path
+	`)
+}
+
+// queryFiles runs a query selecting fileColumns and scans the files.
+func (c *Cache) queryFiles(query string, args ...interface{}) ([]*types.File, error) {
+	rows, err := c.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -510,27 +1193,11 @@ func (c *Cache) GetRecentFiles(limit int) ([]*types.File, error) {
 
 	var files []*types.File
 	for rows.Next() {
-		var file types.File
-		var frontmatterJSON sql.NullString
-		var tags sql.NullString
-		var ft string
-
-		err = rows.Scan(&file.ID, &file.Path, &ft, &file.Title, &frontmatterJSON, &tags, &file.ModifiedAt)
+		file, err := scanFile(rows)
 		if err != nil {
 			return nil, err
 		}
-
-		file.Type = types.FileType(ft)
-
-		if frontmatterJSON.Valid && frontmatterJSON.String != "" {
-			json.Unmarshal([]byte(frontmatterJSON.String), &file.Frontmatter)
-		}
-
-		if tags.Valid && tags.String != "" {
-			file.Tags = strings.Split(tags.String, ",")
-		}
-
-		files = append(files, &file)
+		files = append(files, file)
 	}
 
 	return files, rows.Err()
@@ -45,6 +45,8 @@ type Config struct {
 	Vault       VaultConfig       `yaml:"vault"`
 	Folders     FoldersConfig     `yaml:"folders"`
 	Daily       DailyConfig       `yaml:"daily"`
+	Periodic    PeriodicConfig    `yaml:"periodic"`
+	Templates   TemplatesConfig   `yaml:"templates"`
 	Tasks       TasksConfig       `yaml:"tasks"`
 	Pomodoro    PomodoroConfig    `yaml:"pomodoro"`
 	Sounds      SoundsConfig      `yaml:"sounds"`
@@ -54,12 +56,18 @@ type Config struct {
 	Import      ImportConfig      `yaml:"import"`
 	Language    string            `yaml:"language"`
 	Keybindings map[string]string `yaml:"keybindings"`
+
+	// explicit holds the dotted keys set in the config file, such as
+	// "daily.folder", so settings read from the vault do not override them.
+	explicit map[string]bool
 }
 
 // VaultConfig holds vault-related settings.
 type VaultConfig struct {
-	Path              string `yaml:"path"`
-	AutoCreateFolders bool   `yaml:"auto_create_folders"`
+	Path              string   `yaml:"path"`
+	AutoCreateFolders bool     `yaml:"auto_create_folders"`
+	IgnoreFilters     []string `yaml:"ignore_filters"`
+	AttachmentFolder  string   `yaml:"attachment_folder"`
 }
 
 // FoldersConfig holds folder path mappings.
@@ -73,10 +81,42 @@ type FoldersConfig struct {
 	Wishlist  string `yaml:"wishlist"`
 }
 
-// DailyConfig holds daily note settings.
+// DailyConfig holds daily note settings. Format is a Moment.js format,
+// like the periodic notes' ones. FilenameFormat, a Go layout, is only read
+// from older config files and converted into Format when loading.
 type DailyConfig struct {
 	Folder         string `yaml:"folder"`
-	FilenameFormat string `yaml:"filename_format"`
+	Format         string `yaml:"format"`
+	FilenameFormat string `yaml:"filename_format,omitempty"`
+	Template       string `yaml:"template"`
+	FocusSection   string `yaml:"focus_section"` // heading new tasks go under
+}
+
+// PeriodicNoteConfig holds the settings for one kind of periodic note.
+// Format is a Moment.js format, like Obsidian's, since Go layouts cannot
+// express weeks or quarters.
+type PeriodicNoteConfig struct {
+	Folder      string `yaml:"folder"`
+	Format      string `yaml:"format"`
+	Template    string `yaml:"template"`
+	TaskSection string `yaml:"task_section"` // heading tasks moved to the note go under
+}
+
+// PeriodicConfig holds weekly, monthly, quarterly and yearly note settings.
+type PeriodicConfig struct {
+	Weekly    PeriodicNoteConfig `yaml:"weekly"`
+	Monthly   PeriodicNoteConfig `yaml:"monthly"`
+	Quarterly PeriodicNoteConfig `yaml:"quarterly"`
+	Yearly    PeriodicNoteConfig `yaml:"yearly"`
+}
+
+// TemplatesConfig names the templates new notes are created from. Names
+// are looked up in the templates folder, then as vault paths; empty means
+// the built-in note body.
+type TemplatesConfig struct {
+	Goal   string `yaml:"goal"`
+	Course string `yaml:"course"`
+	Book   string `yaml:"book"`
 }
 
 // TaskStatusConfig holds a task status definition.
@@ -91,6 +131,12 @@ type TaskStatusConfig struct {
 type SubtasksConfig struct {
 	Enabled           bool `yaml:"enabled"`
 	AutoCloseChildren bool `yaml:"auto_close_children"`
+	// CloseChildrenAs is the status open subtasks get when their parent is
+	// closed: "done" or "cancelled".
+	CloseChildrenAs string `yaml:"close_children_as"`
+	// AutoCompleteParent completes a task when its last open subtask is
+	// done, and reopens it when one of them reopens.
+	AutoCompleteParent bool `yaml:"auto_complete_parent"`
 }
 
 // TaskNotesConfig holds task notes settings.
@@ -101,11 +147,23 @@ type TaskNotesConfig struct {
 	IncludeInStats  bool   `yaml:"include_in_stats"`
 }
 
+// TaskDatesConfig holds settings for the dates written when a task
+// changes status.
+type TaskDatesConfig struct {
+	// Enabled adds the day a task is completed or cancelled to its line,
+	// and removes it when the task reopens.
+	Enabled bool `yaml:"enabled"`
+	// Format is "emoji" for ✅ and ❌ dates, as the Tasks plugin writes
+	// them, or "dataview" for [completion:: ] and [cancelled:: ] fields.
+	Format string `yaml:"format"`
+}
+
 // TasksConfig holds task-related settings.
 type TasksConfig struct {
 	Statuses []TaskStatusConfig `yaml:"statuses"`
 	Subtasks SubtasksConfig     `yaml:"subtasks"`
 	Notes    TaskNotesConfig    `yaml:"notes"`
+	Dates    TaskDatesConfig    `yaml:"dates"`
 }
 
 // PomodoroLoggingConfig holds pomodoro logging settings.
@@ -196,8 +254,15 @@ func DefaultConfig() *Config {
 			Wishlist:  "Wishlist",
 		},
 		Daily: DailyConfig{
-			Folder:         "Journal",
-			FilenameFormat: "2006-01-02",
+			Folder:       "Journal",
+			Format:       "YYYY-MM-DD",
+			FocusSection: "Today's Focus",
+		},
+		Periodic: PeriodicConfig{
+			Weekly:    PeriodicNoteConfig{Folder: "Plan", Format: "GGGG-[W]WW", TaskSection: "Goals"},
+			Monthly:   PeriodicNoteConfig{Folder: "Plan", Format: "YYYY-MM", TaskSection: "Goals"},
+			Quarterly: PeriodicNoteConfig{Folder: "Plan", Format: "YYYY-[Q]Q", TaskSection: "Goals"},
+			Yearly:    PeriodicNoteConfig{Folder: "Plan", Format: "YYYY", TaskSection: "Goals"},
 		},
 		Tasks: TasksConfig{
 			Statuses: []TaskStatusConfig{
@@ -209,8 +274,10 @@ func DefaultConfig() *Config {
 				{Symbol: "?", Name: "question", Icon: "?", Color: "info"},
 			},
 			Subtasks: SubtasksConfig{
-				Enabled:           true,
-				AutoCloseChildren: false,
+				Enabled:            true,
+				AutoCloseChildren:  false,
+				CloseChildrenAs:    "done",
+				AutoCompleteParent: false,
 			},
 			Notes: TaskNotesConfig{
 				Folder:          ".task-notes",
@@ -218,6 +285,10 @@ func DefaultConfig() *Config {
 				IncludeInGraph:  false,
 				IncludeInStats:  false,
 			},
+			Dates: TaskDatesConfig{
+				Enabled: true,
+				Format:  "emoji",
+			},
 		},
 		Pomodoro: PomodoroConfig{
 			WorkMinutes:        25,
@@ -304,9 +375,48 @@ func LoadFromFile(path string) (*Config, error) {
 		return nil, err
 	}
 
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	cfg.explicit = make(map[string]bool)
+	if len(doc.Content) > 0 {
+		collectKeys(doc.Content[0], "", cfg.explicit)
+	}
+
+	// Daily notes used to be named with a Go layout
+	if cfg.Daily.FilenameFormat != "" {
+		if !cfg.IsSet("daily.format") {
+			cfg.Daily.Format = goLayoutToMoment(cfg.Daily.FilenameFormat)
+			cfg.explicit["daily.format"] = true
+		}
+		cfg.Daily.FilenameFormat = ""
+	}
+
 	return cfg, nil
 }
 
+// collectKeys records the dotted path of every mapping key under node.
+func collectKeys(node *yaml.Node, prefix string, keys map[string]bool) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i].Value
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		keys[key] = true
+		collectKeys(node.Content[i+1], key, keys)
+	}
+}
+
+// IsSet reports whether key, such as "daily.folder", was set explicitly in
+// the config file rather than left at its default.
+func (c *Config) IsSet(key string) bool {
+	return c.explicit[key]
+}
+
 // Save saves the configuration to the default location.
 func (c *Config) Save() error {
 	configDir, err := getConfigDir()
@@ -476,6 +586,24 @@ func (c *Config) Validate() ValidationErrors {
 		})
 	}
 
+	// Subtask cascade validation
+	validChildStatuses := map[string]bool{"done": true, "cancelled": true}
+	if c.Tasks.Subtasks.CloseChildrenAs != "" && !validChildStatuses[c.Tasks.Subtasks.CloseChildrenAs] {
+		errs = append(errs, ValidationError{
+			Field:   "tasks.subtasks.close_children_as",
+			Message: fmt.Sprintf("invalid subtask status: %s (valid: done, cancelled)", c.Tasks.Subtasks.CloseChildrenAs),
+		})
+	}
+
+	// Task dates validation
+	validDateFormats := map[string]bool{"emoji": true, "dataview": true}
+	if c.Tasks.Dates.Format != "" && !validDateFormats[c.Tasks.Dates.Format] {
+		errs = append(errs, ValidationError{
+			Field:   "tasks.dates.format",
+			Message: fmt.Sprintf("invalid date format: %s (valid: emoji, dataview)", c.Tasks.Dates.Format),
+		})
+	}
+
 	// Task statuses validation
 	if len(c.Tasks.Statuses) == 0 {
 		errs = append(errs, ValidationError{
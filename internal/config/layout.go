@@ -0,0 +1,72 @@
+package config
+
+import (
+	"strings"
+	"unicode"
+)
+
+// goLayoutElements maps the elements of a Go time layout to Moment.js
+// tokens, longest first so "January" wins over "Jan".
+var goLayoutElements = []struct{ layout, moment string }{
+	{"January", "MMMM"},
+	{"Monday", "dddd"},
+	{"2006", "YYYY"},
+	{"002", "DDDD"},
+	{"Jan", "MMM"},
+	{"Mon", "ddd"},
+	{"01", "MM"},
+	{"02", "DD"},
+	{"_2", "D"},
+	{"03", "hh"},
+	{"04", "mm"},
+	{"05", "ss"},
+	{"06", "YY"},
+	{"15", "HH"},
+	{"PM", "A"},
+	{"pm", "a"},
+	{"1", "M"},
+	{"2", "D"},
+	{"3", "h"},
+	{"4", "m"},
+	{"5", "s"},
+}
+
+// goLayoutToMoment converts a Go time layout, as daily.filename_format
+// used to take, to the Moment.js format daily notes are named with.
+// Letters outside layout elements are kept literally in [brackets].
+func goLayoutToMoment(layout string) string {
+	var b strings.Builder
+	var literal strings.Builder
+	flush := func() {
+		if literal.Len() > 0 {
+			b.WriteString("[" + literal.String() + "]")
+			literal.Reset()
+		}
+	}
+
+	for i := 0; i < len(layout); {
+		matched := false
+		for _, e := range goLayoutElements {
+			if strings.HasPrefix(layout[i:], e.layout) {
+				flush()
+				b.WriteString(e.moment)
+				i += len(e.layout)
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+		r := rune(layout[i])
+		if unicode.IsLetter(r) {
+			literal.WriteByte(layout[i])
+		} else {
+			flush()
+			b.WriteByte(layout[i])
+		}
+		i++
+	}
+	flush()
+	return b.String()
+}
@@ -0,0 +1,180 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ObsidianNoteSettings describes where Obsidian creates a kind of note.
+// Nil fields were not set in the vault.
+type ObsidianNoteSettings struct {
+	Folder   *string `json:"folder"`
+	Format   *string `json:"format"` // Moment.js date format
+	Template *string `json:"template"`
+}
+
+// ObsidianSettings holds the parts of a vault's .obsidian configuration
+// that LazyObsidian also needs. Nil fields were not set in the vault.
+type ObsidianSettings struct {
+	Daily     *ObsidianNoteSettings
+	Weekly    *ObsidianNoteSettings
+	Monthly   *ObsidianNoteSettings
+	Quarterly *ObsidianNoteSettings
+	Yearly    *ObsidianNoteSettings
+
+	TemplatesFolder  *string
+	IgnoreFilters    []string
+	AttachmentFolder *string
+}
+
+// periodicNoteSettings is one period in the periodic-notes plugin's data.
+type periodicNoteSettings struct {
+	ObsidianNoteSettings
+	Enabled bool `json:"enabled"`
+}
+
+// ReadObsidianSettings reads the daily notes, templates, app and
+// periodic-notes plugin settings of the vault. Missing files are skipped;
+// files that cannot be decoded are reported in the error, and the
+// settings read from the other files are still returned.
+func ReadObsidianSettings(vaultPath string) (*ObsidianSettings, error) {
+	dir := filepath.Join(vaultPath, ".obsidian")
+	settings := &ObsidianSettings{}
+	var errs []error
+
+	var daily ObsidianNoteSettings
+	if ok, err := readSettingsFile(filepath.Join(dir, "daily-notes.json"), &daily); err != nil {
+		errs = append(errs, err)
+	} else if ok {
+		settings.Daily = &daily
+	}
+
+	var templates struct {
+		Folder *string `json:"folder"`
+	}
+	if _, err := readSettingsFile(filepath.Join(dir, "templates.json"), &templates); err != nil {
+		errs = append(errs, err)
+	}
+	settings.TemplatesFolder = templates.Folder
+
+	var app struct {
+		UserIgnoreFilters    []string `json:"userIgnoreFilters"`
+		AttachmentFolderPath *string  `json:"attachmentFolderPath"`
+	}
+	if _, err := readSettingsFile(filepath.Join(dir, "app.json"), &app); err != nil {
+		errs = append(errs, err)
+	}
+	settings.IgnoreFilters = app.UserIgnoreFilters
+	settings.AttachmentFolder = app.AttachmentFolderPath
+
+	// Enabled periods of the periodic-notes plugin; its daily settings
+	// replace the core plugin's
+	var periodic map[string]periodicNoteSettings
+	if _, err := readSettingsFile(filepath.Join(dir, "plugins", "periodic-notes", "data.json"), &periodic); err != nil {
+		errs = append(errs, err)
+	}
+	for name, target := range map[string]**ObsidianNoteSettings{
+		"daily":     &settings.Daily,
+		"weekly":    &settings.Weekly,
+		"monthly":   &settings.Monthly,
+		"quarterly": &settings.Quarterly,
+		"yearly":    &settings.Yearly,
+	} {
+		if p, ok := periodic[name]; ok && p.Enabled {
+			note := p.ObsidianNoteSettings
+			*target = &note
+		}
+	}
+
+	return settings, errors.Join(errs...)
+}
+
+// readSettingsFile decodes a JSON settings file into v. It reports false
+// without an error when the file does not exist.
+func readSettingsFile(path string, v any) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return true, nil
+}
+
+// ApplyObsidianSettings fills in settings from the vault's Obsidian
+// configuration. Keys set explicitly in the config file are left alone.
+// It returns the keys that were changed.
+func (c *Config) ApplyObsidianSettings(s *ObsidianSettings) []string {
+	var applied []string
+
+	set := func(key string, dst *string, value *string) {
+		if value == nil || c.IsSet(key) {
+			return
+		}
+		*dst = *value
+		applied = append(applied, key)
+	}
+
+	if s.Daily != nil {
+		folder := vaultFolder(s.Daily.Folder)
+		set("daily.folder", &c.Daily.Folder, folder)
+		set("folders.daily", &c.Folders.Daily, folder)
+		set("daily.template", &c.Daily.Template, s.Daily.Template)
+
+		// Obsidian's default format applies when none is set
+		format := "YYYY-MM-DD"
+		if s.Daily.Format != nil && *s.Daily.Format != "" {
+			format = *s.Daily.Format
+		}
+		set("daily.format", &c.Daily.Format, &format)
+	}
+
+	// Periodic notes keep Obsidian's Moment.js formats
+	for _, p := range []struct {
+		key      string
+		settings *ObsidianNoteSettings
+		dst      *PeriodicNoteConfig
+	}{
+		{"periodic.weekly", s.Weekly, &c.Periodic.Weekly},
+		{"periodic.monthly", s.Monthly, &c.Periodic.Monthly},
+		{"periodic.quarterly", s.Quarterly, &c.Periodic.Quarterly},
+		{"periodic.yearly", s.Yearly, &c.Periodic.Yearly},
+	} {
+		if p.settings == nil {
+			continue
+		}
+		set(p.key+".folder", &p.dst.Folder, vaultFolder(p.settings.Folder))
+		if p.settings.Format != nil && *p.settings.Format != "" {
+			set(p.key+".format", &p.dst.Format, p.settings.Format)
+		}
+		set(p.key+".template", &p.dst.Template, p.settings.Template)
+	}
+
+	set("folders.templates", &c.Folders.Templates, vaultFolder(s.TemplatesFolder))
+	set("vault.attachment_folder", &c.Vault.AttachmentFolder, s.AttachmentFolder)
+
+	if len(s.IgnoreFilters) > 0 && !c.IsSet("vault.ignore_filters") {
+		c.Vault.IgnoreFilters = s.IgnoreFilters
+		applied = append(applied, "vault.ignore_filters")
+	}
+
+	return applied
+}
+
+// vaultFolder normalises a folder path from Obsidian's settings, which
+// may carry leading or trailing slashes. An empty path is the vault root.
+func vaultFolder(folder *string) *string {
+	if folder == nil {
+		return nil
+	}
+	f := strings.Trim(filepath.ToSlash(strings.TrimSpace(*folder)), "/")
+	return &f
+}
@@ -54,9 +54,35 @@ func CalculateSectionProgress(section *types.CourseSection) float64 {
 
 	completed := 0
 	for _, lesson := range section.Lessons {
-		if lesson.Status == "x" || lesson.Status == "done" {
+		if IsLessonDone(&lesson) {
 			completed++
 		}
 	}
 	return float64(completed) / float64(len(section.Lessons))
 }
+
+// IsLessonDone reports whether a lesson has been completed.
+func IsLessonDone(lesson *types.CourseLesson) bool {
+	return lesson.Status == "x" || lesson.Status == "done"
+}
+
+// SectionRemainingMinutes returns the length of the section's lessons
+// still to watch. Cancelled lessons are skipped.
+func SectionRemainingMinutes(section *types.CourseSection) int {
+	minutes := 0
+	for _, lesson := range section.Lessons {
+		if !IsLessonDone(&lesson) && lesson.Status != "cancelled" {
+			minutes += lesson.Duration
+		}
+	}
+	return minutes
+}
+
+// RemainingMinutes returns the watch time left in a course.
+func RemainingMinutes(course *types.Course) int {
+	minutes := 0
+	for i := range course.Sections {
+		minutes += SectionRemainingMinutes(&course.Sections[i])
+	}
+	return minutes
+}
@@ -27,6 +27,75 @@ func (t *Tree) Roots() []*types.Goal {
 	return t.roots
 }
 
+// Build assembles goals linked by ParentID into a tree, keeping their
+// order among siblings. Goals whose parent is unknown, or that sit on a
+// cycle of parents, become roots. Each goal's progress and pomodoros are
+// rolled up from its children.
+func Build(flat []types.Goal) *Tree {
+	index := make(map[int64]int, len(flat))
+	for i, g := range flat {
+		index[g.ID] = i
+	}
+
+	parentOf := func(i int) (int, bool) {
+		if flat[i].ParentID == nil {
+			return 0, false
+		}
+		p, ok := index[*flat[i].ParentID]
+		return p, ok && p != i
+	}
+
+	children := make(map[int][]int)
+	tree := NewTree()
+	var roots []int
+	for i := range flat {
+		p, ok := parentOf(i)
+		if !ok || onCycle(i, parentOf) {
+			roots = append(roots, i)
+			continue
+		}
+		children[p] = append(children[p], i)
+	}
+
+	for _, i := range roots {
+		goal := assemble(flat, children, i)
+		tree.AddRoot(&goal)
+	}
+	return tree
+}
+
+// onCycle reports whether following parents from i leads back to i.
+func onCycle(i int, parentOf func(int) (int, bool)) bool {
+	seen := map[int]bool{i: true}
+	for cur := i; ; {
+		p, ok := parentOf(cur)
+		if !ok {
+			return false
+		}
+		if p == i {
+			return true
+		}
+		if seen[p] {
+			return false
+		}
+		seen[p] = true
+		cur = p
+	}
+}
+
+// assemble copies flat[i] with its descendants attached and rolled up.
+func assemble(flat []types.Goal, children map[int][]int, i int) types.Goal {
+	goal := flat[i]
+	goal.Children = append([]types.Goal(nil), goal.Children...)
+	for _, c := range children[i] {
+		goal.Children = append(goal.Children, assemble(flat, children, c))
+	}
+
+	goal.Progress = CalculateProgress(&goal)
+	goal.Pomodoros = AggregatePomodoros(&goal)
+	return goal
+}
+
 // CalculateProgress recursively calculates progress for a goal.
 func CalculateProgress(goal *types.Goal) float64 {
 	if len(goal.Children) == 0 {
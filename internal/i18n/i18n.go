@@ -130,13 +130,17 @@ type CoursesTranslations struct {
 	Completed string `yaml:"completed"`
 	Lessons   string `yaml:"lessons"`
 	Sections  string `yaml:"sections"`
+	Remaining string `yaml:"remaining"`
 	NoCourses string `yaml:"no_courses"`
 }
 
 // BooksTranslations holds books-related translations.
 type BooksTranslations struct {
 	Reading   string `yaml:"reading"`
+	Paused    string `yaml:"paused"`
+	ToRead    string `yaml:"to_read"`
 	Completed string `yaml:"completed"`
+	Abandoned string `yaml:"abandoned"`
 	Pages     string `yaml:"pages"`
 	Chapters  string `yaml:"chapters"`
 	NoBooks   string `yaml:"no_books"`
@@ -389,11 +393,15 @@ func defaultEnglish() *Translations {
 			Completed: "Completed",
 			Lessons:   "{completed}/{total} lessons",
 			Sections:  "{count} sections",
+			Remaining: "{time} left",
 			NoCourses: "No courses",
 		},
 		Books: BooksTranslations{
 			Reading:   "Currently Reading",
+			Paused:    "Paused",
+			ToRead:    "To Read",
 			Completed: "Completed",
+			Abandoned: "Abandoned",
 			Pages:     "p.{current}/{total}",
 			Chapters:  "{count} chapters",
 			NoBooks:   "No books",
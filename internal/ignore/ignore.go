@@ -0,0 +1,254 @@
+// Package ignore decides which vault files LazyObsidian leaves alone.
+//
+// Rules come from three places, in order:
+//
+//   - built-in rules for the templates folder and node_modules,
+//   - Obsidian's "Excluded files" (userIgnoreFilters): path prefixes, or
+//     regular expressions written as /regex/,
+//   - .lazyobsidian/ignore in the vault, with gitignore syntax.
+//
+// As with gitignore, a later "!pattern" re-includes what earlier rules
+// ignored, except inside an ignored directory. Hidden directories are
+// always ignored.
+package ignore
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/BioWare/lazyobsidian/internal/config"
+	"github.com/BioWare/lazyobsidian/internal/logging"
+)
+
+// FileName is the ignore file's path inside the vault.
+const FileName = ".lazyobsidian/ignore"
+
+// rule is one ignore rule.
+type rule struct {
+	re      *regexp.Regexp // matched against the vault-relative path
+	negate  bool
+	dirOnly bool
+
+	// obsidian marks Obsidian's filters, which match a directory written
+	// as "dir/" too
+	obsidian bool
+}
+
+// Matcher tells whether vault paths are ignored. A nil Matcher ignores
+// only hidden directories.
+type Matcher struct {
+	vaultPath string
+	rules     []rule
+}
+
+// New builds the matcher for a vault from its configuration and ignore
+// file. A missing ignore file is not an error.
+func New(vaultPath string, cfg *config.Config) (*Matcher, error) {
+	m := &Matcher{vaultPath: vaultPath}
+
+	m.AddPattern("node_modules/")
+	if cfg != nil {
+		if templates := strings.Trim(filepath.ToSlash(cfg.Folders.Templates), "/"); templates != "" {
+			m.AddPattern("/" + templates + "/")
+		}
+		for _, filter := range cfg.Vault.IgnoreFilters {
+			if err := m.AddObsidianFilter(filter); err != nil {
+				return m, err
+			}
+		}
+	}
+
+	f, err := os.Open(filepath.Join(vaultPath, filepath.FromSlash(FileName)))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return m, nil
+		}
+		return m, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		m.AddPattern(scanner.Text())
+	}
+	return m, scanner.Err()
+}
+
+// AddObsidianFilter adds an entry of Obsidian's excluded files: a
+// /regular expression/ or a path prefix.
+func (m *Matcher) AddObsidianFilter(filter string) error {
+	filter = strings.TrimSpace(filter)
+	if filter == "" {
+		return nil
+	}
+	if len(filter) > 2 && strings.HasPrefix(filter, "/") && strings.HasSuffix(filter, "/") {
+		re, err := regexp.Compile(filter[1 : len(filter)-1])
+		if err != nil {
+			return fmt.Errorf("invalid excluded files filter %q: %w", filter, err)
+		}
+		m.rules = append(m.rules, rule{re: re, obsidian: true})
+		return nil
+	}
+	m.rules = append(m.rules, rule{
+		re:       regexp.MustCompile("^" + regexp.QuoteMeta(strings.TrimPrefix(filter, "/"))),
+		obsidian: true,
+	})
+	return nil
+}
+
+// AddPattern adds a line in gitignore syntax. Blank lines and comments
+// are skipped.
+func (m *Matcher) AddPattern(line string) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return
+	}
+
+	var r rule
+	if strings.HasPrefix(line, "!") {
+		r.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return
+	}
+
+	// A slash anywhere but the end anchors the pattern to the vault root;
+	// otherwise it matches at any depth
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	prefix := "^(?:.*/)?"
+	if anchored {
+		prefix = "^"
+	}
+	r.re = regexp.MustCompile(prefix + globToRegexp(line) + "$")
+	m.rules = append(m.rules, r)
+}
+
+// globToRegexp converts a gitignore glob to a regular expression.
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		ch := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "/**") && i+3 == len(glob):
+			b.WriteString("/.*")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case ch == '*':
+			b.WriteString("[^/]*")
+		case ch == '?':
+			b.WriteString("[^/]")
+		case ch == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end == -1 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case ch == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+	return b.String()
+}
+
+// Ignored reports whether path, absolute or relative to the vault, is
+// ignored. A file inside an ignored directory is ignored too.
+func (m *Matcher) Ignored(path string, isDir bool) bool {
+	if m == nil {
+		return isDir && strings.HasPrefix(filepath.Base(path), ".") && filepath.Base(path) != "."
+	}
+
+	rel := path
+	if filepath.IsAbs(path) {
+		r, err := filepath.Rel(m.vaultPath, path)
+		if err != nil || strings.HasPrefix(r, "..") {
+			return false
+		}
+		rel = r
+	}
+	rel = filepath.ToSlash(rel)
+	if rel == "." || rel == "" {
+		return false
+	}
+
+	parts := strings.Split(rel, "/")
+	for i := range parts {
+		dir := i < len(parts)-1 || isDir
+		if dir && strings.HasPrefix(parts[i], ".") {
+			return true
+		}
+		if m.match(strings.Join(parts[:i+1], "/"), dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// Walk calls fn for every file under root that is not ignored, without
+// entering ignored folders. An unreadable file or folder is skipped with
+// a warning rather than ending the walk; only an unreadable root is an
+// error. An error returned by fn ends the walk.
+func (m *Matcher) Walk(root string, fn func(path string, info os.FileInfo) error) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			logging.Warn("Skipping %s: %v", path, err)
+			if info != nil && info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			if path != root && m.Ignored(path, true) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if m.Ignored(path, false) {
+			return nil
+		}
+		return fn(path, info)
+	})
+}
+
+// match applies the rules to one path; the last matching rule wins.
+func (m *Matcher) match(rel string, isDir bool) bool {
+	ignored := false
+	for _, r := range m.rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		// Directories also match as "dir/", for filters like "Archive/"
+		if r.re.MatchString(rel) || r.obsidian && isDir && r.re.MatchString(rel+"/") {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
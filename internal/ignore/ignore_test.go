@@ -0,0 +1,248 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/BioWare/lazyobsidian/internal/config"
+)
+
+type check struct {
+	path  string
+	isDir bool
+	want  bool
+}
+
+func matcher(patterns ...string) *Matcher {
+	m := &Matcher{vaultPath: "/vault"}
+	for _, p := range patterns {
+		m.AddPattern(p)
+	}
+	return m
+}
+
+func run(t *testing.T, name string, m *Matcher, checks []check) {
+	t.Helper()
+	for _, c := range checks {
+		if got := m.Ignored(c.path, c.isDir); got != c.want {
+			t.Errorf("%s: Ignored(%q, %v) = %v, want %v", name, c.path, c.isDir, got, c.want)
+		}
+	}
+}
+
+func TestPatterns(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		checks   []check
+	}{
+		{
+			name:     "name at any depth",
+			patterns: []string{"drafts"},
+			checks: []check{
+				{"drafts", true, true},
+				{"a/b/drafts", false, true},
+				{"a/drafts/note.md", false, true},
+				{"mydrafts", true, false},
+			},
+		},
+		{
+			name:     "anchored to the root",
+			patterns: []string{"/Archive", "Notes/old"},
+			checks: []check{
+				{"Archive/note.md", false, true},
+				{"Projects/Archive/note.md", false, false},
+				{"Notes/old/x.md", false, true},
+				{"a/Notes/old/x.md", false, false},
+			},
+		},
+		{
+			name:     "directories only",
+			patterns: []string{"build/"},
+			checks: []check{
+				{"build", true, true},
+				{"build", false, false},
+				{"a/build/x.md", false, true},
+				{"a/build", false, false},
+			},
+		},
+		{
+			name:     "globs",
+			patterns: []string{"*.tmp.md", "Daily/202?-*", "[Dd]raft-[!0-9]*"},
+			checks: []check{
+				{"a.tmp.md", false, true},
+				{"x/a.tmp.md", false, true},
+				{"a.md", false, false},
+				{"Daily/2024-01-01.md", false, true},
+				{"Daily/1999-01-01.md", false, false},
+				{"Daily/sub/2024-01-01.md", false, false},
+				{"draft-a.md", false, true},
+				{"Draft-b.md", false, true},
+				{"draft-1.md", false, false},
+			},
+		},
+		{
+			name:     "double stars",
+			patterns: []string{"**/cache", "Assets/**", "a/**/z.md"},
+			checks: []check{
+				{"cache", true, true},
+				{"x/y/cache", true, true},
+				{"Assets/img/x.png", false, true},
+				{"Assets", true, false},
+				{"a/z.md", false, true},
+				{"a/b/c/z.md", false, true},
+				{"b/z.md", false, false},
+			},
+		},
+		{
+			name:     "negation",
+			patterns: []string{"*.md", "!keep.md", "Private/", "!Private/public.md", "Assets/**", "!Assets/logo.png"},
+			checks: []check{
+				{"note.md", false, true},
+				{"keep.md", false, false},
+				{"sub/keep.md", false, false},
+				{"Assets/icon.png", false, true},
+				{"Assets/logo.png", false, false},
+				// A file in an ignored directory cannot be re-included
+				{"Private/public.md", false, true},
+			},
+		},
+		{
+			name:     "last rule wins",
+			patterns: []string{"!x.md", "x.md"},
+			checks:   []check{{"x.md", false, true}},
+		},
+		{
+			name:     "comments, blanks and escapes",
+			patterns: []string{"# comment", "", "   ", `\#hash.md`, `\!bang.md`, "trailing.md   "},
+			checks: []check{
+				{"# comment", false, false},
+				{"#hash.md", false, true},
+				{"!bang.md", false, true},
+				{"trailing.md", false, true},
+			},
+		},
+		{
+			name: "hidden directories",
+			checks: []check{
+				{".obsidian", true, true},
+				{".trash/note.md", false, true},
+				{"a/.git/config", false, true},
+				{".hidden.md", false, false},
+				{"/vault/.obsidian/app.json", false, true},
+				{"/vault/note.md", false, false},
+				{"/elsewhere/.git", true, false},
+			},
+		},
+	}
+	for _, tt := range tests {
+		run(t, tt.name, matcher(tt.patterns...), tt.checks)
+	}
+}
+
+func TestObsidianFilters(t *testing.T) {
+	m := &Matcher{vaultPath: "/vault"}
+	for _, f := range []string{"Archive/", "/Inbox", `/\.excalidraw\.md$/`, " "} {
+		if err := m.AddObsidianFilter(f); err != nil {
+			t.Fatalf("AddObsidianFilter(%q): %v", f, err)
+		}
+	}
+	run(t, "filters", m, []check{
+		{"Archive", true, true},
+		{"Archive/2024/note.md", false, true},
+		{"Archived.md", false, false},
+		{"Inbox.md", false, true},
+		{"Inbox/x.md", false, true},
+		{"Notes/Inbox.md", false, false},
+		{"Drawing.excalidraw.md", false, true},
+		{"Drawing.md", false, false},
+	})
+
+	if err := m.AddObsidianFilter("/[/"); err == nil {
+		t.Error("AddObsidianFilter accepted an invalid regular expression")
+	}
+}
+
+func TestNilMatcher(t *testing.T) {
+	var m *Matcher
+	run(t, "nil", m, []check{
+		{".obsidian", true, true},
+		{"a/.git", true, true},
+		{".", true, false},
+		{"node_modules", true, false},
+		{".note.md", false, false},
+	})
+}
+
+func TestNew(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, ".lazyobsidian"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	ignoreFile := "Scratch/\n# keep the template guide\n!Templates/README.md\n"
+	if err := os.WriteFile(filepath.Join(dir, filepath.FromSlash(FileName)), []byte(ignoreFile), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Folders.Templates = "Templates/"
+	cfg.Vault.IgnoreFilters = []string{"Old/"}
+	m, err := New(dir, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	run(t, "new", m, []check{
+		{"node_modules", true, true},
+		{"a/node_modules/x.md", false, true},
+		{"Templates", true, true},
+		{"Templates/README.md", false, true},
+		{"Notes/Templates", true, false},
+		{"Old/x.md", false, true},
+		{"Scratch/x.md", false, true},
+		{"Notes/x.md", false, false},
+	})
+
+	cfg.Vault.IgnoreFilters = []string{"/(/"}
+	if _, err := New(dir, cfg); err == nil {
+		t.Error("New accepted an invalid excluded files filter")
+	}
+}
+
+func TestWalk(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range []string{"a.md", "skip.md", "Notes/b.md", "Notes/Scratch/c.md", ".obsidian/app.json", "node_modules/x/d.md"} {
+		path := filepath.Join(dir, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m, err := New(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.AddPattern("skip.md")
+	m.AddPattern("Scratch/")
+
+	var got []string
+	err = m.Walk(dir, func(path string, info os.FileInfo) error {
+		rel, _ := filepath.Rel(dir, path)
+		got = append(got, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Notes/b.md", "a.md"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Walk visited %v, want %v", got, want)
+	}
+
+	if err := m.Walk(filepath.Join(dir, "missing"), func(string, os.FileInfo) error { return nil }); err == nil {
+		t.Error("Walk of a missing root succeeded")
+	}
+}
@@ -0,0 +1,337 @@
+// Package indexer keeps the cache in sync with the vault.
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+
+	"github.com/BioWare/lazyobsidian/internal/cache"
+	"github.com/BioWare/lazyobsidian/internal/logging"
+	"github.com/BioWare/lazyobsidian/internal/vault"
+	"github.com/BioWare/lazyobsidian/pkg/types"
+)
+
+// Progress describes how far an indexing run has got.
+type Progress struct {
+	Total   int    // markdown files found in the vault
+	Done    int    // files checked so far
+	Parsed  int    // files parsed because they changed
+	Skipped int    // files unchanged since they were cached
+	Path    string // file just checked
+}
+
+// Result summarises an indexing run.
+type Result struct {
+	Total   int
+	Parsed  int
+	Skipped int
+	Removed int
+	Errors  []error // files that failed to parse or save
+}
+
+// Indexer parses changed vault files into the cache with a pool of
+// workers. Files whose modification time and size match the cache are
+// skipped; when only the modification time differs, the content hash
+// decides.
+//
+// All cache writes go through the indexer, so it also serves the file
+// watcher once the initial run is done.
+type Indexer struct {
+	vaultPath string
+	parser    *vault.Parser
+	cache     *cache.Cache
+	links     *vault.LinkResolver
+
+	// Workers is the number of files parsed concurrently.
+	// Zero means one per CPU.
+	Workers int
+
+	// OnProgress, if set, is called after each file is checked.
+	OnProgress func(Progress)
+
+	mu sync.Mutex // serialises cache writes
+}
+
+// New creates an indexer for the vault.
+func New(vaultPath string, p *vault.Parser, c *cache.Cache, links *vault.LinkResolver) *Indexer {
+	return &Indexer{
+		vaultPath: vaultPath,
+		parser:    p,
+		cache:     c,
+		links:     links,
+	}
+}
+
+// job is a file that may need parsing.
+type job struct {
+	path  string
+	info  os.FileInfo
+	stamp *cache.FileStamp // cached version, if any
+}
+
+// outcome is a worker's result for one job.
+type outcome struct {
+	job     job
+	file    *types.File
+	err     error
+	touched bool // content unchanged; only the modification time moved
+}
+
+// Run brings the cache up to date with the vault. It returns early with
+// ctx.Err() when ctx is cancelled; work done until then is kept.
+func (ix *Indexer) Run(ctx context.Context) (*Result, error) {
+	result := &Result{}
+
+	stamps, err := ix.cache.GetFileStamps()
+	if err != nil {
+		return result, fmt.Errorf("failed to read cache: %w", err)
+	}
+
+	// Cached notes and attachments are link targets even when unchanged
+	ix.registerLinkTargets()
+
+	found, err := ix.walk(ctx)
+	if err != nil {
+		return result, err
+	}
+	result.Total = len(found)
+
+	// Files with matching mtime and size are skipped outright
+	var jobs []job
+	progress := Progress{Total: len(found)}
+	for _, j := range found {
+		stamp, ok := stamps[j.path]
+		delete(stamps, j.path)
+		if ok && stamp.Size == j.info.Size() && stamp.ModifiedAt.Equal(j.info.ModTime()) {
+			result.Skipped++
+			continue
+		}
+		if ok {
+			j.stamp = &stamp
+		}
+		jobs = append(jobs, j)
+	}
+	progress.Done = result.Skipped
+	progress.Skipped = result.Skipped
+	ix.report(progress)
+
+	// Whatever is left in stamps no longer exists in the vault
+	for path := range stamps {
+		if err := ix.Remove(path); err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		result.Removed++
+	}
+
+	// Parse changed files; links are resolved once every note is known
+	pending := make(map[string][]types.Link)
+	for out := range ix.parseAll(ctx, jobs) {
+		progress.Done++
+		progress.Path = out.job.path
+
+		switch {
+		case out.touched:
+			result.Skipped++
+			progress.Skipped++
+			if err := ix.touch(out.job); err != nil {
+				result.Errors = append(result.Errors, fmt.Errorf("%s: %w", out.job.path, err))
+			}
+
+		case out.file == nil:
+			result.Errors = append(result.Errors, fmt.Errorf("%s: %w", out.job.path, out.err))
+
+		default:
+			if out.err != nil {
+				// Malformed frontmatter: the note is still indexed
+				result.Errors = append(result.Errors, out.err)
+			}
+			ix.links.AddFile(out.file)
+			if err := ix.save(out.file); err != nil {
+				result.Errors = append(result.Errors, fmt.Errorf("%s: %w", out.job.path, err))
+				break
+			}
+			pending[out.file.Path] = out.file.Links
+			result.Parsed++
+			progress.Parsed++
+		}
+
+		ix.report(progress)
+	}
+
+	for path, links := range pending {
+		for i := range links {
+			ix.links.Resolve(path, &links[i])
+		}
+		if err := ix.saveLinks(path, links); err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("%s: %w", path, err))
+		}
+	}
+	ix.ResolveUnresolvedLinks()
+
+	return result, ctx.Err()
+}
+
+// walk lists the markdown files in the vault, skipping hidden directories
+// and ignored files. Cached files that are now ignored are dropped by Run
+// like deleted ones.
+func (ix *Indexer) walk(ctx context.Context) ([]job, error) {
+	var found []job
+	err := ix.parser.WalkNotes(ix.vaultPath, func(path string, info os.FileInfo) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		found = append(found, job{path: path, info: info})
+		return nil
+	})
+	return found, err
+}
+
+// parseAll parses jobs on a pool of workers. The returned channel is
+// closed once every job is done or ctx is cancelled.
+func (ix *Indexer) parseAll(ctx context.Context, jobs []job) <-chan outcome {
+	workers := ix.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	queue := make(chan job)
+	results := make(chan outcome)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range queue {
+				select {
+				case results <- ix.parse(j):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(queue)
+		for _, j := range jobs {
+			select {
+			case queue <- j:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	return results
+}
+
+// parse checks one file against its cached version and parses it if the
+// content changed.
+func (ix *Indexer) parse(j job) outcome {
+	if j.stamp != nil && j.stamp.Hash != "" && j.stamp.Size == j.info.Size() {
+		if hash, err := vault.HashFile(j.path); err == nil && hash == j.stamp.Hash {
+			return outcome{job: j, touched: true}
+		}
+	}
+
+	file, err := ix.parser.ParseFile(j.path)
+	if err != nil && !vault.IsFrontmatterError(err) {
+		return outcome{job: j, err: err}
+	}
+	return outcome{job: j, file: file, err: err}
+}
+
+func (ix *Indexer) report(p Progress) {
+	if ix.OnProgress != nil {
+		ix.OnProgress(p)
+	}
+}
+
+// registerLinkTargets adds cached notes and vault attachments to the
+// link resolver.
+func (ix *Indexer) registerLinkTargets() {
+	files, err := ix.cache.GetAllFiles()
+	if err != nil {
+		logging.Error("Failed to load files for link resolution: %v", err)
+	}
+	for _, f := range files {
+		ix.links.AddFile(f)
+	}
+	if err := ix.links.IndexAttachments(); err != nil {
+		logging.Warn("Failed to index attachments: %v", err)
+	}
+}
+
+// Save resolves a parsed file's links and stores it in the cache.
+// Ignored files are not cached.
+func (ix *Indexer) Save(file *types.File) error {
+	if ix.parser.Ignored(file.Path, false) {
+		return nil
+	}
+	ix.links.AddFile(file)
+	ix.links.ResolveLinks(file)
+	return ix.save(file)
+}
+
+func (ix *Indexer) save(file *types.File) error {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	_, err := ix.cache.SaveFile(file)
+	return err
+}
+
+func (ix *Indexer) saveLinks(path string, links []types.Link) error {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	return ix.cache.SaveLinks(path, links)
+}
+
+func (ix *Indexer) touch(j job) error {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	return ix.cache.TouchFile(j.path, j.info.ModTime())
+}
+
+// Remove drops a deleted file from the cache and the link resolver.
+// Links into it become unresolved.
+func (ix *Indexer) Remove(path string) error {
+	ix.links.Remove(path)
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	return ix.cache.InvalidateFile(path)
+}
+
+// ResolveUnresolvedLinks retries resolution for every cached file with
+// unresolved or ambiguous links, after notes were added or removed.
+func (ix *Indexer) ResolveUnresolvedLinks() {
+	sources, err := ix.cache.GetUnresolvedLinkSources()
+	if err != nil {
+		logging.Error("Failed to load unresolved links: %v", err)
+		return
+	}
+
+	for _, source := range sources {
+		links, err := ix.cache.GetLinks(source)
+		if err != nil {
+			logging.Error("Failed to load links of %s: %v", source, err)
+			continue
+		}
+		for i := range links {
+			ix.links.Resolve(source, &links[i])
+		}
+		if err := ix.saveLinks(source, links); err != nil {
+			logging.Error("Failed to save links of %s: %v", source, err)
+		}
+	}
+}
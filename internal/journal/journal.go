@@ -0,0 +1,231 @@
+// Package journal keeps a persistent record of the changes LazyObsidian
+// makes to vault notes, so that they can be undone and redone, even after
+// a restart.
+//
+// Each entry is one operation, such as toggling a task, with the hunks it
+// changed in each file. Undoing an entry marks it undone; the undone
+// entries at the end of the journal can be redone until a new operation
+// is recorded.
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileName is the journal's path inside the vault.
+const FileName = ".lazyobsidian/journal.jsonl"
+
+// maxEntries is how many operations the journal keeps.
+const maxEntries = 200
+
+// contextLines is how many unchanged lines a hunk keeps on each side of
+// a change, to find it again if lines were added or removed elsewhere.
+const contextLines = 2
+
+// Entry is one recorded operation.
+type Entry struct {
+	ID      int64     `json:"id"`
+	Time    time.Time `json:"time"`
+	Op      string    `json:"op"` // what was done, e.g. "toggle task"
+	Changes []Change  `json:"changes"`
+	Undone  bool      `json:"undone,omitempty"`
+}
+
+// Change is a hunk of one file: Before was replaced by After, starting at
+// Line. Both include the same unchanged context lines.
+type Change struct {
+	Path    string   `json:"path"` // relative to the vault
+	Line    int      `json:"line"` // 1-based
+	Before  []string `json:"before"`
+	After   []string `json:"after"`
+	Created bool     `json:"created,omitempty"` // the file did not exist before
+}
+
+// Diff returns the hunk that turns before into after, or false if they
+// are equal.
+func Diff(path string, before, after []string) (Change, bool) {
+	prefix := 0
+	for prefix < len(before) && prefix < len(after) && before[prefix] == after[prefix] {
+		prefix++
+	}
+	if prefix == len(before) && prefix == len(after) {
+		return Change{}, false
+	}
+	suffix := 0
+	for suffix < len(before)-prefix && suffix < len(after)-prefix &&
+		before[len(before)-1-suffix] == after[len(after)-1-suffix] {
+		suffix++
+	}
+
+	start := max(prefix-contextLines, 0)
+	trail := min(suffix, contextLines)
+	return Change{
+		Path:   path,
+		Line:   start + 1,
+		Before: append([]string{}, before[start:len(before)-suffix+trail]...),
+		After:  append([]string{}, after[start:len(after)-suffix+trail]...),
+	}, true
+}
+
+// Journal is the journal file of a vault. It is safe for concurrent use
+// within a process; other processes see its changes on their next read.
+type Journal struct {
+	path string
+	mu   sync.Mutex
+}
+
+// New returns the journal of a vault. The file is created on the first
+// recorded operation.
+func New(vaultPath string) *Journal {
+	return &Journal{path: filepath.Join(vaultPath, filepath.FromSlash(FileName))}
+}
+
+// Record appends an operation, dropping the oldest ones beyond the
+// journal's size. Operations undone before it can no longer be redone, as
+// with any undo stack, and are dropped too.
+func (j *Journal) Record(entry Entry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	entries, err := j.load()
+	if err != nil {
+		return err
+	}
+	entry.ID = 1
+	if len(entries) > 0 {
+		entry.ID = entries[len(entries)-1].ID + 1
+	}
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	for len(entries) > 0 && entries[len(entries)-1].Undone {
+		entries = entries[:len(entries)-1]
+	}
+	entries = append(entries, entry)
+	if len(entries) > maxEntries {
+		entries = entries[len(entries)-maxEntries:]
+	}
+	return j.save(entries)
+}
+
+// Entries returns the recorded operations, oldest first.
+func (j *Journal) Entries() ([]Entry, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.load()
+}
+
+// LastDone returns the most recent operation that is not undone.
+func (j *Journal) LastDone() (Entry, bool, error) {
+	entries, err := j.Entries()
+	if err != nil {
+		return Entry{}, false, err
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		if !entries[i].Undone {
+			return entries[i], true, nil
+		}
+	}
+	return Entry{}, false, nil
+}
+
+// NextRedo returns the operation a redo would apply again: the oldest of
+// the undone operations at the end of the journal.
+func (j *Journal) NextRedo() (Entry, bool, error) {
+	entries, err := j.Entries()
+	if err != nil {
+		return Entry{}, false, err
+	}
+	i := len(entries)
+	for i > 0 && entries[i-1].Undone {
+		i--
+	}
+	if i == len(entries) {
+		return Entry{}, false, nil
+	}
+	return entries[i], true, nil
+}
+
+// SetUndone marks an operation undone or done again.
+func (j *Journal) SetUndone(id int64, undone bool) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	entries, err := j.load()
+	if err != nil {
+		return err
+	}
+	for i := range entries {
+		if entries[i].ID == id {
+			entries[i].Undone = undone
+			return j.save(entries)
+		}
+	}
+	return fmt.Errorf("journal entry %d not found", id)
+}
+
+func (j *Journal) load() ([]Entry, error) {
+	f, err := os.Open(j.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("failed to read journal: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// save replaces the journal file through a temporary file, so that it is
+// never left half written.
+func (j *Journal) save(entries []Entry) error {
+	if err := os.MkdirAll(filepath.Dir(j.path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(j.path), ".journal-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), j.path)
+}
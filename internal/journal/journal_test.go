@@ -0,0 +1,150 @@
+package journal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after []string
+		want          Change
+	}{
+		{
+			name:   "changed line with context",
+			before: []string{"a", "b", "c", "d", "e", "f", "g"},
+			after:  []string{"a", "b", "c", "X", "e", "f", "g"},
+			want: Change{Line: 2, Before: []string{"b", "c", "d", "e", "f"},
+				After: []string{"b", "c", "X", "e", "f"}},
+		},
+		{
+			name:   "insertion at the start",
+			before: []string{"a", "b", "c"},
+			after:  []string{"X", "a", "b", "c"},
+			want:   Change{Line: 1, Before: []string{"a", "b"}, After: []string{"X", "a", "b"}},
+		},
+		{
+			name:   "deletion at the end",
+			before: []string{"a", "b", "c", "d"},
+			after:  []string{"a", "b", "c"},
+			want:   Change{Line: 2, Before: []string{"b", "c", "d"}, After: []string{"b", "c"}},
+		},
+		{
+			name:   "new file",
+			before: []string{""},
+			after:  []string{"x", ""},
+			want:   Change{Line: 1, Before: []string{""}, After: []string{"x", ""}},
+		},
+	}
+	for _, tt := range tests {
+		got, ok := Diff("note.md", tt.before, tt.after)
+		tt.want.Path = "note.md"
+		if !ok || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Diff = %+v (%v), want %+v", tt.name, got, ok, tt.want)
+		}
+	}
+
+	if _, ok := Diff("note.md", []string{"a", "b"}, []string{"a", "b"}); ok {
+		t.Error("Diff of equal lines reported a change")
+	}
+}
+
+// ops returns the operations of the journal, oldest first, with an "~"
+// before undone ones.
+func ops(t *testing.T, j *Journal) []string {
+	t.Helper()
+	entries, err := j.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		name := e.Op
+		if e.Undone {
+			name = "~" + name
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+func record(t *testing.T, j *Journal, op string) Entry {
+	t.Helper()
+	if err := j.Record(Entry{Op: op}); err != nil {
+		t.Fatal(err)
+	}
+	entry, ok, err := j.LastDone()
+	if err != nil || !ok {
+		t.Fatalf("LastDone after recording %q: %v, %v", op, ok, err)
+	}
+	return entry
+}
+
+func TestUndoRedoStack(t *testing.T) {
+	j := New(t.TempDir())
+	if _, ok, err := j.LastDone(); ok || err != nil {
+		t.Fatalf("empty journal: LastDone = %v, %v", ok, err)
+	}
+
+	record(t, j, "one")
+	two := record(t, j, "two")
+	three := record(t, j, "three")
+
+	// Undo the last two, newest first
+	for _, e := range []Entry{three, two} {
+		last, _, _ := j.LastDone()
+		if last.ID != e.ID {
+			t.Fatalf("LastDone = %q, want %q", last.Op, e.Op)
+		}
+		if err := j.SetUndone(e.ID, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := ops(t, j), []string{"one", "~two", "~three"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("after undo: %v, want %v", got, want)
+	}
+
+	// Redo applies the oldest undone operation first
+	next, ok, err := j.NextRedo()
+	if err != nil || !ok || next.ID != two.ID {
+		t.Fatalf("NextRedo = %q, %v, %v, want %q", next.Op, ok, err, "two")
+	}
+	if err := j.SetUndone(two.ID, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// A new operation drops those still undone
+	four := record(t, j, "four")
+	if got, want := ops(t, j), []string{"one", "two", "four"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("after recording: %v, want %v", got, want)
+	}
+	if four.ID <= three.ID {
+		t.Errorf("new entry ID %d reuses a dropped entry's ID %d", four.ID, three.ID)
+	}
+	if _, ok, _ := j.NextRedo(); ok {
+		t.Error("NextRedo found an operation after a new one was recorded")
+	}
+	if err := j.SetUndone(three.ID, false); err == nil {
+		t.Error("SetUndone of a dropped entry succeeded")
+	}
+}
+
+func TestRecordKeepsLastEntries(t *testing.T) {
+	j := New(t.TempDir())
+	for i := 0; i < maxEntries+5; i++ {
+		if err := j.Record(Entry{Op: "op"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := j.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != maxEntries {
+		t.Fatalf("journal keeps %d entries, want %d", len(entries), maxEntries)
+	}
+	if first, last := entries[0].ID, entries[len(entries)-1].ID; first != 6 || last != maxEntries+5 {
+		t.Errorf("journal keeps entries %d to %d, want 6 to %d", first, last, maxEntries+5)
+	}
+}
@@ -0,0 +1,259 @@
+package periodic
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BioWare/lazyobsidian/pkg/types"
+)
+
+// Note names use Moment.js formats, as Obsidian's periodic notes do, since
+// Go layouts cannot express week numbers or quarters. Locale weeks
+// ("gggg", "ww") are read as ISO weeks, and a weekday name picks the day
+// in a week. Times of day can be formatted but are ignored when parsing.
+
+// formatToken is a Moment.js token: how to print it and the pattern that
+// reads it back.
+type formatToken struct {
+	name    string
+	format  func(t time.Time) string
+	pattern string
+	field   dateField
+}
+
+// dateField is the part of a date a token sets when parsing.
+type dateField int
+
+const (
+	fieldNone dateField = iota
+	fieldYear
+	fieldShortYear
+	fieldWeekYear
+	fieldQuarter
+	fieldMonth
+	fieldMonthName
+	fieldMonthShort
+	fieldWeek
+	fieldWeekday
+	fieldDay
+)
+
+var monthPattern = `(January|February|March|April|May|June|July|August|September|October|November|December)`
+
+// formatTokens are matched longest first.
+var formatTokens = []formatToken{
+	{"YYYY", func(t time.Time) string { return fmt.Sprintf("%04d", t.Year()) }, `(\d{4})`, fieldYear},
+	{"GGGG", func(t time.Time) string { y, _ := t.ISOWeek(); return fmt.Sprintf("%04d", y) }, `(\d{4})`, fieldWeekYear},
+	{"gggg", func(t time.Time) string { y, _ := t.ISOWeek(); return fmt.Sprintf("%04d", y) }, `(\d{4})`, fieldWeekYear},
+	{"MMMM", func(t time.Time) string { return t.Month().String() }, monthPattern, fieldMonthName},
+	{"dddd", func(t time.Time) string { return t.Weekday().String() }, `([A-Za-z]+)`, fieldWeekday},
+	{"DDDD", func(t time.Time) string { return fmt.Sprintf("%03d", t.YearDay()) }, `\d{3}`, fieldNone},
+	{"MMM", func(t time.Time) string { return t.Format("Jan") }, `([A-Z][a-z]{2})`, fieldMonthShort},
+	{"ddd", func(t time.Time) string { return t.Format("Mon") }, `([A-Za-z]{3})`, fieldWeekday},
+	{"YY", func(t time.Time) string { return fmt.Sprintf("%02d", t.Year()%100) }, `(\d{2})`, fieldShortYear},
+	{"MM", func(t time.Time) string { return fmt.Sprintf("%02d", int(t.Month())) }, `(\d{2})`, fieldMonth},
+	{"DD", func(t time.Time) string { return fmt.Sprintf("%02d", t.Day()) }, `(\d{2})`, fieldDay},
+	{"Do", func(t time.Time) string { return ordinal(t.Day()) }, `(\d{1,2})(?:st|nd|rd|th)`, fieldDay},
+	{"WW", func(t time.Time) string { _, w := t.ISOWeek(); return fmt.Sprintf("%02d", w) }, `(\d{2})`, fieldWeek},
+	{"ww", func(t time.Time) string { _, w := t.ISOWeek(); return fmt.Sprintf("%02d", w) }, `(\d{2})`, fieldWeek},
+	{"HH", func(t time.Time) string { return t.Format("15") }, `\d{2}`, fieldNone},
+	{"hh", func(t time.Time) string { return t.Format("03") }, `\d{2}`, fieldNone},
+	{"mm", func(t time.Time) string { return t.Format("04") }, `\d{2}`, fieldNone},
+	{"ss", func(t time.Time) string { return t.Format("05") }, `\d{2}`, fieldNone},
+	{"Q", func(t time.Time) string { return strconv.Itoa((int(t.Month())-1)/3 + 1) }, `([1-4])`, fieldQuarter},
+	{"M", func(t time.Time) string { return strconv.Itoa(int(t.Month())) }, `(\d{1,2})`, fieldMonth},
+	{"D", func(t time.Time) string { return strconv.Itoa(t.Day()) }, `(\d{1,2})`, fieldDay},
+	{"W", func(t time.Time) string { _, w := t.ISOWeek(); return strconv.Itoa(w) }, `(\d{1,2})`, fieldWeek},
+	{"w", func(t time.Time) string { _, w := t.ISOWeek(); return strconv.Itoa(w) }, `(\d{1,2})`, fieldWeek},
+	{"H", func(t time.Time) string { return strconv.Itoa(t.Hour()) }, `\d{1,2}`, fieldNone},
+	{"h", func(t time.Time) string { return t.Format("3") }, `\d{1,2}`, fieldNone},
+	{"m", func(t time.Time) string { return strconv.Itoa(t.Minute()) }, `\d{1,2}`, fieldNone},
+	{"s", func(t time.Time) string { return strconv.Itoa(t.Second()) }, `\d{1,2}`, fieldNone},
+	{"A", func(t time.Time) string { return t.Format("PM") }, `[AP]M`, fieldNone},
+	{"a", func(t time.Time) string { return t.Format("pm") }, `[ap]m`, fieldNone},
+}
+
+// segment is a piece of a parsed format: a token or literal text.
+type segment struct {
+	token   *formatToken
+	literal string
+}
+
+// splitFormat breaks a Moment.js format into tokens and literal text.
+// Text in [brackets] is literal; so is any character that starts no token.
+func splitFormat(format string) []segment {
+	var segments []segment
+	for i := 0; i < len(format); {
+		if format[i] == '[' {
+			if end := strings.IndexByte(format[i:], ']'); end != -1 {
+				segments = append(segments, segment{literal: format[i+1 : i+end]})
+				i += end + 1
+				continue
+			}
+		}
+		matched := false
+		for k := range formatTokens {
+			if strings.HasPrefix(format[i:], formatTokens[k].name) {
+				segments = append(segments, segment{token: &formatTokens[k]})
+				i += len(formatTokens[k].name)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			segments = append(segments, segment{literal: format[i : i+1]})
+			i++
+		}
+	}
+	return segments
+}
+
+// Format formats t with a Moment.js format such as "GGGG-[W]WW".
+func Format(t time.Time, format string) string {
+	var b strings.Builder
+	for _, s := range splitFormat(format) {
+		if s.token != nil {
+			b.WriteString(s.token.format(t))
+		} else {
+			b.WriteString(s.literal)
+		}
+	}
+	return b.String()
+}
+
+// Parse reads a name written with a Moment.js format and returns the
+// first day it names in loc. Parts the format leaves out default to the
+// start of the period, so "2026-[Q]Q" read from "2026-Q2" gives April 1.
+func Parse(format, name string, loc *time.Location) (time.Time, bool) {
+	var pattern strings.Builder
+	var fields []dateField
+	pattern.WriteString("^")
+	for _, s := range splitFormat(format) {
+		if s.token == nil {
+			pattern.WriteString(regexp.QuoteMeta(s.literal))
+			continue
+		}
+		pattern.WriteString(s.token.pattern)
+		if s.token.field != fieldNone {
+			fields = append(fields, s.token.field)
+		}
+	}
+	pattern.WriteString("$")
+
+	re, err := regexp.Compile(pattern.String())
+	if err != nil {
+		return time.Time{}, false
+	}
+	m := re.FindStringSubmatch(name)
+	if m == nil {
+		return time.Time{}, false
+	}
+
+	year, weekYear, quarter, month, week, day := -1, -1, 0, 0, 0, 1
+	weekday := time.Monday
+	for i, field := range fields {
+		value := m[i+1]
+		n, _ := strconv.Atoi(value)
+		switch field {
+		case fieldYear:
+			year = n
+		case fieldShortYear:
+			year = 2000 + n
+		case fieldWeekYear:
+			weekYear = n
+		case fieldQuarter:
+			quarter = n
+		case fieldMonth:
+			month = n
+		case fieldMonthName:
+			t, err := time.Parse("January", value)
+			if err != nil {
+				return time.Time{}, false
+			}
+			month = int(t.Month())
+		case fieldMonthShort:
+			t, err := time.Parse("Jan", value)
+			if err != nil {
+				return time.Time{}, false
+			}
+			month = int(t.Month())
+		case fieldWeek:
+			week = n
+		case fieldWeekday:
+			d, ok := parseWeekday(value)
+			if !ok {
+				return time.Time{}, false
+			}
+			weekday = d
+		case fieldDay:
+			day = n
+		}
+	}
+
+	if week > 0 {
+		if weekYear == -1 {
+			weekYear = year
+		}
+		if weekYear == -1 || week > 53 {
+			return time.Time{}, false
+		}
+		// Weeks start on Monday, so Sunday is the week's last day
+		offset := (int(weekday) + 6) % 7
+		return isoWeekStart(weekYear, week, loc).AddDate(0, 0, offset), true
+	}
+
+	if year == -1 {
+		year = weekYear
+	}
+	if year == -1 {
+		return time.Time{}, false
+	}
+	if month == 0 {
+		month = 1
+		if quarter > 0 {
+			month = (quarter-1)*3 + 1
+		}
+	}
+	if month < 1 || month > 12 || day < 1 || day > 31 {
+		return time.Time{}, false
+	}
+	t := time.Date(year, time.Month(month), day, 0, 0, 0, 0, loc)
+	if t.Day() != day {
+		return time.Time{}, false // e.g. February 30
+	}
+	return t, true
+}
+
+// isoWeekStart returns the Monday of an ISO week.
+func isoWeekStart(year, week int, loc *time.Location) time.Time {
+	// January 4th is always in week 1
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, loc)
+	return Start(types.GoalPeriodWeek, jan4).AddDate(0, 0, 7*(week-1))
+}
+
+// parseWeekday reads a weekday's full or three-letter English name.
+func parseWeekday(name string) (time.Weekday, bool) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(name, d.String()) || strings.EqualFold(name, d.String()[:3]) {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+func ordinal(n int) string {
+	suffix := "th"
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+	case n%10 == 1:
+		suffix = "st"
+	case n%10 == 2:
+		suffix = "nd"
+	case n%10 == 3:
+		suffix = "rd"
+	}
+	return strconv.Itoa(n) + suffix
+}
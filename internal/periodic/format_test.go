@@ -0,0 +1,172 @@
+package periodic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BioWare/lazyobsidian/pkg/types"
+)
+
+func day(s string) time.Time {
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestFormat(t *testing.T) {
+	at := time.Date(2026, 3, 1, 15, 4, 5, 0, time.Local)
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"YYYY-MM-DD", "2026-03-01"},
+		{"YY/M/D", "26/3/1"},
+		{"dddd, MMMM Do YYYY", "Sunday, March 1st 2026"},
+		{"ddd D MMM", "Sun 1 Mar"},
+		{"GGGG-[W]WW", "2026-W09"},
+		{"gggg-[W]w", "2026-W9"},
+		{"YYYY-[Q]Q", "2026-Q1"},
+		{"DDDD", "060"},
+		{"HH:mm:ss", "15:04:05"},
+		{"h:mm a", "3:04 pm"},
+		{"hh A", "03 PM"},
+		{"[YYYY] YYYY", "YYYY 2026"},
+		{"YYYY/MM/YYYY-MM-DD", "2026/03/2026-03-01"},
+	}
+	for _, tt := range tests {
+		if got := Format(at, tt.format); got != tt.want {
+			t.Errorf("Format(%q) = %q, want %q", tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestFormatOrdinals(t *testing.T) {
+	want := map[int]string{
+		1: "1st", 2: "2nd", 3: "3rd", 4: "4th", 11: "11th", 12: "12th",
+		13: "13th", 21: "21st", 22: "22nd", 23: "23rd", 30: "30th", 31: "31st",
+	}
+	for d, w := range want {
+		if got := Format(time.Date(2026, 1, d, 0, 0, 0, 0, time.Local), "Do"); got != w {
+			t.Errorf("Format(January %d, \"Do\") = %q, want %q", d, got, w)
+		}
+	}
+}
+
+func TestFormatWeekYear(t *testing.T) {
+	// ISO weeks belong to the year holding their Thursday
+	tests := []struct {
+		date string
+		want string
+	}{
+		{"2024-12-30", "2025-W01"},
+		{"2026-01-01", "2026-W01"},
+		{"2026-12-31", "2026-W53"},
+		{"2027-01-01", "2026-W53"},
+		{"2027-01-03", "2026-W53"},
+		{"2027-01-04", "2027-W01"},
+	}
+	for _, tt := range tests {
+		if got := Format(day(tt.date), "GGGG-[W]WW"); got != tt.want {
+			t.Errorf("Format(%s) = %q, want %q", tt.date, got, tt.want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		format string
+		name   string
+		want   string
+	}{
+		{"YYYY-MM-DD", "2026-03-05", "2026-03-05"},
+		{"YYYY/MM/YYYY-MM-DD", "2026/03/2026-03-05", "2026-03-05"},
+		{"DD.MM.YY", "05.03.26", "2026-03-05"},
+		{"MMMM Do, YYYY", "March 5th, 2026", "2026-03-05"},
+		{"D MMM YYYY", "5 Mar 2026", "2026-03-05"},
+		{"YYYY-MM-DD dddd", "2026-03-05 Thursday", "2026-03-05"},
+		{"YYYY-MM", "2026-03", "2026-03-01"},
+		{"YYYY", "2026", "2026-01-01"},
+		{"YYYY-[Q]Q", "2026-Q1", "2026-01-01"},
+		{"YYYY-[Q]Q", "2026-Q4", "2026-10-01"},
+		{"GGGG-[W]WW", "2026-W01", "2025-12-29"},
+		{"GGGG-[W]WW", "2026-W10", "2026-03-02"},
+		{"GGGG-[W]WW", "2026-W53", "2026-12-28"},
+		{"GGGG-[W]WW", "2025-W01", "2024-12-30"},
+		{"gggg-[W]ww", "2026-W10", "2026-03-02"},
+		{"GGGG-[W]W", "2026-W9", "2026-02-23"},
+		{"GGGG-[W]WW-dddd", "2026-W10-Sunday", "2026-03-08"},
+		{"GGGG-[W]WW-ddd", "2026-W10-Wed", "2026-03-04"},
+		{"[Daily] YYYY-MM-DD HH:mm", "Daily 2026-03-05 09:30", "2026-03-05"},
+	}
+	for _, tt := range tests {
+		got, ok := Parse(tt.format, tt.name, time.Local)
+		if !ok {
+			t.Errorf("Parse(%q, %q) failed", tt.format, tt.name)
+			continue
+		}
+		if !got.Equal(day(tt.want)) {
+			t.Errorf("Parse(%q, %q) = %s, want %s", tt.format, tt.name, got.Format("2006-01-02"), tt.want)
+		}
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	tests := []struct {
+		format string
+		name   string
+	}{
+		{"YYYY-MM-DD", "2026-3-5"},
+		{"YYYY-MM-DD", "2026-02-30"},
+		{"YYYY-MM-DD", "2026-13-01"},
+		{"YYYY-MM-DD", "2026-03-05 extra"},
+		{"YYYY-[Q]Q", "2026-Q5"},
+		{"GGGG-[W]WW", "2026-W54"},
+		{"GGGG-[W]WW-dddd", "2026-W10-Someday"},
+		{"MMMM YYYY", "Smarch 2026"},
+		{"DD", "05"},
+	}
+	for _, tt := range tests {
+		if got, ok := Parse(tt.format, tt.name, time.Local); ok {
+			t.Errorf("Parse(%q, %q) = %s, want no date", tt.format, tt.name, got)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	formats := []struct {
+		period types.GoalPeriod
+		format string
+	}{
+		{types.GoalPeriodWeek, "GGGG-[W]WW"},
+		{types.GoalPeriodWeek, "gggg-[W]w"},
+		{types.GoalPeriodMonth, "YYYY-MM"},
+		{types.GoalPeriodMonth, "MMMM YYYY"},
+		{types.GoalPeriodQuarter, "YYYY-[Q]Q"},
+		{types.GoalPeriodYear, "YYYY"},
+	}
+	for _, f := range formats {
+		for d := day("2020-12-20"); d.Before(day("2028-01-10")); d = d.AddDate(0, 0, 1) {
+			start := Start(f.period, d)
+			name := Format(start, f.format)
+			got, ok := Parse(f.format, name, time.Local)
+			if !ok || !got.Equal(start) {
+				t.Fatalf("%q: %s formats as %q, which parses as %s (ok %v)",
+					f.format, start.Format("2006-01-02"), name, got.Format("2006-01-02"), ok)
+			}
+		}
+	}
+
+	// Daily formats give back the day itself
+	for _, format := range []string{"YYYY-MM-DD", "YYYY/MM/Do MMMM", "GGGG-[W]WW-ddd", "DDDD YYYY-MM-DD"} {
+		for d := day("2023-12-25"); d.Before(day("2025-01-10")); d = d.AddDate(0, 0, 1) {
+			name := Format(d, format)
+			got, ok := Parse(format, name, time.Local)
+			if !ok || !got.Equal(d) {
+				t.Fatalf("%q: %s formats as %q, which parses as %s (ok %v)",
+					format, d.Format("2006-01-02"), name, got.Format("2006-01-02"), ok)
+			}
+		}
+	}
+}
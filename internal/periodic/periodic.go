@@ -0,0 +1,91 @@
+// Package periodic works out the weekly, monthly, quarterly and yearly
+// periods that periodic notes cover, and names their notes.
+//
+// Weeks are ISO weeks: they start on Monday and belong to the year that
+// holds their Thursday.
+package periodic
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/BioWare/lazyobsidian/pkg/types"
+)
+
+// Periods lists the note periods from broadest to narrowest.
+var Periods = []types.GoalPeriod{
+	types.GoalPeriodYear,
+	types.GoalPeriodQuarter,
+	types.GoalPeriodMonth,
+	types.GoalPeriodWeek,
+}
+
+// Start returns midnight on the first day of the period containing t.
+func Start(period types.GoalPeriod, t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch period {
+	case types.GoalPeriodWeek:
+		offset := (int(day.Weekday()) + 6) % 7 // days since Monday
+		return day.AddDate(0, 0, -offset)
+	case types.GoalPeriodMonth:
+		return day.AddDate(0, 0, 1-day.Day())
+	case types.GoalPeriodQuarter:
+		month := time.Month((int(t.Month())-1)/3*3 + 1)
+		return time.Date(t.Year(), month, 1, 0, 0, 0, 0, t.Location())
+	case types.GoalPeriodYear:
+		return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, t.Location())
+	}
+	return day
+}
+
+// Next returns the start of the period after the one containing t.
+func Next(period types.GoalPeriod, t time.Time) time.Time {
+	return shift(period, Start(period, t), 1)
+}
+
+// Prev returns the start of the period before the one containing t.
+func Prev(period types.GoalPeriod, t time.Time) time.Time {
+	return shift(period, Start(period, t), -1)
+}
+
+// End returns the start of the following period, which ends the period
+// containing t.
+func End(period types.GoalPeriod, t time.Time) time.Time {
+	return Next(period, t)
+}
+
+// Contains reports whether t falls within the period that starts at start.
+func Contains(period types.GoalPeriod, start, t time.Time) bool {
+	return Start(period, t).Equal(Start(period, start))
+}
+
+func shift(period types.GoalPeriod, start time.Time, n int) time.Time {
+	switch period {
+	case types.GoalPeriodWeek:
+		return start.AddDate(0, 0, 7*n)
+	case types.GoalPeriodMonth:
+		return start.AddDate(0, n, 0)
+	case types.GoalPeriodQuarter:
+		return start.AddDate(0, 3*n, 0)
+	case types.GoalPeriodYear:
+		return start.AddDate(n, 0, 0)
+	}
+	return start.AddDate(0, 0, n)
+}
+
+// Label returns a short name for the period containing t, such as
+// "2026-W11", "Mar 2026", "2026-Q1" or "2026".
+func Label(period types.GoalPeriod, t time.Time) string {
+	switch period {
+	case types.GoalPeriodWeek:
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case types.GoalPeriodMonth:
+		return t.Format("Jan 2006")
+	case types.GoalPeriodQuarter:
+		return fmt.Sprintf("%d-Q%d", t.Year(), (int(t.Month())-1)/3+1)
+	case types.GoalPeriodYear:
+		return fmt.Sprintf("%d", t.Year())
+	}
+	return t.Format("2006-01-02")
+}
@@ -4,6 +4,8 @@ package pomodoro
 import (
 	"sync"
 	"time"
+
+	"github.com/BioWare/lazyobsidian/pkg/types"
 )
 
 // State represents the current state of the pomodoro timer.
@@ -29,10 +31,14 @@ type Timer struct {
 	dailyGoal     int
 	dailyComplete int
 	context       string
+	started       time.Time          // start of the current session
+	elapsed       time.Duration      // time run in the current session, without pauses
+	breakType     types.PomodoroType // the current break, short or long
+	autoBreak     bool
 	ticker        *time.Ticker
 	done          chan struct{}
 	onTick        func(remaining time.Duration)
-	onComplete    func(sessionType State)
+	onComplete    func(session types.PomodoroSession)
 }
 
 // Config holds pomodoro timer configuration.
@@ -42,6 +48,7 @@ type Config struct {
 	LongBreakMinutes   int
 	SessionsBeforeLong int
 	DailyGoal          int
+	AutoStartBreak     bool // run the break as soon as a work session ends
 }
 
 // NewTimer creates a new pomodoro timer.
@@ -53,6 +60,7 @@ func NewTimer(cfg Config) *Timer {
 		longBreak:     time.Duration(cfg.LongBreakMinutes) * time.Minute,
 		sessionsGoal:  cfg.SessionsBeforeLong,
 		dailyGoal:     cfg.DailyGoal,
+		autoBreak:     cfg.AutoStartBreak,
 		done:          make(chan struct{}),
 	}
 }
@@ -67,6 +75,8 @@ func (t *Timer) Start(context string) {
 	}
 
 	t.context = context
+	t.started = time.Now()
+	t.elapsed = 0
 	t.remaining = t.workDuration
 	t.state = StateRunning
 	t.ticker = time.NewTicker(time.Second)
@@ -158,8 +168,9 @@ func (t *Timer) OnTick(fn func(remaining time.Duration)) {
 	t.onTick = fn
 }
 
-// OnComplete sets the callback for session completion.
-func (t *Timer) OnComplete(fn func(sessionType State)) {
+// OnComplete sets the callback for each finished session, work or break.
+// It runs on the timer's goroutine.
+func (t *Timer) OnComplete(fn func(session types.PomodoroSession)) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 	t.onComplete = fn
@@ -172,12 +183,13 @@ func (t *Timer) run() {
 			return
 		case <-t.ticker.C:
 			t.mu.Lock()
-			if t.state != StateRunning {
+			if t.state != StateRunning && t.state != StateBreak {
 				t.mu.Unlock()
 				return
 			}
 
 			t.remaining -= time.Second
+			t.elapsed += time.Second
 
 			if t.onTick != nil {
 				t.onTick(t.remaining)
@@ -192,30 +204,44 @@ func (t *Timer) run() {
 }
 
 func (t *Timer) handleComplete() {
-	t.ticker.Stop()
-
-	previousState := t.state
+	now := time.Now()
+	session := types.PomodoroSession{
+		StartedAt: t.started,
+		EndedAt:   now,
+		Duration:  int((t.elapsed + 30*time.Second) / time.Minute),
+		Context:   t.context,
+	}
 
 	if t.state == StateRunning {
 		// Work session completed
+		session.Type = types.PomodoroTypeWork
 		t.sessionsCount++
 		t.dailyComplete++
 
 		// Determine break type
 		if t.sessionsCount >= t.sessionsGoal {
 			t.remaining = t.longBreak
+			t.breakType = types.PomodoroTypeLongBreak
 			t.sessionsCount = 0
 		} else {
 			t.remaining = t.shortBreak
+			t.breakType = types.PomodoroTypeShortBreak
 		}
 		t.state = StateBreak
+		t.started = now
+		t.elapsed = 0
+		if !t.autoBreak {
+			t.ticker.Stop()
+		}
 	} else if t.state == StateBreak {
 		// Break completed
+		session.Type = t.breakType
 		t.state = StateIdle
+		t.ticker.Stop()
 	}
 
 	if t.onComplete != nil {
-		t.onComplete(previousState)
+		t.onComplete(session)
 	}
 }
 
@@ -0,0 +1,407 @@
+// Package recurrence reads the recurrence rules of recurring tasks, such
+// as "every week" or "every month on the last Friday when done", and works
+// out their next dates.
+//
+// The rules are the natural-language subset the Obsidian Tasks plugin
+// accepts. Weeks start on Monday. A day that a month lacks, such as the
+// 31st, is skipped, except by plain "every month" and "every year", which
+// fall back to the last day of the month as the Tasks plugin does.
+package recurrence
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency is the unit a rule repeats in.
+type Frequency int
+
+const (
+	Daily Frequency = iota
+	Weekly
+	Monthly
+	Yearly
+)
+
+// Rule is a parsed recurrence rule.
+type Rule struct {
+	Freq     Frequency
+	Interval int            // repeat every Interval units, at least 1
+	Weekdays []time.Weekday // weekly: the days of the week
+	Days     []MonthDay     // monthly and yearly: the days of the month
+	Month    time.Month     // yearly: the month; 0 keeps the reference date's
+	WhenDone bool           // count from the day the task is done
+	Text     string         // the rule as written
+}
+
+// MonthDay is a day of a month: a day number, or with ByWeekday the Nth
+// Weekday, such as the 2nd Wednesday. Negative numbers count from the end
+// of the month, -1 being the last.
+type MonthDay struct {
+	N         int
+	Weekday   time.Weekday
+	ByWeekday bool
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday,
+	"wednesday": time.Wednesday, "thursday": time.Thursday, "friday": time.Friday,
+	"saturday": time.Saturday,
+}
+
+var ordinalWords = map[string]int{
+	"first": 1, "second": 2, "third": 3, "fourth": 4, "fifth": 5, "last": -1,
+}
+
+// Parse reads a rule such as "every 2 weeks on Monday, Friday",
+// "every weekday", "every month on the 2nd Wednesday", "every year on
+// January 15" or "every 3 days when done".
+func Parse(text string) (*Rule, error) {
+	rule := &Rule{Interval: 1, Text: strings.TrimSpace(text)}
+	p := &parser{tokens: tokenize(text)}
+
+	if n := len(p.tokens); n >= 2 && p.tokens[n-2] == "when" && p.tokens[n-1] == "done" {
+		rule.WhenDone = true
+		p.tokens = p.tokens[:len(p.tokens)-2]
+	}
+
+	if !p.accept("every") {
+		return nil, fmt.Errorf("recurrence %q: expected \"every\"", text)
+	}
+	if p.accept("other") {
+		rule.Interval = 2
+	} else if n, err := strconv.Atoi(p.peek()); err == nil {
+		if n < 1 {
+			return nil, fmt.Errorf("recurrence %q: interval must be at least 1", text)
+		}
+		rule.Interval = n
+		p.next()
+	}
+
+	unit := p.next()
+	switch {
+	case unit == "day" || unit == "days":
+		rule.Freq = Daily
+	case unit == "weekday" || unit == "weekdays":
+		rule.Freq = Weekly
+		rule.Weekdays = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+	case unit == "week" || unit == "weeks":
+		rule.Freq = Weekly
+	case unit == "month" || unit == "months":
+		rule.Freq = Monthly
+	case unit == "year" || unit == "years":
+		rule.Freq = Yearly
+	case isWeekday(unit):
+		// "every Monday and Thursday"
+		rule.Freq = Weekly
+		p.back()
+		rule.Weekdays = p.weekdays()
+	case isMonth(unit):
+		// "every January on the 15th"
+		rule.Freq = Yearly
+		rule.Month, _ = parseMonth(unit)
+	default:
+		return nil, fmt.Errorf("recurrence %q: unknown unit %q", text, unit)
+	}
+
+	if p.accept("on") {
+		p.accept("the")
+		switch rule.Freq {
+		case Weekly:
+			if len(rule.Weekdays) > 0 {
+				return nil, fmt.Errorf("recurrence %q: days given twice", text)
+			}
+			rule.Weekdays = p.weekdays()
+			if len(rule.Weekdays) == 0 {
+				return nil, fmt.Errorf("recurrence %q: expected days of the week after \"on\"", text)
+			}
+		case Monthly:
+			rule.Days = p.monthDays()
+			if len(rule.Days) == 0 {
+				return nil, fmt.Errorf("recurrence %q: expected days of the month after \"on\"", text)
+			}
+		case Yearly:
+			// "every year on January 15" or "every January on the 15th"
+			if month, ok := parseMonth(p.peek()); ok && rule.Month == 0 {
+				rule.Month = month
+				p.next()
+				p.accept("the")
+			}
+			rule.Days = p.monthDays()
+			if len(rule.Days) == 0 {
+				return nil, fmt.Errorf("recurrence %q: expected a day after \"on\"", text)
+			}
+		default:
+			return nil, fmt.Errorf("recurrence %q: \"on\" does not apply to days", text)
+		}
+	}
+
+	if p.peek() != "" {
+		return nil, fmt.Errorf("recurrence %q: unexpected %q", text, p.peek())
+	}
+	return rule, nil
+}
+
+// Next returns the first date of the rule after ref. Only the date of ref
+// is used; the result is midnight in ref's location.
+func (r *Rule) Next(ref time.Time) time.Time {
+	ref = time.Date(ref.Year(), ref.Month(), ref.Day(), 0, 0, 0, 0, ref.Location())
+	interval := max(r.Interval, 1)
+
+	switch r.Freq {
+	case Daily:
+		return ref.AddDate(0, 0, interval)
+
+	case Weekly:
+		if len(r.Weekdays) == 0 {
+			return ref.AddDate(0, 0, 7*interval)
+		}
+		// Only every interval-th week, counted from ref's week, is used
+		week := weekStart(ref)
+		for d := ref.AddDate(0, 0, 1); ; d = d.AddDate(0, 0, 1) {
+			weeks := DaysBetween(week, weekStart(d)) / 7
+			if weeks%interval == 0 && hasWeekday(r.Weekdays, d.Weekday()) {
+				return d
+			}
+		}
+
+	case Monthly:
+		if len(r.Days) == 0 {
+			return addMonths(ref, interval)
+		}
+		first := time.Date(ref.Year(), ref.Month(), 1, 0, 0, 0, 0, ref.Location())
+		for m := 0; m <= 48*interval; m += interval {
+			if d, ok := r.firstDayIn(first.AddDate(0, m, 0), ref); ok {
+				return d
+			}
+		}
+
+	case Yearly:
+		if r.Month == 0 && len(r.Days) == 0 {
+			return addMonths(ref, 12*interval)
+		}
+		month := r.Month
+		if month == 0 {
+			month = ref.Month()
+		}
+		days := r.Days
+		if len(days) == 0 {
+			days = []MonthDay{{N: ref.Day()}}
+		}
+		rule := Rule{Days: days}
+		for y := 0; y <= 8*interval; y += interval {
+			first := time.Date(ref.Year()+y, month, 1, 0, 0, 0, 0, ref.Location())
+			if d, ok := rule.firstDayIn(first, ref); ok {
+				return d
+			}
+		}
+	}
+
+	// Rules that never match again, such as the 31st of February
+	return addMonths(ref, 12*interval)
+}
+
+// firstDayIn returns the first of the rule's days in the month starting
+// at first that comes after ref.
+func (r *Rule) firstDayIn(first, ref time.Time) (time.Time, bool) {
+	var dates []time.Time
+	for _, day := range r.Days {
+		if d, ok := day.in(first); ok && d.After(ref) {
+			dates = append(dates, d)
+		}
+	}
+	if len(dates) == 0 {
+		return time.Time{}, false
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+	return dates[0], true
+}
+
+// in returns the day in the month starting at first, if it has it.
+func (md MonthDay) in(first time.Time) (time.Time, bool) {
+	length := daysIn(first)
+	day := 0
+	switch {
+	case !md.ByWeekday && md.N > 0:
+		day = md.N
+	case !md.ByWeekday && md.N < 0:
+		day = length + md.N + 1
+	case md.N > 0:
+		offset := (int(md.Weekday) - int(first.Weekday()) + 7) % 7
+		day = 1 + offset + 7*(md.N-1)
+	case md.N < 0:
+		last := first.AddDate(0, 0, length-1)
+		offset := (int(last.Weekday()) - int(md.Weekday) + 7) % 7
+		day = length - offset + 7*(md.N+1)
+	}
+	if day < 1 || day > length {
+		return time.Time{}, false
+	}
+	return first.AddDate(0, 0, day-1), true
+}
+
+// addMonths adds months to t, keeping its day or, when the month is
+// shorter, using its last day.
+func addMonths(t time.Time, months int) time.Time {
+	first := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location()).AddDate(0, months, 0)
+	day := min(t.Day(), daysIn(first))
+	return first.AddDate(0, 0, day-1)
+}
+
+// daysIn returns the number of days in the month starting at first.
+func daysIn(first time.Time) int {
+	return first.AddDate(0, 1, -1).Day()
+}
+
+// weekStart returns the Monday of t's week.
+func weekStart(t time.Time) time.Time {
+	return t.AddDate(0, 0, -((int(t.Weekday()) + 6) % 7))
+}
+
+// DaysBetween counts the calendar days from a to b, ignoring the time of
+// day and daylight saving changes.
+func DaysBetween(a, b time.Time) int {
+	a = time.Date(a.Year(), a.Month(), a.Day(), 12, 0, 0, 0, time.UTC)
+	b = time.Date(b.Year(), b.Month(), b.Day(), 12, 0, 0, 0, time.UTC)
+	return int(b.Sub(a).Hours() / 24)
+}
+
+func hasWeekday(days []time.Weekday, d time.Weekday) bool {
+	for _, wd := range days {
+		if wd == d {
+			return true
+		}
+	}
+	return false
+}
+
+// parser walks the words of a rule.
+type parser struct {
+	tokens []string
+	pos    int
+}
+
+// tokenize lower-cases a rule and splits it into words, dropping commas.
+func tokenize(text string) []string {
+	text = strings.ToLower(strings.ReplaceAll(text, ",", " "))
+	return strings.Fields(text)
+}
+
+func (p *parser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *parser) next() string {
+	t := p.peek()
+	if p.pos < len(p.tokens) {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) back() {
+	if p.pos > 0 {
+		p.pos--
+	}
+}
+
+func (p *parser) accept(word string) bool {
+	if p.peek() == word {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// weekdays reads a list such as "monday, wednesday and friday".
+func (p *parser) weekdays() []time.Weekday {
+	var days []time.Weekday
+	for {
+		wd, ok := parseWeekday(p.peek())
+		if !ok {
+			break
+		}
+		p.next()
+		days = append(days, wd)
+		p.accept("and")
+	}
+	return days
+}
+
+// monthDays reads a list such as "1st and 15th", "last", "2nd wednesday"
+// or "last friday". A bare day number, as in "January 15", is accepted.
+func (p *parser) monthDays() []MonthDay {
+	var days []MonthDay
+	for {
+		n, ok := parseOrdinal(p.peek())
+		if !ok {
+			break
+		}
+		p.next()
+		day := MonthDay{N: n}
+		if wd, ok := parseWeekday(p.peek()); ok {
+			p.next()
+			day.Weekday, day.ByWeekday = wd, true
+		} else if n == -1 {
+			// "the last day"
+			p.accept("day")
+		}
+		days = append(days, day)
+		p.accept("and")
+		p.accept("the")
+	}
+	return days
+}
+
+func parseWeekday(word string) (time.Weekday, bool) {
+	word = strings.TrimSuffix(word, "s")
+	for name, wd := range weekdayNames {
+		if word == name || (len(word) >= 3 && strings.HasPrefix(name, word)) {
+			return wd, true
+		}
+	}
+	return 0, false
+}
+
+func isWeekday(word string) bool {
+	_, ok := parseWeekday(word)
+	return ok
+}
+
+func parseMonth(word string) (time.Month, bool) {
+	if len(word) < 3 {
+		return 0, false
+	}
+	for m := time.January; m <= time.December; m++ {
+		if strings.HasPrefix(strings.ToLower(m.String()), word) {
+			return m, true
+		}
+	}
+	return 0, false
+}
+
+func isMonth(word string) bool {
+	_, ok := parseMonth(word)
+	return ok
+}
+
+// parseOrdinal reads "1st", "22nd", "15", "third" or "last".
+func parseOrdinal(word string) (int, bool) {
+	if n, ok := ordinalWords[word]; ok {
+		return n, true
+	}
+	for _, suffix := range []string{"st", "nd", "rd", "th"} {
+		word = strings.TrimSuffix(word, suffix)
+	}
+	n, err := strconv.Atoi(word)
+	if err != nil || n < 1 || n > 31 {
+		return 0, false
+	}
+	return n, true
+}
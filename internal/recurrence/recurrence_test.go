@@ -0,0 +1,160 @@
+package recurrence
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		text string
+		want Rule
+	}{
+		{"every day", Rule{Freq: Daily, Interval: 1}},
+		{"every other day", Rule{Freq: Daily, Interval: 2}},
+		{"every 3 days when done", Rule{Freq: Daily, Interval: 3, WhenDone: true}},
+		{"every weekday", Rule{Freq: Weekly, Interval: 1,
+			Weekdays: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}}},
+		{"every 2 weeks on Monday, Friday", Rule{Freq: Weekly, Interval: 2,
+			Weekdays: []time.Weekday{time.Monday, time.Friday}}},
+		{"every Tuesday and Thursday", Rule{Freq: Weekly, Interval: 1,
+			Weekdays: []time.Weekday{time.Tuesday, time.Thursday}}},
+		{"every month on the 1st and 15th", Rule{Freq: Monthly, Interval: 1,
+			Days: []MonthDay{{N: 1}, {N: 15}}}},
+		{"every month on the last day", Rule{Freq: Monthly, Interval: 1,
+			Days: []MonthDay{{N: -1}}}},
+		{"every month on the 2nd Wednesday", Rule{Freq: Monthly, Interval: 1,
+			Days: []MonthDay{{N: 2, Weekday: time.Wednesday, ByWeekday: true}}}},
+		{"every year on January 15", Rule{Freq: Yearly, Interval: 1, Month: time.January,
+			Days: []MonthDay{{N: 15}}}},
+		{"every March on the 3rd", Rule{Freq: Yearly, Interval: 1, Month: time.March,
+			Days: []MonthDay{{N: 3}}}},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.text)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.text, err)
+			continue
+		}
+		tt.want.Text = tt.text
+		if !reflect.DeepEqual(*got, tt.want) {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.text, *got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, text := range []string{
+		"",
+		"daily",
+		"every",
+		"every fortnight",
+		"every 0 days",
+		"every day on Monday",
+		"every week on",
+		"every Monday on Friday",
+		"every month on the 32nd",
+		"every week extra",
+	} {
+		if rule, err := Parse(text); err == nil {
+			t.Errorf("Parse(%q) = %+v, want an error", text, *rule)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		rule string
+		ref  string
+		want string
+	}{
+		{"every day", "2026-03-05", "2026-03-06"},
+		{"every 3 days", "2026-12-30", "2027-01-02"},
+		{"every week", "2026-03-05", "2026-03-12"},
+		{"every weekday", "2026-03-06", "2026-03-09"},
+		{"every week on Monday, Friday", "2026-03-05", "2026-03-06"},
+		{"every week on Monday, Friday", "2026-03-06", "2026-03-09"},
+		{"every 2 weeks on Monday", "2026-03-02", "2026-03-16"},
+		{"every 2 weeks on Monday, Friday", "2026-03-02", "2026-03-06"},
+
+		// Plain months keep the day, or fall back to the month's last
+		{"every month", "2026-03-15", "2026-04-15"},
+		{"every month", "2026-01-31", "2026-02-28"},
+		{"every month", "2024-01-31", "2024-02-29"},
+		{"every 3 months", "2026-11-30", "2027-02-28"},
+
+		// Given days a month lacks are skipped
+		{"every month on the 31st", "2026-01-31", "2026-03-31"},
+		{"every month on the 30th", "2026-01-30", "2026-03-30"},
+		{"every month on the 29th", "2024-01-29", "2024-02-29"},
+		{"every month on the 29th", "2026-01-29", "2026-03-29"},
+		{"every month on the last", "2026-02-10", "2026-02-28"},
+		{"every month on the last", "2024-02-10", "2024-02-29"},
+		{"every month on the last", "2026-02-28", "2026-03-31"},
+		{"every month on the 1st and 15th", "2026-03-05", "2026-03-15"},
+		{"every month on the 1st and 15th", "2026-03-15", "2026-04-01"},
+		{"every month on the 2nd Wednesday", "2026-03-05", "2026-03-11"},
+		{"every month on the 2nd Wednesday", "2026-03-11", "2026-04-08"},
+		{"every month on the last Friday", "2026-03-05", "2026-03-27"},
+		{"every month on the 5th Monday", "2026-03-05", "2026-03-30"},
+		{"every month on the 5th Monday", "2026-03-30", "2026-06-29"},
+
+		// Years
+		{"every year", "2026-03-05", "2027-03-05"},
+		{"every year", "2024-02-29", "2025-02-28"},
+		{"every year on January 15", "2026-03-05", "2027-01-15"},
+		{"every year on January 15", "2026-01-10", "2026-01-15"},
+		{"every year on February 29", "2024-03-01", "2028-02-29"},
+		{"every 2 years on December 31", "2026-12-31", "2028-12-31"},
+	}
+	for _, tt := range tests {
+		rule, err := Parse(tt.rule)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.rule, err)
+			continue
+		}
+		got := rule.Next(date(tt.ref))
+		if !got.Equal(date(tt.want)) {
+			t.Errorf("%q after %s = %s, want %s", tt.rule, tt.ref, got.Format("2006-01-02"), tt.want)
+		}
+	}
+}
+
+func TestNextIgnoresTimeOfDay(t *testing.T) {
+	rule, err := Parse("every day")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref := time.Date(2026, 3, 5, 23, 30, 0, 0, time.Local)
+	if got := rule.Next(ref); !got.Equal(date("2026-03-06")) {
+		t.Errorf("Next(%s) = %s, want midnight on 2026-03-06", ref, got)
+	}
+}
+
+func TestDaysBetween(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"2026-03-05", "2026-03-05", 0},
+		{"2026-03-05", "2026-03-12", 7},
+		{"2026-03-12", "2026-03-05", -7},
+		{"2024-02-28", "2024-03-01", 2},
+		{"2026-02-28", "2026-03-01", 1},
+		{"2026-12-31", "2027-01-01", 1},
+	}
+	for _, tt := range tests {
+		if got := DaysBetween(date(tt.a), date(tt.b)); got != tt.want {
+			t.Errorf("DaysBetween(%s, %s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
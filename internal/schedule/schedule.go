@@ -0,0 +1,83 @@
+// Package schedule handles time-blocked daily schedules.
+package schedule
+
+import (
+	"sort"
+	"time"
+
+	"github.com/BioWare/lazyobsidian/pkg/types"
+)
+
+// Sort orders blocks by start time, then by end time.
+func Sort(blocks []types.ScheduleBlock) {
+	sort.SliceStable(blocks, func(i, j int) bool {
+		if !blocks[i].Start.Equal(blocks[j].Start) {
+			return blocks[i].Start.Before(blocks[j].Start)
+		}
+		return blocks[i].End.Before(blocks[j].End)
+	})
+}
+
+// Overlaps reports whether two blocks share any time.
+// A block without an end time occupies only its start instant.
+func Overlaps(a, b types.ScheduleBlock) bool {
+	if a.Start.Equal(b.Start) {
+		return true
+	}
+	return a.Start.Before(b.End) && b.Start.Before(a.End)
+}
+
+// Overlapping returns the indices of blocks that overlap at least one
+// other block.
+func Overlapping(blocks []types.ScheduleBlock) map[int]bool {
+	result := make(map[int]bool)
+	for i := range blocks {
+		for j := i + 1; j < len(blocks); j++ {
+			if Overlaps(blocks[i], blocks[j]) {
+				result[i] = true
+				result[j] = true
+			}
+		}
+	}
+	return result
+}
+
+// Contains reports whether t falls within the block.
+func Contains(block types.ScheduleBlock, t time.Time) bool {
+	if block.End.Equal(block.Start) {
+		return t.Equal(block.Start)
+	}
+	return !t.Before(block.Start) && t.Before(block.End)
+}
+
+// Active returns the blocks in progress at t.
+func Active(blocks []types.ScheduleBlock, t time.Time) []types.ScheduleBlock {
+	var result []types.ScheduleBlock
+	for _, b := range blocks {
+		if Contains(b, t) {
+			result = append(result, b)
+		}
+	}
+	return result
+}
+
+// TotalDuration returns the time covered by blocks, counting overlapping
+// time once.
+func TotalDuration(blocks []types.ScheduleBlock) time.Duration {
+	sorted := append([]types.ScheduleBlock(nil), blocks...)
+	Sort(sorted)
+
+	var total time.Duration
+	var coveredUntil time.Time
+	for _, b := range sorted {
+		start := b.Start
+		if start.Before(coveredUntil) {
+			start = coveredUntil
+		}
+		if b.End.After(start) {
+			total += b.End.Sub(start)
+			coveredUntil = b.End
+		}
+	}
+	return total
+}
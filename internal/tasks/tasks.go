@@ -2,6 +2,9 @@
 package tasks
 
 import (
+	"sort"
+	"time"
+
 	"github.com/BioWare/lazyobsidian/pkg/types"
 )
 
@@ -80,3 +83,66 @@ func CalculateProgress(tasks []types.Task) (completed, total int) {
 	}
 	return completed, total
 }
+
+// SortByUrgency sorts tasks by due date, earliest first, with undated
+// tasks last. Ties are broken by priority, highest first.
+func SortByUrgency(tasks []types.Task) {
+	sort.SliceStable(tasks, func(i, j int) bool {
+		a, b := tasks[i], tasks[j]
+		switch {
+		case a.DueDate != nil && b.DueDate == nil:
+			return true
+		case a.DueDate == nil && b.DueDate != nil:
+			return false
+		case a.DueDate != nil && b.DueDate != nil && !a.DueDate.Equal(*b.DueDate):
+			return a.DueDate.Before(*b.DueDate)
+		}
+		return a.Priority > b.Priority
+	})
+}
+
+// DueBy returns the tasks due on or before the given day.
+func DueBy(tasks []types.Task, day time.Time) []types.Task {
+	end := time.Date(day.Year(), day.Month(), day.Day(), 23, 59, 59, 0, day.Location())
+
+	var result []types.Task
+	for _, task := range tasks {
+		if task.DueDate != nil && !task.DueDate.After(end) {
+			result = append(result, task)
+		}
+	}
+	return result
+}
+
+// IsOverdue returns true if an unfinished task's due date is before today.
+func IsOverdue(task types.Task, now time.Time) bool {
+	if task.DueDate == nil || task.Status == "done" || task.Status == "cancelled" {
+		return false
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	return task.DueDate.Before(today)
+}
+
+// Actionable returns true if a task can be worked on now: its start
+// date has passed and it has no unfinished dependencies in all.
+func Actionable(task types.Task, all []types.Task, now time.Time) bool {
+	if task.StartDate != nil && task.StartDate.After(now) {
+		return false
+	}
+	if len(task.DependsOn) == 0 {
+		return true
+	}
+
+	finished := make(map[string]bool)
+	for _, t := range all {
+		if t.TaskID != "" {
+			finished[t.TaskID] = t.Status == "done" || t.Status == "cancelled"
+		}
+	}
+	for _, id := range task.DependsOn {
+		if done, ok := finished[id]; ok && !done {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,561 @@
+// Package templates renders note templates written for Obsidian's core
+// Templates plugin or for the Templater plugin.
+//
+// Core syntax: {{title}}, {{date}}, {{time}}, {{date:YYYY-MM-DD}},
+// {{date+1d:...}} and {{date-2w}} offsets, {{yesterday}}, {{tomorrow}},
+// weekdays of the note's week such as {{monday:YYYY-MM-DD}}, {{cursor}}
+// and any extra variable passed in Context.Vars.
+//
+// Templater syntax: tp.file.title, tp.file.folder, tp.file.creation_date,
+// tp.file.cursor, tp.date.now, tp.date.today, tp.date.tomorrow,
+// tp.date.yesterday, tp.date.weekday and tp.system.prompt, with the
+// whitespace control forms <%- -%> and <%_ _%>.
+//
+// Anything the engine does not understand, such as Templater's
+// JavaScript blocks, is left in the note as written.
+package templates
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BioWare/lazyobsidian/internal/periodic"
+	"github.com/BioWare/lazyobsidian/pkg/types"
+)
+
+// Context holds what a template can refer to.
+type Context struct {
+	Title  string    // title of the new note
+	Folder string    // vault-relative folder of the new note
+	Date   time.Time // day the note is for, used by {{date}}
+	Now    time.Time // time the note is created
+
+	// Vars are extra variables for the core syntax, such as {{author}}.
+	Vars map[string]string
+
+	// Prompt asks the user for a value. When nil, prompts take their
+	// default value.
+	Prompt func(question, defaultValue string) (string, error)
+}
+
+// Cursor is where a cursor marker was, as a 1-based line and column.
+type Cursor struct {
+	Line   int
+	Column int
+}
+
+// Result is a rendered template.
+type Result struct {
+	Content string
+	// Cursors lists the cursor markers' positions in jump order.
+	Cursors []Cursor
+}
+
+// cursorMark is a cursor marker's jump order and offset in the output.
+type cursorMark struct {
+	order  int
+	offset int
+}
+
+// renderer holds the state of one Render call.
+type renderer struct {
+	ctx     Context
+	out     strings.Builder
+	cursors []cursorMark
+}
+
+// Render fills in a template. It fails only when a prompt does.
+func Render(template string, ctx Context) (*Result, error) {
+	if ctx.Now.IsZero() {
+		ctx.Now = time.Now()
+	}
+	if ctx.Date.IsZero() {
+		ctx.Date = ctx.Now
+	}
+
+	r := &renderer{ctx: ctx}
+	for i := 0; i < len(template); {
+		rest := template[i:]
+		switch {
+		case strings.HasPrefix(rest, "{{"):
+			end := strings.Index(rest, "}}")
+			if end == -1 {
+				r.out.WriteString(rest)
+				i = len(template)
+				continue
+			}
+			if !r.core(strings.TrimSpace(rest[2:end])) {
+				r.out.WriteString(rest[:end+2])
+			}
+			i += end + 2
+
+		case strings.HasPrefix(rest, "<%"):
+			end := strings.Index(rest, "%>")
+			if end == -1 {
+				r.out.WriteString(rest)
+				i = len(template)
+				continue
+			}
+			n, err := r.templater(rest[:end+2], template[i+end+2:])
+			if err != nil {
+				return nil, err
+			}
+			i += end + 2 + n
+
+		default:
+			r.out.WriteByte(template[i])
+			i++
+		}
+	}
+
+	return r.result(), nil
+}
+
+// result converts cursor offsets to line and column positions.
+func (r *renderer) result() *Result {
+	content := r.out.String()
+	sort.SliceStable(r.cursors, func(a, b int) bool {
+		return r.cursors[a].order < r.cursors[b].order
+	})
+
+	res := &Result{Content: content}
+	for _, c := range r.cursors {
+		before := content[:c.offset]
+		line := strings.Count(before, "\n") + 1
+		column := len([]rune(before[strings.LastIndex(before, "\n")+1:])) + 1
+		res.Cursors = append(res.Cursors, Cursor{Line: line, Column: column})
+	}
+	return res
+}
+
+func (r *renderer) cursor(order int) {
+	r.cursors = append(r.cursors, cursorMark{order: order, offset: r.out.Len()})
+}
+
+// coreVarPattern matches a core variable with an optional offset, such as
+// "date", "date+1d" or "monday-1w".
+var coreVarPattern = regexp.MustCompile(`^([a-zA-Z_][\w-]*?)\s*(?:([+-])\s*(\d+)\s*([dwMQy]))?$`)
+
+// core renders a {{...}} variable. It reports false for unknown ones.
+func (r *renderer) core(expr string) bool {
+	name, format, hasFormat := strings.Cut(expr, ":")
+	m := coreVarPattern.FindStringSubmatch(strings.TrimSpace(name))
+	if m == nil {
+		return false
+	}
+	name = strings.ToLower(m[1])
+	format = strings.TrimSpace(format)
+
+	var base time.Time
+	defaultFormat := "YYYY-MM-DD"
+	switch name {
+	case "title":
+		if m[2] != "" {
+			return false
+		}
+		r.out.WriteString(r.ctx.Title)
+		return true
+	case "cursor":
+		r.cursor(0)
+		return true
+	case "date":
+		base = r.ctx.Date
+	case "time":
+		base = r.ctx.Now
+		defaultFormat = "HH:mm"
+	case "yesterday":
+		base = r.ctx.Date.AddDate(0, 0, -1)
+	case "tomorrow":
+		base = r.ctx.Date.AddDate(0, 0, 1)
+	default:
+		if day, ok := weekdays[name]; ok {
+			base = periodic.Start(types.GoalPeriodWeek, r.ctx.Date).AddDate(0, 0, day)
+			break
+		}
+		value, ok := r.ctx.Vars[m[1]]
+		if !ok || m[2] != "" || hasFormat {
+			return false
+		}
+		r.out.WriteString(value)
+		return true
+	}
+
+	if m[2] != "" {
+		n, _ := strconv.Atoi(m[3])
+		if m[2] == "-" {
+			n = -n
+		}
+		base = addUnits(base, n, m[4])
+	}
+	if format == "" {
+		format = defaultFormat
+	}
+	r.out.WriteString(periodic.Format(base, format))
+	return true
+}
+
+// weekdays maps day names to their offset from Monday.
+var weekdays = map[string]int{
+	"monday": 0, "tuesday": 1, "wednesday": 2, "thursday": 3,
+	"friday": 4, "saturday": 5, "sunday": 6,
+}
+
+// addUnits adds n Moment.js units (d, w, M, Q or y) to t.
+func addUnits(t time.Time, n int, unit string) time.Time {
+	switch unit {
+	case "w":
+		return t.AddDate(0, 0, 7*n)
+	case "M":
+		return t.AddDate(0, n, 0)
+	case "Q":
+		return t.AddDate(0, 3*n, 0)
+	case "y":
+		return t.AddDate(n, 0, 0)
+	}
+	return t.AddDate(0, 0, n)
+}
+
+// templater renders a <% ... %> tag; after is the template text that
+// follows it. It returns how many bytes of after were consumed by
+// whitespace control.
+func (r *renderer) templater(tag, after string) (int, error) {
+	inner := tag[2 : len(tag)-2]
+
+	// <%- and <%_ trim whitespace before the tag, once it is known to run
+	trimBefore := byte(0)
+	if strings.HasPrefix(inner, "-") || strings.HasPrefix(inner, "_") {
+		trimBefore = inner[0]
+		inner = inner[1:]
+	}
+	// -%> and _%> trim whitespace after it
+	consumed := 0
+	if strings.HasSuffix(inner, "-") || strings.HasSuffix(inner, "_") {
+		consumed = trimmedPrefix(after, inner[len(inner)-1] == '-')
+		inner = inner[:len(inner)-1]
+	}
+
+	expr := strings.TrimSuffix(strings.TrimSpace(inner), ";")
+	if strings.HasPrefix(inner, "*") {
+		r.out.WriteString(tag) // JavaScript blocks are not run
+		return 0, nil
+	}
+
+	call, ok := parseCall(strings.TrimSpace(expr))
+	if !ok {
+		r.out.WriteString(tag)
+		return 0, nil
+	}
+	value, ok, err := r.eval(call)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		r.out.WriteString(tag)
+		return 0, nil
+	}
+	if trimBefore != 0 {
+		r.trimOutput(trimBefore == '-')
+	}
+	r.out.WriteString(value)
+	return consumed, nil
+}
+
+// trimOutput removes whitespace at the end of the output: one newline
+// (with the indentation after it) when newlineOnly is set, all of it
+// otherwise.
+func (r *renderer) trimOutput(newlineOnly bool) {
+	s := r.out.String()
+	trimmed := strings.TrimRight(s, " \t")
+	if newlineOnly {
+		if strings.HasSuffix(trimmed, "\n") {
+			trimmed = strings.TrimSuffix(strings.TrimSuffix(trimmed, "\n"), "\r")
+		} else {
+			trimmed = s
+		}
+	} else {
+		trimmed = strings.TrimRight(s, " \t\r\n")
+	}
+	r.out.Reset()
+	r.out.WriteString(trimmed)
+
+	// Cursors in the trimmed whitespace move to where it was
+	for i := range r.cursors {
+		r.cursors[i].offset = min(r.cursors[i].offset, len(trimmed))
+	}
+}
+
+// trimmedPrefix returns the length of the whitespace to drop at the start
+// of s: up to one newline when newlineOnly is set, all of it otherwise.
+func trimmedPrefix(s string, newlineOnly bool) int {
+	if !newlineOnly {
+		return len(s) - len(strings.TrimLeft(s, " \t\r\n"))
+	}
+	rest := strings.TrimLeft(s, " \t")
+	rest = strings.TrimPrefix(rest, "\r")
+	if !strings.HasPrefix(rest, "\n") {
+		return 0
+	}
+	return len(s) - len(rest) + 1
+}
+
+// call is a parsed Templater expression such as tp.date.now("YYYY", -1).
+// A property access like tp.file.title has no arguments and isCall unset.
+type call struct {
+	name   string
+	args   []any // string, float64 or *call
+	isCall bool
+}
+
+// eval evaluates a Templater call. It reports false for unknown ones.
+func (r *renderer) eval(c *call) (string, bool, error) {
+	args := make([]any, len(c.args))
+	for i, a := range c.args {
+		if nested, ok := a.(*call); ok {
+			value, ok, err := r.eval(nested)
+			if err != nil || !ok {
+				return "", ok, err
+			}
+			args[i] = value
+			continue
+		}
+		args[i] = a
+	}
+	str := func(i int, def string) string {
+		if i < len(args) {
+			if s, ok := args[i].(string); ok {
+				return s
+			}
+		}
+		return def
+	}
+
+	switch c.name {
+	case "tp.file.title":
+		return r.ctx.Title, true, nil
+
+	case "tp.file.folder":
+		if relative, _ := argBool(args, 0); relative {
+			return r.ctx.Folder, true, nil
+		}
+		return path.Base("/" + r.ctx.Folder), true, nil
+
+	case "tp.file.creation_date", "tp.file.last_modified_date":
+		return periodic.Format(r.ctx.Now, str(0, "YYYY-MM-DD HH:mm")), true, nil
+
+	case "tp.file.cursor":
+		order := 0
+		if n, ok := argNumber(args, 0); ok {
+			order = int(n)
+		}
+		r.cursor(order)
+		return "", true, nil
+
+	case "tp.date.now":
+		base := r.reference(r.ctx.Now, str(2, ""), str(3, ""))
+		return periodic.Format(offset(base, args, 1), str(0, "YYYY-MM-DD")), true, nil
+
+	case "tp.date.today":
+		return periodic.Format(r.ctx.Now, str(0, "YYYY-MM-DD")), true, nil
+
+	case "tp.date.tomorrow":
+		return periodic.Format(r.ctx.Now.AddDate(0, 0, 1), str(0, "YYYY-MM-DD")), true, nil
+
+	case "tp.date.yesterday":
+		return periodic.Format(r.ctx.Now.AddDate(0, 0, -1), str(0, "YYYY-MM-DD")), true, nil
+
+	case "tp.date.weekday":
+		base := r.reference(r.ctx.Now, str(2, ""), str(3, ""))
+		day, _ := argNumber(args, 1)
+		monday := periodic.Start(types.GoalPeriodWeek, base)
+		return periodic.Format(monday.AddDate(0, 0, int(day)), str(0, "YYYY-MM-DD")), true, nil
+
+	case "tp.system.prompt":
+		question, def := str(0, ""), str(1, "")
+		if r.ctx.Prompt == nil {
+			return def, true, nil
+		}
+		answer, err := r.ctx.Prompt(question, def)
+		if err != nil {
+			return "", false, fmt.Errorf("prompt %q: %w", question, err)
+		}
+		return answer, true, nil
+	}
+
+	return "", false, nil
+}
+
+// reference returns the date in ref, read with the Moment.js format
+// refFormat, or def when there is none.
+func (r *renderer) reference(def time.Time, ref, refFormat string) time.Time {
+	if ref == "" {
+		return def
+	}
+	if refFormat == "" {
+		refFormat = "YYYY-MM-DD"
+	}
+	if t, ok := periodic.Parse(refFormat, ref, def.Location()); ok {
+		return t
+	}
+	return def
+}
+
+// isoDurationPattern matches ISO 8601 durations in days and larger units,
+// such as "P1W" or "P-1M"; Templater also accepts a leading minus.
+var isoDurationPattern = regexp.MustCompile(`^(-)?P(?:(-?\d+)Y)?(?:(-?\d+)M)?(?:(-?\d+)W)?(?:(-?\d+)D)?$`)
+
+// offset shifts t by the Templater offset in args[i]: a number of days or
+// an ISO 8601 duration.
+func offset(t time.Time, args []any, i int) time.Time {
+	if i >= len(args) {
+		return t
+	}
+	switch v := args[i].(type) {
+	case float64:
+		return t.AddDate(0, 0, int(v))
+	case string:
+		if days, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			return t.AddDate(0, 0, days)
+		}
+		m := isoDurationPattern.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(v)))
+		if m == nil {
+			return t
+		}
+		sign := 1
+		if m[1] == "-" {
+			sign = -1
+		}
+		part := func(s string) int {
+			n, _ := strconv.Atoi(s)
+			return n * sign
+		}
+		return t.AddDate(part(m[2]), part(m[3]), 7*part(m[4])+part(m[5]))
+	}
+	return t
+}
+
+func argNumber(args []any, i int) (float64, bool) {
+	if i >= len(args) {
+		return 0, false
+	}
+	switch v := args[i].(type) {
+	case float64:
+		return v, true
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+func argBool(args []any, i int) (bool, bool) {
+	if i >= len(args) {
+		return false, false
+	}
+	s, ok := args[i].(string)
+	return ok && s == "true", ok
+}
+
+// parseCall parses a Templater expression: a dotted name, optionally
+// called with string, number, boolean or nested expression arguments.
+func parseCall(expr string) (*call, bool) {
+	p := &exprParser{s: expr}
+	c, ok := p.call()
+	if !ok {
+		return nil, false
+	}
+	p.space()
+	return c, p.i == len(p.s)
+}
+
+type exprParser struct {
+	s string
+	i int
+}
+
+func (p *exprParser) space() {
+	for p.i < len(p.s) && (p.s[p.i] == ' ' || p.s[p.i] == '\t' || p.s[p.i] == '\n') {
+		p.i++
+	}
+}
+
+func (p *exprParser) call() (*call, bool) {
+	p.space()
+	start := p.i
+	for p.i < len(p.s) {
+		ch := p.s[p.i]
+		if ch == '.' || ch == '_' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9' && p.i > start {
+			p.i++
+			continue
+		}
+		break
+	}
+	if p.i == start {
+		return nil, false
+	}
+	c := &call{name: p.s[start:p.i]}
+
+	p.space()
+	if p.i >= len(p.s) || p.s[p.i] != '(' {
+		return c, true
+	}
+	p.i++
+	c.isCall = true
+
+	for {
+		p.space()
+		if p.i < len(p.s) && p.s[p.i] == ')' {
+			p.i++
+			return c, true
+		}
+		arg, ok := p.arg()
+		if !ok {
+			return nil, false
+		}
+		c.args = append(c.args, arg)
+		p.space()
+		if p.i < len(p.s) && p.s[p.i] == ',' {
+			p.i++
+		}
+	}
+}
+
+func (p *exprParser) arg() (any, bool) {
+	if p.i >= len(p.s) {
+		return nil, false
+	}
+	switch ch := p.s[p.i]; {
+	case ch == '"' || ch == '\'' || ch == '`':
+		end := strings.IndexByte(p.s[p.i+1:], ch)
+		if end == -1 {
+			return nil, false
+		}
+		value := p.s[p.i+1 : p.i+1+end]
+		p.i += end + 2
+		return value, true
+
+	case ch == '-' || ch >= '0' && ch <= '9':
+		start := p.i
+		p.i++
+		for p.i < len(p.s) && (p.s[p.i] == '.' || p.s[p.i] >= '0' && p.s[p.i] <= '9') {
+			p.i++
+		}
+		n, err := strconv.ParseFloat(p.s[start:p.i], 64)
+		return n, err == nil
+
+	default:
+		c, ok := p.call()
+		if !ok {
+			return nil, false
+		}
+		// Bare true and false are booleans, kept as strings
+		if !c.isCall && (c.name == "true" || c.name == "false") {
+			return c.name, true
+		}
+		return c, true
+	}
+}
@@ -2,6 +2,7 @@
 package ui
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
@@ -11,9 +12,13 @@ import (
 
 	"github.com/BioWare/lazyobsidian/internal/cache"
 	"github.com/BioWare/lazyobsidian/internal/config"
+	"github.com/BioWare/lazyobsidian/internal/goals"
 	"github.com/BioWare/lazyobsidian/internal/i18n"
+	"github.com/BioWare/lazyobsidian/internal/indexer"
 	"github.com/BioWare/lazyobsidian/internal/logging"
+	"github.com/BioWare/lazyobsidian/internal/periodic"
 	"github.com/BioWare/lazyobsidian/internal/pomodoro"
+	"github.com/BioWare/lazyobsidian/internal/ui/components"
 	"github.com/BioWare/lazyobsidian/internal/ui/icons"
 	"github.com/BioWare/lazyobsidian/internal/ui/layout"
 	"github.com/BioWare/lazyobsidian/internal/ui/theme"
@@ -66,6 +71,9 @@ type App struct {
 	parser      *vault.Parser
 	writer      *vault.Writer
 	watcher     *watcher.Watcher
+	indexer     *indexer.Indexer
+	indexCtx    context.Context
+	cancelIndex context.CancelFunc
 	width       int
 	height      int
 	currentView View
@@ -73,6 +81,11 @@ type App struct {
 	sidebar     *Sidebar
 	quitting    bool
 	err         error
+	statusMsg   string // shown in the footer, e.g. why an edit failed
+
+	// Footer text input, open while a task is being added or edited
+	input       *components.TextInput
+	inputSubmit func(value string) error
 
 	// Dashboard navigation
 	focusedModule  DashboardModule
@@ -80,18 +93,34 @@ type App struct {
 
 	// Pomodoro timer
 	pomodoroTimer *pomodoro.Timer
+	pomodoroDone  chan types.PomodoroSession // finished sessions, to be logged
+	pomodoroTask  *types.Task                // task the session is run for, if any
+	pomodoroNote  string                     // note of pomodoroTask
 
 	// Data loaded from vault
 	todayTasks      []types.Task
 	todayNotePath   string // Path to today's daily note
 	activeCourses   []types.Course
-	currentBook     *types.Book
+	books           []types.Book
+	currentBook     *types.Book // first book being read, in books
 	recentNotes     []views.RecentNote
 	dailyGoal       *types.DailyGoal
 	weeklyStats     views.WeeklyStats
 
 	// Goals data
 	goals           []types.Goal
+
+	// Books and goals views, kept for their selection
+	booksView *views.BooksView
+	goalsView *views.GoalsView
+
+	// Calendar state
+	todaySchedule    []types.ScheduleBlock
+	calendarMode     views.CalendarViewMode
+	calendarDate     time.Time // Selected day; zero means today
+	calendarTasks    []types.Task
+	calendarSchedule []types.ScheduleBlock
+	calendarPeriods  []views.CalendarPeriod // periodic notes covering the selected day
 }
 
 // New creates a new App instance.
@@ -103,21 +132,40 @@ func New(cfg *config.Config, c *cache.Cache, p *vault.Parser, w *watcher.Watcher
 		LongBreakMinutes:   cfg.Pomodoro.LongBreak,
 		SessionsBeforeLong: cfg.Pomodoro.SessionsBeforeLong,
 		DailyGoal:          cfg.Pomodoro.DailyGoal,
+		AutoStartBreak:     cfg.Pomodoro.AutoStartBreak,
+	})
+
+	// The timer finishes sessions on its own goroutine; they are logged on
+	// the next tick
+	done := make(chan types.PomodoroSession, 16)
+	timer.OnComplete(func(session types.PomodoroSession) {
+		select {
+		case done <- session:
+		default:
+			logging.Warn("Pomodoro session at %s not logged", session.StartedAt.Format("15:04"))
+		}
 	})
 
 	// Initialize vault writer
 	writer := vault.NewWriter(cfg.Vault.Path, cfg, p)
 
+	// Indexing stops when the app quits
+	indexCtx, cancelIndex := context.WithCancel(context.Background())
+
 	return &App{
 		config:        cfg,
 		cache:         c,
 		parser:        p,
 		writer:        writer,
 		watcher:       w,
+		indexer:       indexer.New(cfg.Vault.Path, p, c, p.LinkResolver()),
+		indexCtx:      indexCtx,
+		cancelIndex:   cancelIndex,
 		currentView:   ViewDashboard,
 		focus:         FocusSidebar,
 		sidebar:       NewSidebar(),
 		pomodoroTimer: timer,
+		pomodoroDone:  done,
 	}
 }
 
@@ -125,6 +173,7 @@ func New(cfg *config.Config, c *cache.Cache, p *vault.Parser, w *watcher.Watcher
 type dataLoadedMsg struct{}
 type fileChangedMsg struct {
 	path string
+	op   watcher.EventType
 }
 type tickMsg time.Time
 
@@ -152,6 +201,9 @@ func (a *App) loadInitialData() tea.Cmd {
 	return func() tea.Msg {
 		logging.Info("Loading initial data from vault...")
 
+		// Bring the cache up to date, re-parsing only changed files
+		a.indexVault()
+
 		// Parse and cache today's daily note
 		today := time.Now()
 		logging.Debug("Checking for daily note: %s", today.Format("2006-01-02"))
@@ -161,9 +213,13 @@ func (a *App) loadInitialData() tea.Cmd {
 			file, err := a.parser.ParseDailyNote(today)
 			if err != nil {
 				logging.Error("Failed to parse daily note: %v", err)
-			} else if file != nil {
-				a.cache.SaveFile(file)
+			}
+			if file != nil && (err == nil || vault.IsFrontmatterError(err)) {
+				if err := a.indexer.Save(file); err != nil {
+					logging.Error("Failed to cache %s: %v", file.Path, err)
+				}
 				a.todayTasks = file.Tasks
+				a.todaySchedule = file.Schedule
 				a.todayNotePath = file.Path
 				logging.Info("Loaded %d tasks from daily note: %s", len(a.todayTasks), file.Path)
 			}
@@ -171,6 +227,8 @@ func (a *App) loadInitialData() tea.Cmd {
 			logging.Debug("No daily note found for today")
 		}
 
+		a.calendarPeriods = a.loadPeriods(today)
+
 		// Load courses from cache/vault
 		courseFiles, err := a.cache.GetFilesByType(types.FileTypeCourse)
 		if err != nil {
@@ -178,21 +236,6 @@ func (a *App) loadInitialData() tea.Cmd {
 		}
 		logging.Debug("Found %d course files in cache", len(courseFiles))
 
-		if len(courseFiles) == 0 {
-			// Parse from vault if cache is empty
-			logging.Info("Cache empty, parsing vault...")
-			files, err := a.parser.ParseVault()
-			if err != nil {
-				logging.Error("Failed to parse vault: %v", err)
-			} else {
-				logging.Info("Parsed %d files from vault", len(files))
-				for _, f := range files {
-					a.cache.SaveFile(f)
-				}
-			}
-			courseFiles, _ = a.cache.GetFilesByType(types.FileTypeCourse)
-		}
-
 		// Convert course files to Course structs
 		for _, f := range courseFiles {
 			course := a.parseCourseFromFile(f)
@@ -202,17 +245,23 @@ func (a *App) loadInitialData() tea.Cmd {
 		}
 		logging.Info("Loaded %d active courses", len(a.activeCourses))
 
-		// Load books
+		// Load the library; the most recently touched book being read
+		// is shown on the dashboard
 		bookFiles, _ := a.cache.GetFilesByType(types.FileTypeBook)
 		logging.Debug("Found %d book files", len(bookFiles))
 		for _, f := range bookFiles {
-			book := a.parseBookFromFile(f)
-			if book != nil {
-				a.currentBook = book
-				logging.Info("Current book: %s", book.Title)
-				break // Just get the first/most recent book
+			if book := a.parseBookFromFile(f); book != nil {
+				a.books = append(a.books, *book)
+			}
+		}
+		for i := range a.books {
+			if a.books[i].Status == types.BookReading {
+				a.currentBook = &a.books[i]
+				logging.Info("Current book: %s", a.currentBook.Title)
+				break
 			}
 		}
+		logging.Info("Loaded %d books", len(a.books))
 
 		// Load recent notes
 		recentFiles, _ := a.cache.GetRecentFiles(5)
@@ -225,14 +274,7 @@ func (a *App) loadInitialData() tea.Cmd {
 		}
 		logging.Debug("Loaded %d recent notes", len(a.recentNotes))
 
-		// Load goals
-		goals, err := a.parser.ParseGoals()
-		if err != nil {
-			logging.Error("Failed to parse goals: %v", err)
-		} else {
-			a.goals = goals
-			logging.Info("Loaded %d goals", len(a.goals))
-		}
+		a.loadGoals()
 
 		// Load daily goal
 		a.dailyGoal, _ = a.cache.GetDailyGoal(today)
@@ -264,6 +306,23 @@ func (a *App) loadInitialData() tea.Cmd {
 	}
 }
 
+// loadGoals parses the goal notes and periodic notes into the goal tree.
+func (a *App) loadGoals() {
+	flatGoals, err := a.parser.ParseGoals()
+	if err != nil {
+		logging.Error("Failed to parse goals: %v", err)
+		return
+	}
+	a.goals = nil
+	for _, g := range goals.Build(flatGoals).Roots() {
+		a.goals = append(a.goals, *g)
+	}
+	if a.goalsView != nil {
+		a.goalsView.SetGoals(a.goals)
+	}
+	logging.Info("Loaded %d goals (%d top-level)", len(flatGoals), len(a.goals))
+}
+
 // startFileWatcher starts listening for file changes.
 func (a *App) startFileWatcher() tea.Cmd {
 	if a.watcher == nil {
@@ -277,7 +336,7 @@ func (a *App) startFileWatcher() tea.Cmd {
 		// Wait for first event (blocks)
 		select {
 		case event := <-a.watcher.Events:
-			return fileChangedMsg{path: event.Path}
+			return fileChangedMsg{path: event.Path, op: event.Type}
 		case <-time.After(time.Second):
 			// Timeout, return empty to re-poll
 			return nil
@@ -294,68 +353,50 @@ func (a *App) waitForFileEvent() tea.Cmd {
 	return func() tea.Msg {
 		select {
 		case event := <-a.watcher.Events:
-			return fileChangedMsg{path: event.Path}
+			return fileChangedMsg{path: event.Path, op: event.Type}
 		case <-time.After(time.Second):
 			return nil
 		}
 	}
 }
 
-// parseCourseFromFile converts a File to a Course.
-func (a *App) parseCourseFromFile(f *types.File) *types.Course {
-	if f == nil || f.Frontmatter == nil {
-		return nil
-	}
-
-	course := &types.Course{
-		FileID: f.ID,
-		Title:  f.Title,
+// indexVault runs the incremental indexer over the whole vault.
+func (a *App) indexVault() {
+	a.indexer.OnProgress = func(p indexer.Progress) {
+		if p.Done > 0 && (p.Done%500 == 0 || p.Done == p.Total) {
+			logging.Debug("Indexed %d/%d files (%d parsed, %d unchanged)", p.Done, p.Total, p.Parsed, p.Skipped)
+		}
 	}
 
-	if source, ok := f.Frontmatter["source"].(string); ok {
-		course.Source = source
+	start := time.Now()
+	result, err := a.indexer.Run(a.indexCtx)
+	if err != nil {
+		logging.Error("Failed to index vault: %v", err)
 	}
-	if url, ok := f.Frontmatter["url"].(string); ok {
-		course.URL = url
+	for _, e := range result.Errors {
+		logging.Warn("Indexing: %v", e)
 	}
+	logging.Info("Indexed %d files in %s: %d parsed, %d unchanged, %d removed, %d errors",
+		result.Total, time.Since(start).Round(time.Millisecond),
+		result.Parsed, result.Skipped, result.Removed, len(result.Errors))
+}
 
-	// Count completed and total lessons from tasks
-	completed, total := vault.CountTasks(f.Tasks)
-	course.TotalLessons = total
-	course.Completed = completed
-
-	return course
+// parseCourseFromFile converts a File to a Course.
+func (a *App) parseCourseFromFile(f *types.File) *types.Course {
+	if f == nil {
+		return nil
+	}
+	course := vault.CourseFromFile(f)
+	return &course
 }
 
 // parseBookFromFile converts a File to a Book.
 func (a *App) parseBookFromFile(f *types.File) *types.Book {
-	if f == nil || f.Frontmatter == nil {
+	if f == nil {
 		return nil
 	}
-
-	book := &types.Book{
-		FileID: f.ID,
-		Title:  f.Title,
-	}
-
-	if author, ok := f.Frontmatter["author"].(string); ok {
-		book.Author = author
-	}
-	if pages, ok := f.Frontmatter["total_pages"].(string); ok {
-		fmt.Sscanf(pages, "%d", &book.TotalPages)
-	}
-	if current, ok := f.Frontmatter["current_page"].(string); ok {
-		fmt.Sscanf(current, "%d", &book.CurrentPage)
-	}
-
-	// Count completed chapters from tasks
-	completed, total := vault.CountTasks(f.Tasks)
-	if book.TotalPages == 0 {
-		book.TotalPages = total
-		book.CurrentPage = completed
-	}
-
-	return book
+	book := vault.BookFromFile(f)
+	return &book
 }
 
 // Update implements tea.Model.
@@ -371,23 +412,63 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case pomodoroTickMsg:
 		// The pomodoro timer runs its own goroutine, we just need to refresh the UI
+		// and log the sessions it finished
+		a.logPomodoros()
 		// Continue ticking to keep the UI updated
 		return a, a.tickPomodoro()
 
 	case dataLoadedMsg:
-		// Data loaded, nothing special to do
+		// Views opened while loading show the loaded data
+		if a.booksView != nil {
+			a.booksView.SetBooks(a.books)
+		}
+		if a.goalsView != nil {
+			a.goalsView.SetGoals(a.goals)
+		}
 		return a, nil
 
 	case fileChangedMsg:
+		// Drop deleted or renamed-away files; links into them may now
+		// resolve elsewhere or not at all
+		if msg.path != "" && (msg.op == watcher.EventDelete || msg.op == watcher.EventRename) {
+			if err := a.indexer.Remove(msg.path); err != nil {
+				logging.Warn("Failed to remove %s from cache: %v", msg.path, err)
+			}
+			a.indexer.ResolveUnresolvedLinks()
+			return a, a.waitForFileEvent()
+		}
+
 		// Re-parse the changed file
 		if msg.path != "" {
 			file, err := a.parser.ParseFile(msg.path)
-			if err == nil && file != nil {
-				a.cache.SaveFile(file)
+			if err != nil {
+				logging.Warn("Failed to parse changed file %s: %v", msg.path, err)
+			}
+			if file != nil && (err == nil || vault.IsFrontmatterError(err)) {
+				if err := a.indexer.Save(file); err != nil {
+					logging.Error("Failed to cache %s: %v", file.Path, err)
+				}
+				if msg.op == watcher.EventCreate {
+					a.indexer.ResolveUnresolvedLinks()
+				}
 
-				// If it's today's daily note, update tasks
+				// If it's today's daily note, update tasks and schedule
 				if file.Type == types.FileTypeDaily {
 					a.todayTasks = file.Tasks
+					a.todaySchedule = file.Schedule
+				}
+				if file.Type == types.FileTypeBook {
+					a.setBook(file)
+				}
+
+				// Plans roll up into the goal tree and the calendar
+				if file.Type == types.FileTypeGoal {
+					a.loadGoals()
+					date := a.calendarDate
+					if date.IsZero() {
+						date = time.Now()
+					}
+					a.calendarPeriods = a.loadPeriods(date)
 				}
 			}
 		}
@@ -403,10 +484,17 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (a *App) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// An open text input takes every key
+	if a.input != nil {
+		return a.handleInputKeys(msg)
+	}
+	a.statusMsg = ""
+
 	// Global keybindings
 	switch msg.String() {
 	case "q", "ctrl+c":
 		a.quitting = true
+		a.cancelIndex()
 		return a, tea.Quit
 
 	case "tab":
@@ -505,14 +593,14 @@ func (a *App) handleMainKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		state := a.pomodoroTimer.State()
 		switch state {
 		case pomodoro.StateIdle:
-			a.pomodoroTimer.Start("")
+			a.startPomodoro()
 		case pomodoro.StateRunning:
 			a.pomodoroTimer.Pause()
 		case pomodoro.StatePaused:
 			a.pomodoroTimer.Resume()
 		case pomodoro.StateBreak:
 			a.pomodoroTimer.Stop()
-			a.pomodoroTimer.Start("")
+			a.startPomodoro()
 		}
 		logging.Debug("Pomodoro toggled, state: %d", a.pomodoroTimer.State())
 		return a, nil
@@ -532,12 +620,26 @@ func (a *App) handleMainKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		// Subtract minute from timer
 		a.pomodoroTimer.AdjustTime(-1)
 		return a, nil
+
+	case "u":
+		a.undo(false)
+		return a, nil
+
+	case "ctrl+r":
+		a.undo(true)
+		return a, nil
 	}
 
 	// Delegate to current view's handler
 	switch a.currentView {
 	case ViewDashboard:
 		return a.handleDashboardKeys(msg)
+	case ViewCalendar:
+		return a.handleCalendarKeys(msg)
+	case ViewGoals:
+		return a.handleGoalsKeys(msg)
+	case ViewBooks:
+		return a.handleBooksKeys(msg)
 	default:
 		// Other views not implemented yet
 		logging.Debug("View %s navigation not implemented", a.currentView)
@@ -553,9 +655,9 @@ func (a *App) handleDashboardKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch key {
 	case "j", "down":
 		// Move down within current module or to next module
-		if a.focusedModule == ModuleTodayFocus && len(a.todayTasks) > 0 {
+		if a.focusedModule == ModuleTodayFocus && len(a.focusTasks()) > 0 {
 			// Navigate within tasks
-			if a.selectedTask < len(a.todayTasks)-1 {
+			if a.selectedTask < len(a.focusTasks())-1 {
 				a.selectedTask++
 				logging.Debug("Task selection: %d", a.selectedTask)
 			} else {
@@ -576,8 +678,8 @@ func (a *App) handleDashboardKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		} else if a.focusedModule > 0 {
 			a.focusedModule--
 			// If entering TodayFocus, select last task
-			if a.focusedModule == ModuleTodayFocus && len(a.todayTasks) > 0 {
-				a.selectedTask = len(a.todayTasks) - 1
+			if a.focusedModule == ModuleTodayFocus && len(a.focusTasks()) > 0 {
+				a.selectedTask = len(a.focusTasks()) - 1
 			}
 			logging.Debug("Module changed to: %d", a.focusedModule)
 		}
@@ -587,7 +689,7 @@ func (a *App) handleDashboardKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		logging.Debug("Enter pressed on module: %d", a.focusedModule)
 		switch a.focusedModule {
 		case ModuleTodayFocus:
-			if len(a.todayTasks) > 0 && a.selectedTask < len(a.todayTasks) {
+			if a.selectedTask < len(a.focusTasks()) {
 				// Toggle task status
 				return a.toggleTask(a.selectedTask)
 			}
@@ -598,10 +700,16 @@ func (a *App) handleDashboardKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 	case "x", " ":
 		// Toggle task completion (spacebar or x)
-		if a.focusedModule == ModuleTodayFocus && len(a.todayTasks) > 0 {
+		if a.focusedModule == ModuleTodayFocus && len(a.focusTasks()) > 0 {
 			return a.toggleTask(a.selectedTask)
 		}
 
+	case "a", "A", "e", "D", ">", "<", "J", "K", "m", "W":
+		// Add, edit, delete, indent, reorder and move tasks
+		if a.focusedModule == ModuleTodayFocus {
+			a.handleTaskEditKeys(key)
+		}
+
 	case "g":
 		// Go to top
 		a.focusedModule = ModuleTodayFocus
@@ -617,13 +725,120 @@ func (a *App) handleDashboardKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return a, nil
 }
 
+// handleCalendarKeys handles keyboard input for the calendar view.
+func (a *App) handleCalendarKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	date := a.calendarDate
+	if date.IsZero() {
+		date = time.Now()
+	}
+
+	switch msg.String() {
+	case "d":
+		a.calendarMode = views.CalendarModeDay
+	case "m":
+		a.calendarMode = views.CalendarModeMonth
+	case "y":
+		a.calendarMode = views.CalendarModeYear
+	case "]":
+		a.selectCalendarDate(date.AddDate(0, 0, 1))
+	case "[":
+		a.selectCalendarDate(date.AddDate(0, 0, -1))
+	case "}":
+		a.selectCalendarDate(date.AddDate(0, 0, 7))
+	case "{":
+		a.selectCalendarDate(date.AddDate(0, 0, -7))
+	case "t":
+		a.selectCalendarDate(time.Now())
+	case ">":
+		a.selectCalendarDate(periodic.Next(types.GoalPeriodMonth, date))
+	case "<":
+		a.selectCalendarDate(periodic.Prev(types.GoalPeriodMonth, date))
+	case "n":
+		a.createPeriodicNote(types.GoalPeriodWeek, date)
+	case "N":
+		a.createPeriodicNote(types.GoalPeriodMonth, date)
+	}
+
+	return a, nil
+}
+
+// createPeriodicNote creates the weekly or monthly note for date if it
+// does not exist yet.
+func (a *App) createPeriodicNote(period types.GoalPeriod, date time.Time) {
+	note, err := a.writer.CreatePeriodicNote(period, date)
+	if err != nil {
+		logging.Error("Failed to create %s note: %v", period, err)
+		a.err = err
+		return
+	}
+	logging.Info("Periodic note ready: %s", note.Path)
+	a.calendarPeriods = a.loadPeriods(date)
+}
+
+// loadPeriods parses the periodic notes covering date, broadest first.
+func (a *App) loadPeriods(date time.Time) []views.CalendarPeriod {
+	var result []views.CalendarPeriod
+	for _, period := range periodic.Periods {
+		if _, err := a.parser.PeriodicNotePath(period, date); err != nil {
+			continue // not configured
+		}
+		p := views.CalendarPeriod{
+			Period: period,
+			Label:  periodic.Label(period, date),
+		}
+		if a.parser.PeriodicNoteExists(period, date) {
+			file, err := a.parser.ParsePeriodicNote(period, date)
+			if err != nil {
+				logging.Warn("Failed to parse %s note for %s: %v", period, p.Label, err)
+			}
+			if file != nil && (err == nil || vault.IsFrontmatterError(err)) {
+				p.Exists = true
+				p.Tasks = file.Tasks
+			}
+		}
+		result = append(result, p)
+	}
+	return result
+}
+
+// selectCalendarDate selects a day in the calendar and loads its daily
+// note's tasks and schedule.
+func (a *App) selectCalendarDate(date time.Time) {
+	a.calendarTasks = nil
+	a.calendarSchedule = nil
+	a.calendarPeriods = a.loadPeriods(date)
+
+	if sameDay(date, time.Now()) {
+		a.calendarDate = time.Time{}
+		return
+	}
+	a.calendarDate = date
+
+	if !a.parser.DailyNoteExists(date) {
+		return
+	}
+	file, err := a.parser.ParseDailyNote(date)
+	if err != nil {
+		logging.Warn("Failed to parse daily note for %s: %v", date.Format("2006-01-02"), err)
+	}
+	if file != nil && (err == nil || vault.IsFrontmatterError(err)) {
+		a.calendarTasks = file.Tasks
+		a.calendarSchedule = file.Schedule
+	}
+}
+
+func sameDay(a, b time.Time) bool {
+	return a.Year() == b.Year() && a.YearDay() == b.YearDay()
+}
+
 // toggleTask toggles the completion status of a task.
 func (a *App) toggleTask(index int) (tea.Model, tea.Cmd) {
-	if index < 0 || index >= len(a.todayTasks) {
+	tasks := a.focusTasks()
+	if index < 0 || index >= len(tasks) {
 		return a, nil
 	}
 
-	task := &a.todayTasks[index]
+	task := &tasks[index]
 	logging.Info("Toggling task: %s (current status: %s)", task.Text, task.Status)
 
 	// Save to file
@@ -631,9 +846,14 @@ func (a *App) toggleTask(index int) (tea.Model, tea.Cmd) {
 		if err := a.writer.ToggleTask(a.todayNotePath, task); err != nil {
 			logging.Error("Failed to toggle task in file: %v", err)
 			a.err = err
+			// Show the note as it is now, so the next try hits the right task
+			if vault.IsConflictError(err) {
+				a.reloadToday()
+			}
 			return a, nil
 		}
 		logging.Debug("Task saved to file: %s -> %s", task.Text, task.Status)
+		a.reloadToday()
 	} else {
 		// Toggle in memory only (no daily note path)
 		if task.Status == "done" {
@@ -777,18 +997,31 @@ func (a *App) renderMainPanel(width, height int) string {
 	case ViewCalendar:
 		calendar := views.NewCalendar(width, height)
 		calendar.SetFocused(a.focus == FocusMain)
-		// Convert today's tasks to calendar events
-		events := views.ConvertTasksToEvents(a.todayTasks)
+		calendar.SetMode(a.calendarMode)
+
+		date := calendar.Today
+		tasks, schedule := a.todayTasks, a.todaySchedule
+		if !a.calendarDate.IsZero() {
+			date = a.calendarDate
+			tasks, schedule = a.calendarTasks, a.calendarSchedule
+		}
+		calendar.CurrentDate = date
+		calendar.SelectedDate = date
+
+		// Convert the selected day's tasks to calendar events
+		events := views.ConvertTasksToEvents(tasks)
 		for i := range events {
-			events[i].Date = time.Now()
+			events[i].Date = date
 		}
 		calendar.SetEvents(events)
+		calendar.SetSchedule(schedule)
+		calendar.SetPeriods(a.calendarPeriods)
 		content = calendar.Render()
 
 	case ViewGoals:
-		goalsView := views.NewGoalsView(width, height)
+		goalsView := a.goalsPanel()
+		goalsView.SetSize(width, height)
 		goalsView.SetFocused(a.focus == FocusMain)
-		goalsView.SetGoals(a.goals)
 		content = goalsView.Render()
 
 	case ViewCourses:
@@ -798,13 +1031,9 @@ func (a *App) renderMainPanel(width, height int) string {
 		content = coursesView.Render()
 
 	case ViewBooks:
-		booksView := views.NewBooksView(width, height)
+		booksView := a.booksPanel()
+		booksView.SetSize(width, height)
 		booksView.SetFocused(a.focus == FocusMain)
-		var books []types.Book
-		if a.currentBook != nil {
-			books = append(books, *a.currentBook)
-		}
-		booksView.SetBooks(books)
 		content = booksView.Render()
 
 	case ViewWishlist:
@@ -871,6 +1100,17 @@ func (a *App) renderFooter() string {
 	bgColor := theme.Current.Color("bg_secondary")
 	keyStyle := theme.S.HelpKey
 	descStyle := theme.S.HelpDesc
+	footerStyle := lipgloss.NewStyle().Background(bgColor)
+
+	// The text input and status messages take the footer's place
+	if a.input != nil {
+		a.input.PromptStyle = keyStyle
+		return footerStyle.Render(layout.FitToWidth(" "+a.input.Render(a.width-2), a.width))
+	}
+	if a.statusMsg != "" {
+		msgStyle := lipgloss.NewStyle().Foreground(theme.Current.Color("error"))
+		return footerStyle.Render(layout.FitToWidth(" "+msgStyle.Render(a.statusMsg), a.width))
+	}
 
 	hints := []struct {
 		key  string
@@ -894,7 +1134,6 @@ func (a *App) renderFooter() string {
 
 	// Fit to width and apply background to entire footer
 	footer = layout.FitToWidth(footer, a.width)
-	footerStyle := lipgloss.NewStyle().Background(bgColor)
 	return footerStyle.Render(footer)
 }
 
@@ -970,6 +1209,7 @@ func Run(cfg *config.Config) error {
 		w = nil
 	}
 	if w != nil {
+		w.Ignore = parser.Ignore()
 		defer w.Stop()
 	}
 
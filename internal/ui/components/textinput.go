@@ -0,0 +1,111 @@
+package components
+
+import (
+	"github.com/charmbracelet/lipgloss"
+)
+
+// TextInput is a single-line text field with a prompt.
+type TextInput struct {
+	Prompt      string
+	PromptStyle lipgloss.Style
+	TextStyle   lipgloss.Style
+	CursorStyle lipgloss.Style
+
+	value  []rune
+	cursor int // position in value, in runes
+}
+
+// NewTextInput creates a text input holding value, with the cursor at
+// its end.
+func NewTextInput(prompt, value string) *TextInput {
+	runes := []rune(value)
+	return &TextInput{
+		Prompt:      prompt,
+		PromptStyle: lipgloss.NewStyle().Bold(true),
+		CursorStyle: lipgloss.NewStyle().Reverse(true),
+		value:       runes,
+		cursor:      len(runes),
+	}
+}
+
+// Value returns the text entered so far.
+func (t *TextInput) Value() string {
+	return string(t.value)
+}
+
+// HandleKey applies a key, as named by bubbletea, to the text. It
+// reports whether the key was used.
+func (t *TextInput) HandleKey(key string, runes []rune) bool {
+	switch key {
+	case "left", "ctrl+b":
+		if t.cursor > 0 {
+			t.cursor--
+		}
+	case "right", "ctrl+f":
+		if t.cursor < len(t.value) {
+			t.cursor++
+		}
+	case "home", "ctrl+a":
+		t.cursor = 0
+	case "end", "ctrl+e":
+		t.cursor = len(t.value)
+	case "backspace", "ctrl+h":
+		if t.cursor > 0 {
+			t.value = append(t.value[:t.cursor-1], t.value[t.cursor:]...)
+			t.cursor--
+		}
+	case "delete", "ctrl+d":
+		if t.cursor < len(t.value) {
+			t.value = append(t.value[:t.cursor], t.value[t.cursor+1:]...)
+		}
+	case "ctrl+u":
+		t.value = t.value[t.cursor:]
+		t.cursor = 0
+	case "ctrl+k":
+		t.value = t.value[:t.cursor]
+	case "ctrl+w":
+		start := t.cursor
+		for start > 0 && t.value[start-1] == ' ' {
+			start--
+		}
+		for start > 0 && t.value[start-1] != ' ' {
+			start--
+		}
+		t.value = append(t.value[:start], t.value[t.cursor:]...)
+		t.cursor = start
+	default:
+		if len(runes) == 0 {
+			return false
+		}
+		inserted := make([]rune, 0, len(t.value)+len(runes))
+		inserted = append(inserted, t.value[:t.cursor]...)
+		inserted = append(inserted, runes...)
+		t.value = append(inserted, t.value[t.cursor:]...)
+		t.cursor += len(runes)
+	}
+	return true
+}
+
+// Render renders the prompt and the text with a cursor, scrolled so the
+// cursor stays within width.
+func (t *TextInput) Render(width int) string {
+	prompt := t.PromptStyle.Render(t.Prompt)
+	room := width - lipgloss.Width(prompt) - 1
+	if room < 1 {
+		room = 1
+	}
+
+	// Keep the cursor in view by dropping text from the left
+	start := 0
+	for start < t.cursor && lipgloss.Width(string(t.value[start:t.cursor])) >= room {
+		start++
+	}
+
+	before := string(t.value[start:t.cursor])
+	under, after := " ", ""
+	if t.cursor < len(t.value) {
+		under = string(t.value[t.cursor])
+		after = string(t.value[t.cursor+1:])
+	}
+	return prompt + t.TextStyle.Render(before) + t.CursorStyle.Render(under) + t.TextStyle.Render(after)
+}
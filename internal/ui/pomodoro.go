@@ -0,0 +1,74 @@
+package ui
+
+import (
+	"time"
+
+	"github.com/BioWare/lazyobsidian/internal/logging"
+	"github.com/BioWare/lazyobsidian/pkg/types"
+)
+
+// startPomodoro starts a work session for what is selected: the task
+// selected in Today's Focus, which gets a 🍅 when the session completes,
+// or the note selected in the Books or Goals view.
+func (a *App) startPomodoro() {
+	a.pomodoroTask, a.pomodoroNote = nil, ""
+	var context string
+	switch a.currentView {
+	case ViewDashboard:
+		if task, ok := a.selectedFocusTask(); ok && a.focusedModule == ModuleTodayFocus {
+			a.pomodoroTask, a.pomodoroNote = task, a.todayNotePath
+			context = task.Text
+		}
+	case ViewBooks:
+		if book := a.booksPanel().SelectedBook(); book != nil {
+			context = a.parser.NoteLink(book.Path)
+		}
+	case ViewGoals:
+		if goal := a.goalsPanel().SelectedGoal(); goal != nil {
+			context = a.parser.NoteLink(goal.Path)
+		}
+	}
+	a.pomodoroTimer.Start(context)
+}
+
+// logPomodoros writes the sessions the timer finished to the vault and the
+// cache.
+func (a *App) logPomodoros() {
+	for {
+		select {
+		case session := <-a.pomodoroDone:
+			if err := a.cache.SavePomodoroSession(&session); err != nil {
+				logging.Error("Failed to cache pomodoro session: %v", err)
+			}
+			if session.Type == types.PomodoroTypeWork {
+				if err := a.cache.IncrementDailyPomodoros(session.StartedAt); err != nil {
+					logging.Error("Failed to count pomodoro session: %v", err)
+				}
+				if a.pomodoroTask != nil {
+					if err := a.writer.AddPomodoro(a.pomodoroNote, a.pomodoroTask); err != nil {
+						logging.Error("Failed to mark task with a pomodoro: %v", err)
+						a.statusMsg = err.Error()
+					}
+					if a.pomodoroNote == a.todayNotePath {
+						a.reloadToday()
+					}
+				}
+			}
+			path, err := a.writer.LogPomodoro(session)
+			if err != nil {
+				logging.Error("Failed to log pomodoro session: %v", err)
+				a.statusMsg = err.Error()
+				continue
+			}
+			if path != "" {
+				logging.Info("Pomodoro session logged in %s", path)
+			}
+			if path != "" && path == a.parser.DailyNotePath(time.Now()) {
+				a.todayNotePath = path
+				a.reloadToday()
+			}
+		default:
+			return
+		}
+	}
+}
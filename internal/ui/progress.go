@@ -0,0 +1,213 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/BioWare/lazyobsidian/internal/logging"
+	"github.com/BioWare/lazyobsidian/internal/ui/views"
+	"github.com/BioWare/lazyobsidian/internal/vault"
+	"github.com/BioWare/lazyobsidian/pkg/types"
+)
+
+// bookStatusCycle is the order "s" steps a book through.
+var bookStatusCycle = []types.BookStatus{
+	types.BookToRead,
+	types.BookReading,
+	types.BookPaused,
+	types.BookFinished,
+	types.BookAbandoned,
+}
+
+// booksPanel returns the books view, which keeps its selection between
+// renders.
+func (a *App) booksPanel() *views.BooksView {
+	if a.booksView == nil {
+		a.booksView = views.NewBooksView(0, 0)
+		a.booksView.SetBooks(a.books)
+	}
+	return a.booksView
+}
+
+// goalsPanel returns the goals view, which keeps its selection between
+// renders.
+func (a *App) goalsPanel() *views.GoalsView {
+	if a.goalsView == nil {
+		a.goalsView = views.NewGoalsView(0, 0)
+		a.goalsView.SetGoals(a.goals)
+	}
+	return a.goalsView
+}
+
+// handleBooksKeys handles keyboard input for the books view.
+func (a *App) handleBooksKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	view := a.booksPanel()
+	book := view.SelectedBook()
+
+	switch msg.String() {
+	case "j", "down":
+		view.SelectNext()
+	case "k", "up":
+		view.SelectPrev()
+	case "enter", "l", "right":
+		view.ToggleExpand()
+	case "c":
+		view.ToggleCompleted()
+
+	case "]", "[", "}", "{":
+		if book != nil {
+			step := map[string]int{"]": 1, "[": -1, "}": 10, "{": -10}[msg.String()]
+			a.editBook(book, func() error {
+				return a.writer.SetBookPage(book, book.CurrentPage+step)
+			})
+		}
+	case "#":
+		if book != nil {
+			a.prompt("Current page: ", strconv.Itoa(book.CurrentPage), func(value string) error {
+				page, err := strconv.Atoi(strings.TrimSpace(value))
+				if err != nil {
+					return fmt.Errorf("not a page number: %q", value)
+				}
+				return a.editBookErr(book, func() error {
+					return a.writer.SetBookPage(book, page)
+				})
+			})
+		}
+	case "s":
+		if book != nil {
+			next := bookStatusCycle[0]
+			for i, s := range bookStatusCycle {
+				if s == book.Status {
+					next = bookStatusCycle[(i+1)%len(bookStatusCycle)]
+				}
+			}
+			a.editBook(book, func() error {
+				return a.writer.SetBookStatus(book, next)
+			})
+		}
+	}
+	return a, nil
+}
+
+// handleGoalsKeys handles keyboard input for the goals view.
+func (a *App) handleGoalsKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	view := a.goalsPanel()
+	goal := view.SelectedGoal()
+
+	switch msg.String() {
+	case "j", "down":
+		view.SelectNext()
+	case "k", "up":
+		view.SelectPrev()
+	case "enter", "l", "right":
+		view.ToggleExpand()
+	case "d":
+		view.ToggleDetails()
+
+	case "]", "[":
+		if goal != nil {
+			step := 0.1
+			if msg.String() == "[" {
+				step = -0.1
+			}
+			a.editGoal(func() error {
+				return a.writer.SetGoalProgress(goal, goal.Progress+step)
+			})
+		}
+	case "#":
+		if goal != nil {
+			percent := strconv.Itoa(int(goal.Progress*100 + 0.5))
+			a.prompt("Progress (%): ", percent, func(value string) error {
+				n, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(value), "%"), 64)
+				if err != nil {
+					return fmt.Errorf("not a percentage: %q", value)
+				}
+				return a.editGoalErr(func() error {
+					return a.writer.SetGoalProgress(goal, n/100)
+				})
+			})
+		}
+	}
+	return a, nil
+}
+
+// editBook changes a book's note and shows any error in the footer.
+func (a *App) editBook(book *types.Book, edit func() error) {
+	if err := a.editBookErr(book, edit); err != nil {
+		logging.Error("Book edit failed: %v", err)
+		a.statusMsg = err.Error()
+	}
+}
+
+// editBookErr changes a book's note, then reloads the book.
+func (a *App) editBookErr(book *types.Book, edit func() error) error {
+	path := book.Path
+	err := edit()
+	a.reloadBook(path)
+	return err
+}
+
+// editGoal changes a goal note and shows any error in the footer.
+func (a *App) editGoal(edit func() error) {
+	if err := a.editGoalErr(edit); err != nil {
+		logging.Error("Goal edit failed: %v", err)
+		a.statusMsg = err.Error()
+	}
+}
+
+// editGoalErr changes a goal note, then reloads the goal tree.
+func (a *App) editGoalErr(edit func() error) error {
+	err := edit()
+	a.loadGoals()
+	return err
+}
+
+// reloadBook re-parses a book note and updates it in the library.
+func (a *App) reloadBook(path string) {
+	file, err := a.parser.ParseFile(path)
+	if err != nil && !vault.IsFrontmatterError(err) {
+		logging.Error("Failed to reload book %s: %v", path, err)
+		return
+	}
+	if err := a.indexer.Save(file); err != nil {
+		logging.Error("Failed to cache %s: %v", file.Path, err)
+	}
+	a.setBook(file)
+}
+
+// setBook puts the book a note describes in the library, replacing the
+// previous version of it.
+func (a *App) setBook(file *types.File) {
+	book := vault.BookFromFile(file)
+	replaced := false
+	for i := range a.books {
+		if a.books[i].Path == book.Path {
+			a.books[i] = book
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		a.books = append(a.books, book)
+	}
+
+	a.currentBook = nil
+	for i := range a.books {
+		if a.books[i].Status == types.BookReading {
+			a.currentBook = &a.books[i]
+			break
+		}
+	}
+	if a.booksView != nil {
+		// Keep the selected book selected as it changes group
+		var selected string
+		if b := a.booksView.SelectedBook(); b != nil {
+			selected = b.Path
+		}
+		a.booksView.SetBooks(a.books)
+		a.booksView.SelectBook(selected)
+	}
+}
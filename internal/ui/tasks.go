@@ -0,0 +1,255 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/BioWare/lazyobsidian/internal/journal"
+	"github.com/BioWare/lazyobsidian/internal/logging"
+	"github.com/BioWare/lazyobsidian/internal/ui/components"
+	"github.com/BioWare/lazyobsidian/internal/vault"
+	"github.com/BioWare/lazyobsidian/pkg/types"
+)
+
+// focusTasks returns the tasks of Today's Focus in display order:
+// each task followed by its subtasks.
+func (a *App) focusTasks() []types.Task {
+	return vault.FlattenTasks(a.todayTasks)
+}
+
+// selectedFocusTask returns a copy of the selected task, if any.
+func (a *App) selectedFocusTask() (*types.Task, bool) {
+	tasks := a.focusTasks()
+	if a.selectedTask < 0 || a.selectedTask >= len(tasks) {
+		return nil, false
+	}
+	task := tasks[a.selectedTask]
+	return &task, true
+}
+
+// handleTaskEditKeys handles the task editing keys of Today's Focus. It
+// reports whether the key was one of them.
+func (a *App) handleTaskEditKeys(key string) bool {
+	task, ok := a.selectedFocusTask()
+
+	switch key {
+	case "a":
+		a.prompt("New task: ", "", func(text string) error {
+			path, err := a.ensureTodayNote()
+			if err != nil {
+				return err
+			}
+			return a.writer.AddTask(path, a.config.Daily.FocusSection, text)
+		})
+	case "A":
+		if ok {
+			a.promptTaskEdit("New subtask: ", "", func(path, text string) error {
+				return a.writer.AddSubtask(path, task, text)
+			})
+		}
+	case "e":
+		if ok {
+			a.promptTaskEdit("Edit task: ", task.Text, func(path, text string) error {
+				return a.writer.EditTaskText(path, task, text)
+			})
+		}
+	case "D":
+		if ok {
+			a.editTask(task, a.writer.DeleteTask)
+		}
+	case ">":
+		if ok {
+			a.editTask(task, a.writer.IndentTask)
+		}
+	case "<":
+		if ok {
+			a.editTask(task, a.writer.OutdentTask)
+		}
+	case "K":
+		if ok {
+			a.editTask(task, a.writer.MoveTaskUp)
+		}
+	case "J":
+		if ok {
+			a.editTask(task, a.writer.MoveTaskDown)
+		}
+	case "m":
+		if ok {
+			a.promptTaskEdit("Move to note: ", "", func(path, name string) error {
+				dest, err := a.resolveNote(name)
+				if err != nil {
+					return err
+				}
+				return a.writer.MoveTaskToNote(path, task, dest, "")
+			})
+		}
+	case "W":
+		if ok {
+			a.editTask(task, func(path string, task *types.Task) error {
+				note, err := a.writer.CreatePeriodicNote(types.GoalPeriodWeek, time.Now().AddDate(0, 0, 7))
+				if err != nil {
+					return err
+				}
+				return a.writer.MoveTaskToNote(path, task, note.Path, a.config.Periodic.Weekly.TaskSection)
+			})
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// editTask applies a writer operation to a task of today's note, then
+// reloads the note and keeps the task selected if it is still there.
+func (a *App) editTask(task *types.Task, op func(path string, task *types.Task) error) {
+	if a.todayNotePath == "" {
+		return
+	}
+	a.applyTaskEdit(vault.TaskKey(task), func() error {
+		return op(a.todayNotePath, task)
+	})
+}
+
+// promptTaskEdit asks for a value, then applies an edit of today's note
+// with it. Like editTask, it does nothing without today's note.
+func (a *App) promptTaskEdit(label, value string, edit func(path, value string) error) {
+	if a.todayNotePath == "" {
+		return
+	}
+	a.prompt(label, value, func(value string) error {
+		return edit(a.todayNotePath, value)
+	})
+}
+
+// applyTaskEdit runs an edit of today's note and reloads it. Errors are
+// shown in the footer rather than replacing the view.
+func (a *App) applyTaskEdit(selectKey string, edit func() error) {
+	if err := edit(); err != nil {
+		logging.Error("Task edit failed: %v", err)
+		a.statusMsg = err.Error()
+	} else {
+		a.statusMsg = ""
+	}
+	a.reloadToday()
+
+	if selectKey != "" {
+		for i, t := range a.focusTasks() {
+			if vault.TaskKey(&t) == selectKey {
+				a.selectedTask = i
+				return
+			}
+		}
+	}
+	if n := len(a.focusTasks()); a.selectedTask >= n {
+		a.selectedTask = max(n-1, 0)
+	}
+}
+
+// undo reverts the last vault edit, or applies again the last one undone
+// when redo is set, and reports it in the footer.
+func (a *App) undo(redo bool) {
+	apply, verb, none := a.writer.Undo, "Undid", "Nothing to undo"
+	if redo {
+		apply, verb, none = a.writer.Redo, "Redid", "Nothing to redo"
+	}
+
+	var selectKey string
+	if task, ok := a.selectedFocusTask(); ok {
+		selectKey = vault.TaskKey(task)
+	}
+	var entries []journal.Entry
+	a.applyTaskEdit(selectKey, func() error {
+		var err error
+		entries, err = apply(1)
+		return err
+	})
+	switch {
+	case a.statusMsg != "":
+	case len(entries) == 0:
+		a.statusMsg = none
+	default:
+		a.statusMsg = verb + ": " + entries[0].Op
+	}
+
+	// Books and goals may have changed too
+	for _, e := range entries {
+		for _, c := range e.Changes {
+			path := filepath.Join(a.config.Vault.Path, filepath.FromSlash(c.Path))
+			for _, b := range a.books {
+				if b.Path == path {
+					a.reloadBook(path)
+					break
+				}
+			}
+		}
+	}
+	if len(entries) > 0 {
+		a.loadGoals()
+	}
+}
+
+// reloadToday re-parses today's daily note.
+func (a *App) reloadToday() {
+	if a.todayNotePath == "" {
+		return
+	}
+	file, err := a.parser.ParseFile(a.todayNotePath)
+	if err != nil && !vault.IsFrontmatterError(err) {
+		logging.Error("Failed to reload daily note: %v", err)
+		return
+	}
+	a.todayTasks = file.Tasks
+	a.todaySchedule = file.Schedule
+}
+
+// ensureTodayNote returns the path of today's daily note, creating it if
+// needed.
+func (a *App) ensureTodayNote() (string, error) {
+	if a.todayNotePath != "" {
+		return a.todayNotePath, nil
+	}
+	note, err := a.writer.CreateDailyNote(a.parser.DailyNotePath(time.Now()))
+	if err != nil {
+		return "", err
+	}
+	a.todayNotePath = note.Path
+	return note.Path, nil
+}
+
+// resolveNote finds a note by name or path the way a wikilink would.
+func (a *App) resolveNote(name string) (string, error) {
+	path, ok := a.parser.ResolveNote(a.todayNotePath, name)
+	if !ok {
+		return "", fmt.Errorf("no single note matches %q", name)
+	}
+	return path, nil
+}
+
+// prompt opens the footer text input. submit runs on Enter, as an edit of
+// today's note.
+func (a *App) prompt(label, value string, submit func(value string) error) {
+	a.input = components.NewTextInput(label, value)
+	a.inputSubmit = submit
+}
+
+// handleInputKeys handles keys while the text input is open.
+func (a *App) handleInputKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		a.input, a.inputSubmit = nil, nil
+	case "enter":
+		value, submit := a.input.Value(), a.inputSubmit
+		a.input, a.inputSubmit = nil, nil
+		var selectKey string
+		if task, ok := a.selectedFocusTask(); ok {
+			selectKey = vault.TaskKey(task)
+		}
+		a.applyTaskEdit(selectKey, func() error { return submit(value) })
+	default:
+		a.input.HandleKey(msg.String(), msg.Runes)
+	}
+	return a, nil
+}
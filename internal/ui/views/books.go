@@ -3,11 +3,13 @@ package views
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
 
+	"github.com/BioWare/lazyobsidian/internal/books"
 	"github.com/BioWare/lazyobsidian/internal/i18n"
 	"github.com/BioWare/lazyobsidian/internal/ui/icons"
 	"github.com/BioWare/lazyobsidian/internal/ui/layout"
@@ -23,9 +25,10 @@ const (
 	BookViewChapters
 )
 
-// BookNode represents a node in the book tree (book or chapter).
+// BookNode represents a node in the book tree (status group, book or chapter).
 type BookNode struct {
 	Type     BookNodeType
+	Status   types.BookStatus // for status groups
 	Book     *types.Book
 	Chapter  *types.BookChapter
 	Level    int
@@ -40,6 +43,7 @@ type BookNodeType int
 const (
 	NodeTypeBook BookNodeType = iota
 	NodeTypeChapter
+	NodeTypeBookStatus
 )
 
 // BooksView represents the books list view.
@@ -88,7 +92,7 @@ func (b *BooksView) SetFocused(focused bool) {
 	b.Focused = focused
 }
 
-// ToggleCompleted toggles showing completed books.
+// ToggleCompleted expands or collapses the finished and abandoned groups.
 func (b *BooksView) ToggleCompleted() {
 	b.ShowCompleted = !b.ShowCompleted
 	b.buildTree()
@@ -124,20 +128,23 @@ func (b *BooksView) ToggleExpand() {
 
 // ExpandAll expands all nodes.
 func (b *BooksView) ExpandAll() {
-	for _, node := range b.nodes {
-		node.Expanded = true
-	}
+	b.setExpandAll(b.nodes, true)
 	b.flattenVisible()
 }
 
 // CollapseAll collapses all nodes.
 func (b *BooksView) CollapseAll() {
-	for _, node := range b.nodes {
-		node.Expanded = false
-	}
+	b.setExpandAll(b.nodes, false)
 	b.flattenVisible()
 }
 
+func (b *BooksView) setExpandAll(nodes []*BookNode, expanded bool) {
+	for _, node := range nodes {
+		node.Expanded = expanded
+		b.setExpandAll(node.Children, expanded)
+	}
+}
+
 // SelectedBook returns the currently selected book.
 func (b *BooksView) SelectedBook() *types.Book {
 	if b.SelectedIndex >= 0 && b.SelectedIndex < len(b.flatNodes) {
@@ -149,6 +156,17 @@ func (b *BooksView) SelectedBook() *types.Book {
 	return nil
 }
 
+// SelectBook selects the book with a note path, if it is visible.
+func (b *BooksView) SelectBook(path string) {
+	for i, node := range b.flatNodes {
+		if node.Type == NodeTypeBook && node.Book.Path == path {
+			b.SelectedIndex = i
+			b.ensureVisible()
+			return
+		}
+	}
+}
+
 // SelectedNode returns the currently selected node.
 func (b *BooksView) SelectedNode() *BookNode {
 	if b.SelectedIndex >= 0 && b.SelectedIndex < len(b.flatNodes) {
@@ -160,47 +178,46 @@ func (b *BooksView) SelectedNode() *BookNode {
 func (b *BooksView) buildTree() {
 	b.nodes = make([]*BookNode, 0)
 
-	for i := range b.Books {
-		book := &b.Books[i]
-
-		// Filter completed books
-		progress := float64(book.CurrentPage) / float64(book.TotalPages)
-		if progress >= 1.0 && !b.ShowCompleted {
-			continue
-		}
-
-		bookNode := &BookNode{
-			Type:     NodeTypeBook,
-			Book:     book,
+	// One group per reading status; finished and abandoned books stay
+	// folded unless completed books are shown
+	for _, group := range books.GroupByStatus(b.Books) {
+		done := group.Status == types.BookFinished || group.Status == types.BookAbandoned
+		groupNode := &BookNode{
+			Type:     NodeTypeBookStatus,
+			Status:   group.Status,
 			Level:    0,
-			Expanded: false,
+			Expanded: !done || b.ShowCompleted,
 		}
 
-		for j := range book.Chapters {
-			chapter := &book.Chapters[j]
-			chapterNode := &BookNode{
-				Type:    NodeTypeChapter,
-				Book:    book,
-				Chapter: chapter,
-				Level:   1,
+		for _, book := range group.Books {
+			bookNode := &BookNode{
+				Type:     NodeTypeBook,
+				Book:     book,
+				Level:    1,
+				Expanded: false,
 			}
-			bookNode.Children = append(bookNode.Children, chapterNode)
+
+			for j := range book.Chapters {
+				chapter := &book.Chapters[j]
+				chapterNode := &BookNode{
+					Type:    NodeTypeChapter,
+					Book:    book,
+					Chapter: chapter,
+					Level:   2,
+				}
+				bookNode.Children = append(bookNode.Children, chapterNode)
+			}
+
+			groupNode.Children = append(groupNode.Children, bookNode)
 		}
 
-		b.nodes = append(b.nodes, bookNode)
+		b.nodes = append(b.nodes, groupNode)
 	}
 }
 
 func (b *BooksView) flattenVisible() {
 	b.flatNodes = make([]*BookNode, 0)
-	for _, node := range b.nodes {
-		b.flatNodes = append(b.flatNodes, node)
-		if node.Expanded {
-			for _, child := range node.Children {
-				b.flatNodes = append(b.flatNodes, child)
-			}
-		}
-	}
+	b.flattenNodes(b.nodes)
 
 	for i, node := range b.flatNodes {
 		node.Index = i
@@ -214,6 +231,15 @@ func (b *BooksView) flattenVisible() {
 	}
 }
 
+func (b *BooksView) flattenNodes(nodes []*BookNode) {
+	for _, node := range nodes {
+		b.flatNodes = append(b.flatNodes, node)
+		if node.Expanded {
+			b.flattenNodes(node.Children)
+		}
+	}
+}
+
 func (b *BooksView) ensureVisible() {
 	visibleLines := b.Height - 4
 	if visibleLines < 1 {
@@ -322,14 +348,12 @@ func (b *BooksView) renderNodeLine(node *BookNode, width int, selected bool, th
 	prefix.WriteString(indent)
 
 	// Expand/collapse indicator
-	if node.Type == NodeTypeBook && len(node.Children) > 0 {
+	if len(node.Children) > 0 {
 		if node.Expanded {
 			prefix.WriteString(icons.Get("expanded") + " ")
 		} else {
 			prefix.WriteString(icons.Get("collapsed") + " ")
 		}
-	} else if node.Type == NodeTypeBook {
-		prefix.WriteString("  ")
 	} else {
 		prefix.WriteString("  ")
 	}
@@ -339,8 +363,16 @@ func (b *BooksView) renderNodeLine(node *BookNode, width int, selected bool, th
 	var progress float64
 
 	switch node.Type {
+	case NodeTypeBookStatus:
+		icon = icons.Get("folder")
+		title = bookStatusLabel(node.Status)
+		statusStr = fmt.Sprintf(" %d", len(node.Children))
+		if node.Status == types.BookFinished {
+			progress = 1.0
+		}
+
 	case NodeTypeBook:
-		progress = float64(node.Book.CurrentPage) / float64(node.Book.TotalPages)
+		progress = books.CalculateProgress(node.Book)
 		if progress >= 1.0 {
 			icon = icons.Get("check")
 		} else if progress > 0 {
@@ -349,11 +381,18 @@ func (b *BooksView) renderNodeLine(node *BookNode, width int, selected bool, th
 			icon = icons.Get("book")
 		}
 		title = node.Book.Title
-		// Use i18n format for pages
-		statusStr = " " + i18n.Format(t.Books.Pages, map[string]interface{}{
-			"current": node.Book.CurrentPage,
-			"total":   node.Book.TotalPages,
-		})
+		if node.Book.TotalPages > 0 {
+			// Use i18n format for pages
+			statusStr = " " + i18n.Format(t.Books.Pages, map[string]interface{}{
+				"current": node.Book.CurrentPage,
+				"total":   node.Book.TotalPages,
+			})
+		} else if len(node.Book.Chapters) > 0 {
+			statusStr = fmt.Sprintf(" %d%%", int(progress*100))
+		}
+		if node.Book.Rating > 0 {
+			statusStr += " " + formatRating(node.Book.Rating)
+		}
 
 	case NodeTypeChapter:
 		if node.Chapter.Status == "done" || node.Chapter.Status == "completed" {
@@ -429,6 +468,15 @@ func (b *BooksView) renderBookDetails(width int) []string {
 		return lines
 	}
 
+	// A status group shows its size
+	if node.Type == NodeTypeBookStatus {
+		lines = append(lines, lipgloss.NewStyle().
+			Foreground(th.Color("text_secondary")).
+			Bold(true).
+			Render(fmt.Sprintf("%s: %d", bookStatusLabel(node.Status), len(node.Children))))
+		return lines
+	}
+
 	// Get the book
 	var book *types.Book
 	if node.Book != nil {
@@ -454,15 +502,18 @@ func (b *BooksView) renderBookDetails(width int) []string {
 	}
 	lines = append(lines, "")
 
-	// Reading Progress
-	progress := float64(book.CurrentPage) / float64(book.TotalPages)
-	var statusLabel string
-	if progress >= 1.0 {
-		statusLabel = t.Books.Completed
-	} else {
-		statusLabel = t.Books.Reading
+	// Reading status, dates and rating
+	progress := books.CalculateProgress(book)
+	lines = append(lines, labelStyle.Render(bookStatusLabel(book.Status)))
+	if book.StartedAt != nil {
+		lines = append(lines, labelStyle.Render("Started: ")+valueStyle.Render(book.StartedAt.Format("2006-01-02")))
+	}
+	if book.FinishedAt != nil {
+		lines = append(lines, labelStyle.Render("Finished: ")+valueStyle.Render(book.FinishedAt.Format("2006-01-02")))
+	}
+	if book.Rating > 0 {
+		lines = append(lines, labelStyle.Render("Rating: ")+valueStyle.Render(formatRating(book.Rating)))
 	}
-	lines = append(lines, labelStyle.Render(statusLabel))
 	lines = append(lines, "")
 
 	// Progress bar
@@ -476,11 +527,13 @@ func (b *BooksView) renderBookDetails(width int) []string {
 		"total":   book.TotalPages,
 	})
 	pagesRemaining := book.TotalPages - book.CurrentPage
-	lines = append(lines, labelStyle.Render("Pages: ")+valueStyle.Render(pagesLabel))
-	if pagesRemaining > 0 {
-		lines = append(lines, mutedStyle.Render(fmt.Sprintf("  %d pages remaining", pagesRemaining)))
+	if book.TotalPages > 0 {
+		lines = append(lines, labelStyle.Render("Pages: ")+valueStyle.Render(pagesLabel))
+		if pagesRemaining > 0 {
+			lines = append(lines, mutedStyle.Render(fmt.Sprintf("  %d pages remaining", pagesRemaining)))
+		}
+		lines = append(lines, "")
 	}
-	lines = append(lines, "")
 
 	// Chapters
 	chaptersLabel := i18n.Format(t.Books.Chapters, map[string]interface{}{
@@ -528,6 +581,28 @@ func (b *BooksView) renderBookDetails(width int) []string {
 	return lines
 }
 
+// bookStatusLabel returns the display name of a reading status.
+func bookStatusLabel(status types.BookStatus) string {
+	t := i18n.T()
+	switch status {
+	case types.BookReading:
+		return t.Books.Reading
+	case types.BookPaused:
+		return t.Books.Paused
+	case types.BookFinished:
+		return t.Books.Completed
+	case types.BookAbandoned:
+		return t.Books.Abandoned
+	default:
+		return t.Books.ToRead
+	}
+}
+
+// formatRating formats a rating out of five, e.g. "★4" or "★4.5".
+func formatRating(rating float64) string {
+	return "★" + strconv.FormatFloat(rating, 'f', -1, 64)
+}
+
 func (b *BooksView) renderProgressBar(progress float64, width int, th *theme.Theme) string {
 	if width < 10 {
 		width = 10
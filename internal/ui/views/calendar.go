@@ -8,6 +8,7 @@ import (
 
 	"github.com/charmbracelet/lipgloss"
 
+	"github.com/BioWare/lazyobsidian/internal/schedule"
 	"github.com/BioWare/lazyobsidian/internal/ui/icons"
 	"github.com/BioWare/lazyobsidian/internal/ui/layout"
 	"github.com/BioWare/lazyobsidian/internal/ui/theme"
@@ -42,6 +43,15 @@ type CalendarEvent struct {
 	Done  bool
 }
 
+// CalendarPeriod is a periodic note covering the selected day, such as
+// its week's or month's plan.
+type CalendarPeriod struct {
+	Period types.GoalPeriod
+	Label  string
+	Exists bool
+	Tasks  []types.Task
+}
+
 // Calendar represents the calendar view.
 type Calendar struct {
 	Width  int
@@ -54,7 +64,9 @@ type Calendar struct {
 
 	// Data
 	Events       []CalendarEvent
-	ActivityData map[string]int // Date string -> activity count (pomodoros)
+	ActivityData map[string]int        // Date string -> activity count (pomodoros)
+	Schedule     []types.ScheduleBlock // Time blocks for the selected day
+	Periods      []CalendarPeriod      // Periodic notes covering the selected day
 
 	// Settings
 	FirstDayOfWeek int // 0 = Sunday, 1 = Monday
@@ -90,6 +102,17 @@ func (c *Calendar) SetEvents(events []CalendarEvent) {
 	c.Events = events
 }
 
+// SetSchedule sets the time blocks shown in the day view timeline.
+func (c *Calendar) SetSchedule(blocks []types.ScheduleBlock) {
+	c.Schedule = append([]types.ScheduleBlock(nil), blocks...)
+	schedule.Sort(c.Schedule)
+}
+
+// SetPeriods sets the periodic notes covering the selected day.
+func (c *Calendar) SetPeriods(periods []CalendarPeriod) {
+	c.Periods = periods
+}
+
 // SetActivityData sets the activity data for the heatmap.
 func (c *Calendar) SetActivityData(data map[string]int) {
 	c.ActivityData = data
@@ -326,6 +349,12 @@ func (c *Calendar) renderDayPanel(width, height int) string {
 		}
 	}
 
+	// Plans for the week, month, quarter and year
+	if len(c.Periods) > 0 {
+		lines = append(lines, "")
+		lines = append(lines, c.renderPeriodLines(contentWidth)...)
+	}
+
 	// Activity summary
 	dateKey := c.SelectedDate.Format("2006-01-02")
 	if activity, ok := c.ActivityData[dateKey]; ok && activity > 0 {
@@ -438,9 +467,33 @@ func (c *Calendar) renderMiniMonth(month int, width, height int) string {
 	return strings.Join(lines[:height], "\n")
 }
 
-// renderDayView renders the detailed day view.
+// renderDayView renders the detailed day view: an hour-by-hour timeline
+// of the day's time blocks on the left, tasks and activity on the right.
 func (c *Calendar) renderDayView() string {
-	frame := layout.NewFrame(c.Width, c.Height)
+	if c.Width <= 0 || c.Height <= 0 {
+		return ""
+	}
+
+	timelineWidth := c.Width * 55 / 100
+	detailsWidth := c.Width - timelineWidth
+
+	timeline := c.renderTimeline(timelineWidth, c.Height)
+	details := c.renderDayDetails(detailsWidth, c.Height)
+
+	return lipgloss.JoinHorizontal(lipgloss.Top, timeline, details)
+}
+
+// Timeline defaults: the working day shown when no block falls outside it.
+const (
+	timelineStartHour = 8
+	timelineEndHour   = 18
+)
+
+// renderTimeline renders the selected day's schedule as a vertical
+// timeline. Overlapping blocks are flagged and the current time slot is
+// highlighted when the selected day is today.
+func (c *Calendar) renderTimeline(width, height int) string {
+	frame := layout.NewFrame(width, height)
 
 	dateStr := c.SelectedDate.Format("Monday, January 2, 2006")
 	frame.SetTitle(dateStr)
@@ -459,6 +512,141 @@ func (c *Calendar) renderDayView() string {
 	contentWidth := frame.ContentWidth()
 	contentHeight := frame.ContentHeight()
 
+	day := time.Date(c.SelectedDate.Year(), c.SelectedDate.Month(), c.SelectedDate.Day(), 0, 0, 0, 0, c.SelectedDate.Location())
+	isToday := c.isSameDay(day, c.Today)
+	overlapping := schedule.Overlapping(c.Schedule)
+
+	// Hour range: the working day, widened to fit every block and now
+	startHour, endHour := timelineStartHour, timelineEndHour
+	for _, b := range c.Schedule {
+		startHour = min(startHour, int(b.Start.Sub(day).Hours()))
+		endHour = max(endHour, int((b.End.Sub(day)+time.Hour-1)/time.Hour))
+	}
+	if isToday {
+		startHour = min(startHour, c.Today.Hour())
+		endHour = max(endHour, c.Today.Hour()+1)
+	}
+	startHour = max(startHour, 0)
+	endHour = min(endHour, 24)
+
+	// Footer lines: overlap warning and total scheduled time
+	var footer []string
+	mutedStyle := lipgloss.NewStyle().Foreground(theme.Current.Color("text_muted"))
+	warnStyle := lipgloss.NewStyle().Foreground(theme.Current.Color("warning"))
+	if len(c.Schedule) == 0 {
+		footer = append(footer, mutedStyle.Render(" No time blocks"))
+	} else {
+		total := fmt.Sprintf(" %s %s scheduled", icons.Get("clock"), formatDuration(schedule.TotalDuration(c.Schedule)))
+		footer = append(footer, mutedStyle.Render(total))
+	}
+	if len(overlapping) > 0 {
+		warning := fmt.Sprintf(" %s %d overlapping blocks", icons.Get("warning"), len(overlapping))
+		footer = append(footer, warnStyle.Render(warning))
+	}
+
+	// Half-hour slots when they fit, otherwise whole hours
+	rows := contentHeight - len(footer) - 1
+	slot := 30 * time.Minute
+	if (endHour-startHour)*2 > rows {
+		slot = time.Hour
+	}
+
+	// Scroll so the current hour (or the first block) stays visible
+	if slots := int(time.Duration(endHour-startHour) * time.Hour / slot); slots > rows && rows > 0 {
+		focusHour := startHour
+		if isToday {
+			focusHour = c.Today.Hour()
+		} else if len(c.Schedule) > 0 {
+			focusHour = max(int(c.Schedule[0].Start.Sub(day).Hours()), 0)
+		}
+		visibleHours := rows * int(slot/time.Minute) / 60
+		startHour = max(startHour, min(focusHour, endHour-visibleHours))
+	}
+
+	labelStyle := mutedStyle
+	nowStyle := lipgloss.NewStyle().Foreground(theme.Current.Color("primary")).Bold(true)
+	blockStyle := lipgloss.NewStyle().Foreground(theme.Current.Color("accent"))
+	titleStyle := theme.S.TextPrimary
+
+	var lines []string
+	slotStart := day.Add(time.Duration(startHour) * time.Hour)
+	dayEnd := day.Add(time.Duration(endHour) * time.Hour)
+	for ; slotStart.Before(dayEnd) && len(lines) < rows; slotStart = slotStart.Add(slot) {
+		slotEnd := slotStart.Add(slot)
+		isNow := isToday && !c.Today.Before(slotStart) && c.Today.Before(slotEnd)
+
+		// Blocks covering this slot, and those starting in it
+		covered := false
+		var starting []string
+		for i, b := range c.Schedule {
+			if b.Start.Before(slotEnd) && (b.End.After(slotStart) || !b.Start.Before(slotStart)) {
+				covered = true
+			}
+			if b.Start.Before(slotStart) || !b.Start.Before(slotEnd) {
+				continue
+			}
+
+			text := b.Start.Format("15:04")
+			if b.End.After(b.Start) {
+				text += "-" + b.End.Format("15:04")
+			}
+			text += " " + b.Title
+			if b.Context != "" {
+				text += " · " + b.Context
+			}
+			if overlapping[i] {
+				starting = append(starting, warnStyle.Render(icons.Get("warning")+" "+text))
+			} else {
+				starting = append(starting, titleStyle.Render(text))
+			}
+		}
+
+		label := labelStyle.Render(" " + slotStart.Format("15:04") + " ")
+		if isNow {
+			label = nowStyle.Render("▶" + slotStart.Format("15:04") + " ")
+		}
+
+		gutter := mutedStyle.Render("│")
+		if covered {
+			gutter = blockStyle.Render("┃")
+		}
+
+		line := label + gutter
+		if len(starting) > 0 {
+			line += " " + strings.Join(starting, ", ")
+		} else if isNow {
+			line += nowStyle.Render(" ← " + c.Today.Format("15:04"))
+		}
+		lines = append(lines, layout.FitToWidth(line, contentWidth))
+	}
+
+	lines = append(lines, "")
+	lines = append(lines, footer...)
+
+	frame.SetContentLines(lines)
+	return frame.Render()
+}
+
+// renderDayDetails renders the selected day's tasks, goals, journal and
+// activity by layer.
+func (c *Calendar) renderDayDetails(width, height int) string {
+	frame := layout.NewFrame(width, height)
+
+	frame.SetTitle(c.SelectedDate.Format("Mon, Jan 2"))
+	frame.SetBorder(layout.BorderRounded)
+
+	if theme.Current != nil {
+		frame.SetColors(
+			theme.Current.Color("border_default"),
+			theme.Current.Color("border_active"),
+			theme.Current.Color("text_primary"),
+			theme.Current.Color("bg_primary"),
+		)
+	}
+
+	contentWidth := frame.ContentWidth()
+	contentHeight := frame.ContentHeight()
+
 	var lines []string
 
 	// Layer sections
@@ -487,7 +675,17 @@ func (c *Calendar) renderDayView() string {
 
 		// Section content
 		events := c.filterEventsByType(l.name)
-		if len(events) == 0 {
+		var periodLines []string
+		if l.layer == LayerGoals {
+			periodLines = c.renderPeriodLines(contentWidth - 2)
+		}
+		for _, line := range periodLines {
+			if len(lines) >= contentHeight-2 {
+				break
+			}
+			lines = append(lines, "  "+line)
+		}
+		if len(events) == 0 && len(periodLines) == 0 {
 			emptyStyle := lipgloss.NewStyle().Foreground(theme.Current.Color("text_muted"))
 			lines = append(lines, layout.FitToWidth(emptyStyle.Render("   No items"), contentWidth))
 		} else {
@@ -507,6 +705,41 @@ func (c *Calendar) renderDayView() string {
 	return frame.Render()
 }
 
+// renderPeriodLines renders the periodic notes covering the selected
+// day with their progress and open tasks.
+func (c *Calendar) renderPeriodLines(width int) []string {
+	mutedStyle := lipgloss.NewStyle().Foreground(theme.Current.Color("text_muted"))
+
+	var lines []string
+	for _, p := range c.Periods {
+		if !p.Exists {
+			line := fmt.Sprintf(" %s %s", icons.Get("goals"), p.Label)
+			lines = append(lines, layout.FitToWidth(mutedStyle.Render(line), width))
+			continue
+		}
+
+		done, total := countTaskProgress(p.Tasks)
+		header := fmt.Sprintf(" %s %s", icons.Get("goals"), theme.S.GoalTitle.Render(p.Label))
+		if total > 0 {
+			header += mutedStyle.Render(fmt.Sprintf("  %d/%d", done, total))
+		}
+		lines = append(lines, layout.FitToWidth(header, width))
+
+		for _, task := range p.Tasks {
+			if task.Status == "done" || task.Status == "cancelled" {
+				continue
+			}
+			title := task.Text
+			if maxLen := width - 6; lipgloss.Width(title) > maxLen {
+				title = layout.TruncateWithEllipsis(title, maxLen)
+			}
+			line := fmt.Sprintf("   %s %s", icons.Get("task_open"), theme.S.TaskOpen.Render(title))
+			lines = append(lines, layout.FitToWidth(line, width))
+		}
+	}
+	return lines
+}
+
 // filterEventsByType filters events for the selected date and type.
 func (c *Calendar) filterEventsByType(layerName string) []CalendarEvent {
 	var result []CalendarEvent
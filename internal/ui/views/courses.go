@@ -7,6 +7,7 @@ import (
 
 	"github.com/charmbracelet/lipgloss"
 
+	"github.com/BioWare/lazyobsidian/internal/courses"
 	"github.com/BioWare/lazyobsidian/internal/i18n"
 	"github.com/BioWare/lazyobsidian/internal/ui/icons"
 	"github.com/BioWare/lazyobsidian/internal/ui/layout"
@@ -168,7 +169,7 @@ func (c *CoursesView) buildTree() {
 		course := &c.Courses[i]
 
 		// Filter based on completion
-		progress := float64(course.Completed) / float64(course.TotalLessons)
+		progress := courses.CalculateProgress(course)
 		if progress >= 1.0 && !c.ShowCompleted {
 			continue
 		}
@@ -385,10 +386,11 @@ func (c *CoursesView) renderNodeLine(node *CourseNode, width int, selected bool,
 	case NodeTypeCourse:
 		icon = icons.Get("courses")
 		title = node.Course.Title
-		if node.Course.TotalLessons > 0 {
-			progress = float64(node.Course.Completed) / float64(node.Course.TotalLessons)
-		}
+		progress = courses.CalculateProgress(node.Course)
 		statusStr = fmt.Sprintf(" %d/%d", node.Course.Completed, node.Course.TotalLessons)
+		if remaining := courses.RemainingMinutes(node.Course); remaining > 0 {
+			statusStr += " " + formatMinutes(remaining)
+		}
 
 	case NodeTypeSection:
 		icon = icons.Get("folder")
@@ -396,15 +398,18 @@ func (c *CoursesView) renderNodeLine(node *CourseNode, width int, selected bool,
 		progress = node.Section.Progress
 		lessonCount := len(node.Section.Lessons)
 		completed := 0
-		for _, l := range node.Section.Lessons {
-			if l.Status == "done" || l.Status == "completed" {
+		for i := range node.Section.Lessons {
+			if courses.IsLessonDone(&node.Section.Lessons[i]) {
 				completed++
 			}
 		}
 		statusStr = fmt.Sprintf(" %d/%d", completed, lessonCount)
+		if remaining := courses.SectionRemainingMinutes(node.Section); remaining > 0 {
+			statusStr += " " + formatMinutes(remaining)
+		}
 
 	case NodeTypeLesson:
-		if node.Lesson.Status == "done" || node.Lesson.Status == "completed" {
+		if courses.IsLessonDone(node.Lesson) {
 			icon = icons.Get("check")
 			progress = 1.0
 		} else if node.Lesson.Status == "in_progress" {
@@ -416,7 +421,7 @@ func (c *CoursesView) renderNodeLine(node *CourseNode, width int, selected bool,
 		}
 		title = node.Lesson.Title
 		if node.Lesson.Duration > 0 {
-			statusStr = fmt.Sprintf(" %dm", node.Lesson.Duration)
+			statusStr = " " + formatMinutes(node.Lesson.Duration)
 		}
 		if node.Lesson.HasNote {
 			statusStr += " " + icons.Get("note")
@@ -515,10 +520,16 @@ func (c *CoursesView) renderCourseDetails(width int) []string {
 	lines = append(lines, "")
 
 	// Progress
-	progress := float64(course.Completed) / float64(course.TotalLessons)
+	progress := courses.CalculateProgress(course)
 	lines = append(lines, labelStyle.Render("Progress:"))
 	progressBar := c.renderProgressBar(progress, width-4, th)
 	lines = append(lines, "  "+progressBar)
+	if remaining := courses.RemainingMinutes(course); remaining > 0 {
+		remainingLabel := i18n.Format(t.Courses.Remaining, map[string]interface{}{
+			"time": formatMinutes(remaining),
+		})
+		lines = append(lines, "  "+mutedStyle.Render(remainingLabel))
+	}
 	lines = append(lines, "")
 
 	// Lessons
@@ -585,6 +596,14 @@ func (c *CoursesView) renderProgressBar(progress float64, width int, th *theme.T
 	return filled + empty + percentStr
 }
 
+// formatMinutes formats a lesson length or watch time, e.g. "1h 05m".
+func formatMinutes(minutes int) string {
+	if minutes < 60 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh %02dm", minutes/60, minutes%60)
+}
+
 // Helper function to combine two rendered frames horizontally
 func combineHorizontal(left, right string) string {
 	leftLines := strings.Split(left, "\n")
@@ -8,6 +8,8 @@ import (
 
 	"github.com/charmbracelet/lipgloss"
 
+	"github.com/BioWare/lazyobsidian/internal/books"
+	"github.com/BioWare/lazyobsidian/internal/tasks"
 	"github.com/BioWare/lazyobsidian/internal/ui/components"
 	"github.com/BioWare/lazyobsidian/internal/ui/icons"
 	"github.com/BioWare/lazyobsidian/internal/ui/layout"
@@ -155,15 +157,18 @@ func (d *Dashboard) renderTodayFocus(width, height int) string {
 		emptyMsg := emptyStyle.Render("No daily note. Press Enter to create.")
 		lines = append(lines, layout.PadCenter(emptyMsg, contentWidth))
 	} else {
-		// Render tasks
+		// Render tasks with their subtasks, scrolled to keep the
+		// selected one in view
 		maxTasks := contentHeight - 2 // Leave room for progress bar
-		for i, task := range d.Tasks {
-			if i >= maxTasks {
-				break
-			}
-
-			line := d.renderTaskLine(task, i == d.SelectedTask && d.FocusedModule == ModuleTodayFocus, contentWidth)
-			lines = append(lines, line)
+		rows := flattenTaskRows(d.Tasks, 0)
+		first := 0
+		if d.SelectedTask >= maxTasks {
+			first = d.SelectedTask - maxTasks + 1
+		}
+		for i := first; i < len(rows) && i < first+maxTasks; i++ {
+			indent := strings.Repeat("  ", rows[i].depth)
+			line := d.renderTaskLine(rows[i].task, i == d.SelectedTask && d.FocusedModule == ModuleTodayFocus, contentWidth-len(indent))
+			lines = append(lines, indent+line)
 		}
 
 		// Pad remaining lines
@@ -218,11 +223,28 @@ func (d *Dashboard) renderTaskLine(task types.Task, selected bool, width int) st
 	iconWidth := lipgloss.Width(icon) + 1 // icon + space
 	maxTextWidth := width - iconWidth - 2  // padding
 
+	// Due date and priority from Tasks plugin metadata
+	var meta string
+	if task.Priority >= types.PriorityHigh {
+		meta += " !"
+	}
+	if task.DueDate != nil {
+		meta += " " + icons.Get("calendar") + " " + task.DueDate.Format("Jan 2")
+	}
+	maxTextWidth -= lipgloss.Width(meta)
+
 	if lipgloss.Width(text) > maxTextWidth {
 		text = layout.TruncateWithEllipsis(text, maxTextWidth)
 	}
 
 	content := icon + " " + style.Render(text)
+	if meta != "" {
+		metaStyle := lipgloss.NewStyle().Foreground(theme.Current.Color("text_muted"))
+		if tasks.IsOverdue(task, time.Now()) {
+			metaStyle = lipgloss.NewStyle().Foreground(theme.Current.Color("error"))
+		}
+		content += metaStyle.Render(meta)
+	}
 
 	if selected {
 		// Highlight selected task
@@ -446,10 +468,7 @@ func (d *Dashboard) renderBook(width, height int) string {
 		emptyStyle := lipgloss.NewStyle().Foreground(theme.Current.Color("text_muted"))
 		lines = append(lines, layout.FitToWidth(emptyStyle.Render(" No book in progress"), contentWidth))
 	} else {
-		progress := float64(0)
-		if d.CurrentBook.TotalPages > 0 {
-			progress = float64(d.CurrentBook.CurrentPage) / float64(d.CurrentBook.TotalPages)
-		}
+		progress := books.CalculateProgress(d.CurrentBook)
 		progressBar := components.NewProgressBar(12, progress)
 		progressBar.ShowLabel = false
 
@@ -473,12 +492,11 @@ func (d *Dashboard) renderBook(width, height int) string {
 			lines = append(lines, layout.FitToWidth(authorStyle.Render(author), contentWidth))
 		}
 
-		// Progress
-		progressLine := fmt.Sprintf(" %s %d%% • p.%d/%d",
-			progressBar.Render(),
-			int(progress*100),
-			d.CurrentBook.CurrentPage,
-			d.CurrentBook.TotalPages)
+		// Progress, in pages when the page count is known
+		progressLine := fmt.Sprintf(" %s %d%%", progressBar.Render(), int(progress*100))
+		if d.CurrentBook.TotalPages > 0 {
+			progressLine += fmt.Sprintf(" • p.%d/%d", d.CurrentBook.CurrentPage, d.CurrentBook.TotalPages)
+		}
 		lines = append(lines, layout.FitToWidth(progressLine, contentWidth))
 	}
 
@@ -543,6 +561,24 @@ func (d *Dashboard) renderRecentNotes(width, height int) string {
 
 // Helper functions
 
+// taskRow is a task with its nesting depth, for rendering task trees as
+// a list.
+type taskRow struct {
+	task  types.Task
+	depth int
+}
+
+// flattenTaskRows lists tasks and their subtasks in order, the same order
+// as vault.FlattenTasks.
+func flattenTaskRows(tasks []types.Task, depth int) []taskRow {
+	var rows []taskRow
+	for _, t := range tasks {
+		rows = append(rows, taskRow{task: t, depth: depth})
+		rows = append(rows, flattenTaskRows(t.Subtasks, depth+1)...)
+	}
+	return rows
+}
+
 func countTaskProgress(tasks []types.Task) (completed, total int) {
 	for _, t := range tasks {
 		total++
@@ -0,0 +1,283 @@
+package vault
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BioWare/lazyobsidian/internal/journal"
+	"github.com/BioWare/lazyobsidian/internal/logging"
+	"github.com/BioWare/lazyobsidian/pkg/types"
+)
+
+// maxEditAttempts bounds how often an edit is retried while the file
+// keeps changing underneath it.
+const maxEditAttempts = 3
+
+// utf8BOM is the byte order mark some editors put at the start of a file.
+const utf8BOM = "\ufeff"
+
+// ConflictError reports that a note changed outside LazyObsidian in a way
+// that keeps an edit from being applied. Re-reading the note and trying
+// again is safe.
+type ConflictError struct {
+	Path string
+	Msg  string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s changed on disk: %s", e.Path, e.Msg)
+}
+
+// IsConflictError reports whether err is a *ConflictError.
+func IsConflictError(err error) bool {
+	var conflict *ConflictError
+	return errors.As(err, &conflict)
+}
+
+// errFileChanged is returned by writeFileAtomic when the file no longer
+// has the expected content.
+var errFileChanged = errors.New("file changed while writing")
+
+// document is a note's content split into lines, remembering what is
+// needed to write it back byte for byte: line endings, byte order mark
+// and whether it ends with a newline (the last line is then empty).
+type document struct {
+	path  string
+	lines []string
+	crlf  bool
+	bom   bool
+	mode  os.FileMode
+	hash  string // of the content as read
+
+	modTime time.Time
+	parsed  *types.File // the content as read, parsed
+	changed bool        // the content changed since the parser last saw it
+}
+
+// readDocument reads a note for editing.
+func readDocument(path string) (*document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &document{
+		path: path,
+		mode: info.Mode().Perm(),
+		hash: hashBytes(data),
+
+		modTime: info.ModTime(),
+	}
+	content := string(data)
+	if strings.HasPrefix(content, utf8BOM) {
+		doc.bom = true
+		content = strings.TrimPrefix(content, utf8BOM)
+	}
+	doc.lines = strings.Split(content, "\n")
+
+	// Line endings are only converted when every line uses CRLF, so that
+	// files with mixed endings are written back unchanged
+	if len(doc.lines) > 1 {
+		doc.crlf = true
+		for _, line := range doc.lines[:len(doc.lines)-1] {
+			if !strings.HasSuffix(line, "\r") {
+				doc.crlf = false
+				break
+			}
+		}
+	}
+	if doc.crlf {
+		for i := range doc.lines[:len(doc.lines)-1] {
+			doc.lines[i] = strings.TrimSuffix(doc.lines[i], "\r")
+		}
+	}
+	return doc, nil
+}
+
+// bytes returns the document's content with its original line endings
+// and byte order mark.
+func (d *document) bytes() []byte {
+	eol := "\n"
+	if d.crlf {
+		eol = "\r\n"
+	}
+	var b bytes.Buffer
+	if d.bom {
+		b.WriteString(utf8BOM)
+	}
+	b.WriteString(strings.Join(d.lines, eol))
+	return b.Bytes()
+}
+
+// editFile applies edit to a note, writes the result atomically and
+// records the change in the journal under op.
+//
+// The note is parsed afresh into doc.parsed before edit runs, so that edit
+// can find its target even if the note changed since the caller parsed
+// it, and return a *ConflictError if it cannot. If the note changes while
+// the edit is applied, the edit is retried on the new content.
+func (w *Writer) editFile(path, op string, edit func(doc *document) error) error {
+	for attempt := 1; ; attempt++ {
+		doc, err := w.loadDocument(path)
+		if err != nil {
+			return err
+		}
+
+		before := append([]string(nil), doc.lines...)
+		if err := edit(doc); err != nil {
+			return err
+		}
+
+		data := doc.bytes()
+		err = writeFileAtomic(path, data, doc.mode, doc.hash)
+		if errors.Is(err, errFileChanged) {
+			if attempt < maxEditAttempts {
+				logging.Debug("%s changed during an edit, retrying", path)
+				continue
+			}
+			return &ConflictError{Path: path, Msg: "it keeps changing, try again later"}
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write file: %w", err)
+		}
+
+		// Our own write is not an outside change
+		w.parser.rememberHash(path, hashBytes(data))
+		if change, ok := journal.Diff(w.relPath(path), before, doc.lines); ok {
+			w.record(op, change)
+		}
+		return nil
+	}
+}
+
+// loadDocument reads and parses a note for editing.
+func (w *Writer) loadDocument(path string) (*document, error) {
+	doc, err := readDocument(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	if known := w.parser.ParsedHash(path); known != "" && known != doc.hash {
+		logging.Info("%s changed since it was parsed", path)
+		doc.changed = true
+	}
+	original := doc.bytes()
+	parsed, err := w.parser.parse(path, bytes.NewReader(original), doc.modTime, int64(len(original)))
+	if err != nil && !IsFrontmatterError(err) {
+		return nil, fmt.Errorf("failed to parse file: %w", err)
+	}
+	doc.parsed = parsed
+	return doc, nil
+}
+
+// writeFileAtomic replaces a file's content through a synced temporary
+// file renamed over it, so that a crash leaves either the old or the new
+// content. If expectHash is set and the file's content no longer has
+// that hash just before the rename, nothing is written and
+// errFileChanged is returned.
+func writeFileAtomic(path string, data []byte, perm os.FileMode, expectHash string) error {
+	// Replace the target of a symlink rather than the link itself
+	if target, err := filepath.EvalSymlinks(path); err == nil {
+		path = target
+	}
+	tmp, err := writeTemp(path, data, perm)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+
+	if expectHash != "" {
+		current, err := HashFile(path)
+		if err != nil || current != expectHash {
+			return errFileChanged
+		}
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	syncDir(filepath.Dir(path))
+	return nil
+}
+
+// createFileAtomic writes a new file through a synced temporary file
+// linked to its name, so that it fails with an error matching
+// os.ErrExist rather than replace a file created in the meantime.
+func createFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := writeTemp(path, data, perm)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+	return linkNew(tmp, path)
+}
+
+// linkNew gives a staged temporary file its name, failing with an error
+// matching os.ErrExist if a file has that name by then.
+func linkNew(tmp, path string) error {
+	exists := &os.PathError{Op: "create", Path: path, Err: os.ErrExist}
+	if err := os.Link(tmp, path); err != nil {
+		if os.IsExist(err) {
+			return exists
+		}
+		// Not every file system has hard links; check as late as possible
+		if _, statErr := os.Lstat(path); statErr == nil {
+			return exists
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			return err
+		}
+	}
+	syncDir(filepath.Dir(path))
+	return nil
+}
+
+// writeTemp writes data to a synced temporary file next to path and
+// returns its name.
+func writeTemp(path string, data []byte, perm os.FileMode) (string, error) {
+	// The temporary name is hidden and does not end in ".md", so the
+	// watcher and the indexer pass over it
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return "", err
+	}
+	if _, err = tmp.Write(data); err == nil {
+		if err = tmp.Sync(); err == nil {
+			err = tmp.Chmod(perm)
+		}
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
+
+// syncDir persists the entries of a directory, such as a rename in it;
+// not every platform can sync a directory.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
+
+// hashBytes returns the content hash used for File.Hash.
+func hashBytes(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,192 @@
+package vault
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BioWare/lazyobsidian/pkg/types"
+)
+
+// Property names read for a book's reading dates.
+var (
+	bookStartedKeys  = []string{"started", "start_date", "date_started"}
+	bookFinishedKeys = []string{"finished", "finish_date", "date_finished"}
+)
+
+// parseBookStatus maps a "status" property to a reading status. Common
+// spellings from reading trackers are accepted.
+func parseBookStatus(s string) (types.BookStatus, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = strings.NewReplacer("_", "-", " ", "-").Replace(s)
+
+	switch s {
+	case "to-read", "toread", "want-to-read", "tbr", "unread", "planned":
+		return types.BookToRead, true
+	case "reading", "currently-reading", "in-progress", "current":
+		return types.BookReading, true
+	case "paused", "on-hold":
+		return types.BookPaused, true
+	case "finished", "read", "done", "completed":
+		return types.BookFinished, true
+	case "abandoned", "dnf", "dropped":
+		return types.BookAbandoned, true
+	}
+	return "", false
+}
+
+// parseRating reads a rating such as 4, 4.5, "4/5", "8/10" or "★★★★"
+// and scales it to five stars.
+func parseRating(props map[string]interface{}) (float64, bool) {
+	if rating, ok := FrontmatterFloat(props, "rating"); ok {
+		return clampRating(rating), true
+	}
+
+	s, ok := FrontmatterString(props, "rating")
+	if !ok {
+		return 0, false
+	}
+	s = strings.TrimSpace(s)
+
+	if stars := strings.Count(s, "★") + strings.Count(s, "⭐"); stars > 0 {
+		return clampRating(float64(stars)), true
+	}
+
+	value, scale, found := strings.Cut(s, "/")
+	n, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return 0, false
+	}
+	if found {
+		max, err := strconv.ParseFloat(strings.TrimSpace(scale), 64)
+		if err != nil || max <= 0 {
+			return 0, false
+		}
+		n = n / max * 5
+	}
+	return clampRating(n), true
+}
+
+func clampRating(r float64) float64 {
+	if r < 0 {
+		return 0
+	}
+	if r > 5 {
+		return 5
+	}
+	return r
+}
+
+// bookDate returns the first of keys holding a date.
+func bookDate(props map[string]interface{}, keys []string) *time.Time {
+	for _, key := range keys {
+		if date, ok := FrontmatterDate(props, key); ok {
+			return &date
+		}
+	}
+	return nil
+}
+
+// chapterHeadings returns the headings that divide a book note into
+// chapters: the first level with more than one heading, looking through
+// a lone title heading.
+func chapterHeadings(headings []types.Heading) []types.Heading {
+	if len(headings) == 1 {
+		return chapterHeadings(headings[0].Children)
+	}
+	return headings
+}
+
+// bookChapters builds a book's chapters. A checklist of chapters wins;
+// otherwise the note's headings are the chapters, and a chapter has
+// notes when its section has text.
+func bookChapters(file *types.File, finished bool) []types.BookChapter {
+	chapters := []types.BookChapter{}
+
+	if len(file.Tasks) > 0 {
+		for i := range file.Tasks {
+			task := &file.Tasks[i]
+			chapters = append(chapters, types.BookChapter{
+				Title:   task.Text,
+				Status:  task.Status,
+				HasNote: lessonHasNote(task, file.Links),
+			})
+		}
+		return chapters
+	}
+
+	status := "open"
+	if finished {
+		status = "done"
+	}
+	for _, h := range chapterHeadings(file.Headings) {
+		chapters = append(chapters, types.BookChapter{
+			Title:   h.Text,
+			Status:  status,
+			HasNote: h.HasContent || len(h.Children) > 0,
+		})
+	}
+	return chapters
+}
+
+// BookFromFile builds a book from its note. Without a "status" property
+// the status is inferred: a finish date or full progress means finished,
+// a start date or any progress means reading, otherwise to-read.
+func BookFromFile(file *types.File) types.Book {
+	book := types.Book{
+		FileID: file.ID,
+		Path:   file.Path,
+		Title:  file.Title,
+	}
+
+	// Extract from frontmatter and inline fields
+	props := Properties(file)
+	if author, ok := FrontmatterString(props, "author"); ok {
+		book.Author = author
+	}
+	if currentPage, ok := FrontmatterInt(props, "current_page"); ok {
+		book.CurrentPage = currentPage
+	}
+	if totalPages, ok := FrontmatterInt(props, "total_pages"); ok {
+		book.TotalPages = totalPages
+	}
+	if target, ok := FrontmatterDate(props, "target_date"); ok {
+		book.TargetDate = &target
+	}
+	if rating, ok := parseRating(props); ok {
+		book.Rating = rating
+	}
+	book.StartedAt = bookDate(props, bookStartedKeys)
+	book.FinishedAt = bookDate(props, bookFinishedKeys)
+
+	if s, ok := FrontmatterString(props, "status"); ok {
+		book.Status, _ = parseBookStatus(s)
+	}
+
+	book.Pomodoros = CountPomodoros(file.Tasks)
+	book.Chapters = bookChapters(file, book.Status == types.BookFinished)
+	completed := 0
+	for _, ch := range book.Chapters {
+		if ch.Status == "done" {
+			completed++
+		}
+		if ch.HasNote {
+			book.Notes++
+		}
+	}
+
+	if book.Status == "" {
+		switch {
+		case book.FinishedAt != nil,
+			book.TotalPages > 0 && book.CurrentPage >= book.TotalPages,
+			book.TotalPages == 0 && len(book.Chapters) > 0 && completed == len(book.Chapters):
+			book.Status = types.BookFinished
+		case book.StartedAt != nil || book.CurrentPage > 0 || completed > 0:
+			book.Status = types.BookReading
+		default:
+			book.Status = types.BookToRead
+		}
+	}
+
+	return book
+}
@@ -0,0 +1,134 @@
+package vault
+
+import (
+	"strings"
+	"time"
+
+	"github.com/BioWare/lazyobsidian/internal/logging"
+)
+
+// setLineStatus changes the status of the task on lines[idx] and returns
+// the line the task is on afterwards. The task gets the date it is done or
+// cancelled, and completing a recurring task adds its next occurrence above
+// it, as the Tasks plugin does.
+func (w *Writer) setLineStatus(doc *document, idx int, status string) int {
+	old := doc.lines[idx]
+	m := taskPattern.FindStringSubmatch(old)
+	if m == nil {
+		return idx
+	}
+	now := time.Now()
+	line := w.replaceTaskStatus(old, w.parser.statusToSymbol(status))
+	doc.lines[idx] = w.stampStatusDate(line, status, now)
+
+	if status == "done" && w.parser.symbolToStatus(m[2]) != "done" {
+		if next, ok := w.nextOccurrence(old, now); ok {
+			doc.lines = insertLines(doc.lines, idx, []string{next})
+			logging.Info("Next occurrence: %s", next)
+			return idx + 1
+		}
+	}
+	return idx
+}
+
+// cascadeStatus applies the subtask rules of the config once the task on
+// lines[idx] has changed to status: closing its open subtasks, and
+// completing or reopening its parents. It returns how many lines were added
+// above the task, by recurring parents.
+func (w *Writer) cascadeStatus(doc *document, idx int, status string) int {
+	if w.config == nil {
+		return 0
+	}
+	cfg := w.config.Tasks.Subtasks
+
+	if cfg.AutoCloseChildren && isClosed(status) {
+		closeAs := cfg.CloseChildrenAs
+		if closeAs == "" {
+			closeAs = "done"
+		}
+		// Bottom up, so that added occurrences do not move the lines left
+		for i := blockEnd(doc.lines, idx) - 1; i > idx; i-- {
+			if st, ok := w.lineStatus(doc.lines[i]); ok && !isClosed(st) {
+				w.setLineStatus(doc, i, closeAs)
+			}
+		}
+	}
+
+	shift := 0
+	if !cfg.AutoCompleteParent {
+		return shift
+	}
+	for child := idx; ; {
+		parent := parentTask(doc.lines, child)
+		if parent < 0 {
+			break
+		}
+		parentStatus, _ := w.lineStatus(doc.lines[parent])
+
+		var next string
+		switch {
+		case isClosed(status) && !isClosed(parentStatus) && w.childrenDone(doc.lines, parent):
+			next = "done"
+		case !isClosed(status) && parentStatus == "done":
+			next = "open"
+		default:
+			return shift
+		}
+		moved := w.setLineStatus(doc, parent, next)
+		shift += moved - parent
+		child, status = moved, next
+	}
+	return shift
+}
+
+// childrenDone reports whether every subtask directly under the task on
+// lines[parent] is closed, and at least one of them is done.
+func (w *Writer) childrenDone(lines []string, parent int) bool {
+	done := false
+	for i := parent + 1; i < blockEnd(lines, parent); i++ {
+		status, ok := w.lineStatus(lines[i])
+		if !ok || parentTask(lines, i) != parent {
+			continue
+		}
+		if !isClosed(status) {
+			return false
+		}
+		done = done || status == "done"
+	}
+	return done
+}
+
+// lineStatus returns the status of the task on a line.
+func (w *Writer) lineStatus(line string) (string, bool) {
+	m := taskPattern.FindStringSubmatch(line)
+	if m == nil {
+		return "", false
+	}
+	return w.parser.symbolToStatus(m[2]), true
+}
+
+// parentTask returns the line of the task a task is nested under, or -1
+// when it is not nested under one.
+func parentTask(lines []string, idx int) int {
+	base := indentWidth(lines[idx])
+	for i := idx - 1; i >= 0 && base > 0; i-- {
+		if strings.TrimSpace(lines[i]) == "" {
+			continue
+		}
+		width := indentWidth(lines[i])
+		switch {
+		case width >= base:
+			continue
+		case taskPattern.MatchString(lines[i]):
+			return i
+		case listItemPattern.MatchString(lines[i]), width == 0:
+			// Nested under a plain list item, or not nested at all
+			return -1
+		}
+	}
+	return -1
+}
+
+func isClosed(status string) bool {
+	return status == "done" || status == "cancelled"
+}
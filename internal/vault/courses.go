@@ -0,0 +1,177 @@
+package vault
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/BioWare/lazyobsidian/internal/courses"
+	"github.com/BioWare/lazyobsidian/pkg/types"
+)
+
+var (
+	// lessonDurationPattern matches a lesson length such as "(12m)",
+	// "(1h 30m)" or "(12:34)" in a lesson title
+	lessonDurationPattern = regexp.MustCompile(`(?i)\(\s*(\d+\s*(?:h|hr|hrs|m|min|mins)(?:\s*\d+\s*(?:m|min|mins))?|\d{1,2}:\d{2}(?::\d{2})?)\s*\)`)
+	// durationPartPattern matches one "<number><unit>" part of a duration
+	durationPartPattern = regexp.MustCompile(`(?i)(\d+)\s*(h|hr|hrs|m|min|mins)\b`)
+)
+
+// lessonDurationFields are the inline fields read as a lesson's length.
+var lessonDurationFields = []string{"duration", "length", "time"}
+
+// parseDurationMinutes parses a lesson length into minutes. It accepts
+// unit forms ("12m", "1h 30m", "90 min"), video timestamps ("12:34" as
+// minutes and seconds, "1:02:03") and bare numbers of minutes. Seconds
+// round up to the next minute.
+func parseDurationMinutes(s string) (int, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, false
+	}
+
+	if n, err := strconv.Atoi(s); err == nil && n >= 0 {
+		return n, true
+	}
+
+	if strings.Contains(s, ":") {
+		var seconds int
+		for _, part := range strings.Split(s, ":") {
+			n, err := strconv.Atoi(part)
+			if err != nil || n < 0 {
+				return 0, false
+			}
+			seconds = seconds*60 + n
+		}
+		return (seconds + 59) / 60, true
+	}
+
+	parts := durationPartPattern.FindAllStringSubmatch(s, -1)
+	if parts == nil {
+		return 0, false
+	}
+	var minutes int
+	for _, m := range parts {
+		n, _ := strconv.Atoi(m[1])
+		if strings.HasPrefix(strings.ToLower(m[2]), "h") {
+			n *= 60
+		}
+		minutes += n
+	}
+	return minutes, true
+}
+
+// lessonDuration returns a lesson's length in minutes from its inline
+// fields or a "(12m)" in its text, and the text with the duration removed.
+func lessonDuration(task *types.Task) (int, string) {
+	for _, key := range lessonDurationFields {
+		if value, ok := task.Fields[key]; ok {
+			if minutes, ok := parseDurationMinutes(fmt.Sprint(value)); ok {
+				return minutes, task.Text
+			}
+		}
+	}
+
+	m := lessonDurationPattern.FindStringSubmatchIndex(task.Text)
+	if m == nil {
+		return 0, task.Text
+	}
+	minutes, ok := parseDurationMinutes(task.Text[m[2]:m[3]])
+	if !ok {
+		return 0, task.Text
+	}
+	text := task.Text[:m[0]] + task.Text[m[1]:]
+	return minutes, strings.TrimSpace(multiSpacePattern.ReplaceAllString(text, " "))
+}
+
+// lessonHasNote reports whether a lesson has notes: it is marked with 📎
+// or links to another note. Links known not to resolve, and embeds, do
+// not count.
+func lessonHasNote(task *types.Task, links []types.Link) bool {
+	if task.HasNote {
+		return true
+	}
+	for _, link := range links {
+		if link.Line != task.Line || link.Type == types.LinkTypeEmbed || link.Target == "" {
+			continue
+		}
+		if link.Status != types.LinkUnresolved && strings.HasSuffix(link.TargetPath, ".md") {
+			return true
+		}
+	}
+	return false
+}
+
+// CourseFromFile builds a course from its note. Each heading with tasks
+// directly beneath it becomes a section and each top-level task a
+// lesson; tasks above the first such heading form a section named after
+// the course. Links in the file should already be resolved for lesson
+// notes to be detected.
+func CourseFromFile(file *types.File) types.Course {
+	course := types.Course{
+		FileID:   file.ID,
+		Title:    file.Title,
+		Sections: []types.CourseSection{},
+	}
+
+	// Extract from frontmatter and inline fields
+	props := Properties(file)
+	if source, ok := FrontmatterString(props, "source"); ok {
+		course.Source = source
+	}
+	if url, ok := FrontmatterString(props, "url"); ok {
+		course.URL = url
+	}
+	if target, ok := FrontmatterDate(props, "target_date"); ok {
+		course.TargetDate = &target
+	}
+
+	sectionIndex := make(map[int]int) // heading line -> index in Sections
+	for i := range file.Tasks {
+		task := &file.Tasks[i]
+
+		idx, ok := sectionIndex[task.SectionLine]
+		if !ok {
+			title := task.Section
+			if task.SectionLine == 0 {
+				title = file.Title
+			}
+			idx = len(course.Sections)
+			sectionIndex[task.SectionLine] = idx
+			course.Sections = append(course.Sections, types.CourseSection{Title: title})
+		}
+
+		duration, title := lessonDuration(task)
+		section := &course.Sections[idx]
+		section.Lessons = append(section.Lessons, types.CourseLesson{
+			Title:    title,
+			Status:   task.Status,
+			Duration: duration,
+			HasNote:  lessonHasNote(task, file.Links),
+		})
+
+		course.TotalLessons++
+		if task.Status == "done" {
+			course.Completed++
+		}
+	}
+
+	for i := range course.Sections {
+		course.Sections[i].Progress = courses.CalculateSectionProgress(&course.Sections[i])
+		for _, lesson := range course.Sections[i].Lessons {
+			if lesson.HasNote {
+				course.Notes++
+			}
+		}
+	}
+
+	course.Pomodoros = CountPomodoros(file.Tasks)
+
+	// A declared lesson count wins over the lessons listed so far
+	if total, ok := FrontmatterInt(props, "total_lessons"); ok && total > 0 {
+		course.TotalLessons = total
+	}
+
+	return course
+}
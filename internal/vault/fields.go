@@ -0,0 +1,107 @@
+package vault
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/BioWare/lazyobsidian/pkg/types"
+)
+
+// Dataview inline field patterns. Keys may contain letters, digits,
+// underscores, spaces and dashes; whole-line keys may be wrapped in bold.
+var (
+	bracketFieldPattern = regexp.MustCompile(`\[([\p{L}\p{N}_][\p{L}\p{N}_ \-]*?)::\s*([^\[\]]*?)\s*\]`)
+	parenFieldPattern   = regexp.MustCompile(`\(([\p{L}\p{N}_][\p{L}\p{N}_ \-]*?)::\s*([^()]*?)\s*\)`)
+	lineFieldPattern    = regexp.MustCompile(`^\s*(?:[-*+]\s+)?(?:\*\*|__)?([\p{L}\p{N}_][\p{L}\p{N}_ \-]*?)(?:\*\*|__)?::\s*(.*?)\s*$`)
+)
+
+// parseInlineFields extracts bracketed and parenthesized inline fields
+// from text. It returns the fields found and the text with them removed.
+func parseInlineFields(text string) (map[string]interface{}, string) {
+	var fields map[string]interface{}
+
+	for _, pattern := range []*regexp.Regexp{bracketFieldPattern, parenFieldPattern} {
+		for _, m := range pattern.FindAllStringSubmatch(text, -1) {
+			if fields == nil {
+				fields = make(map[string]interface{})
+			}
+			addField(fields, m[1], m[2])
+		}
+		text = pattern.ReplaceAllString(text, "")
+	}
+
+	return fields, strings.TrimSpace(multiSpacePattern.ReplaceAllString(text, " "))
+}
+
+// parseLineField parses a whole-line "key:: value" field.
+func parseLineField(line string) (key, value string, ok bool) {
+	m := lineFieldPattern.FindStringSubmatch(line)
+	if m == nil {
+		return "", "", false
+	}
+	return strings.TrimSpace(m[1]), m[2], true
+}
+
+// parseTaskFields moves inline fields on a task line into task.Fields.
+func parseTaskFields(task *types.Task) {
+	fields, text := parseInlineFields(task.Text)
+	if fields == nil {
+		return
+	}
+	task.Fields = fields
+	task.Text = text
+}
+
+// extractLineFields adds every inline field on a non-task line to fields.
+func extractLineFields(line string, fields map[string]interface{}) {
+	if key, value, ok := parseLineField(line); ok {
+		addField(fields, key, value)
+		return
+	}
+	inline, _ := parseInlineFields(line)
+	mergeFields(fields, inline)
+}
+
+// addField stores value under key. Repeated keys collect into a list,
+// matching how Dataview treats a field set more than once.
+func addField(fields map[string]interface{}, key, value string) {
+	key = strings.TrimSpace(key)
+	value = strings.TrimSpace(value)
+
+	switch existing := fields[key].(type) {
+	case nil:
+		fields[key] = value
+	case []interface{}:
+		fields[key] = append(existing, value)
+	default:
+		fields[key] = []interface{}{existing, value}
+	}
+}
+
+// mergeFields adds every field in src to dst.
+func mergeFields(dst, src map[string]interface{}) {
+	for key, value := range src {
+		switch v := value.(type) {
+		case []interface{}:
+			for _, item := range v {
+				addField(dst, key, item.(string))
+			}
+		case string:
+			addField(dst, key, v)
+		}
+	}
+}
+
+// Properties returns the combined frontmatter and inline fields of a file.
+// Frontmatter takes precedence when a key is set in both places.
+// The result works with the Frontmatter* accessors.
+func Properties(file *types.File) map[string]interface{} {
+	props := make(map[string]interface{}, len(file.Frontmatter)+len(file.Fields))
+	for key, value := range file.Fields {
+		props[key] = value
+	}
+	for key, value := range file.Frontmatter {
+		props[key] = value
+	}
+	return props
+}
@@ -0,0 +1,309 @@
+package vault
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// yamlLinePattern extracts the line number from yaml.v3 syntax errors,
+// which are reported as "yaml: line N: message".
+var yamlLinePattern = regexp.MustCompile(`^yaml: line (\d+): (.+)$`)
+
+// FrontmatterError describes a malformed frontmatter block.
+// Line and Column are 1-based positions in the note itself, not in the
+// YAML block. Column is 0 when the YAML parser does not report one.
+type FrontmatterError struct {
+	Path   string
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *FrontmatterError) Error() string {
+	if e.Column > 0 {
+		return fmt.Sprintf("%s:%d:%d: frontmatter: %s", e.Path, e.Line, e.Column, e.Msg)
+	}
+	return fmt.Sprintf("%s:%d: frontmatter: %s", e.Path, e.Line, e.Msg)
+}
+
+// parseFrontmatter decodes the lines between the "---" delimiters as YAML.
+// firstLine is the file line number of lines[0], used to report errors
+// relative to the note rather than the block.
+//
+// Values keep their YAML types: integers decode to int, floats to float64,
+// timestamps to time.Time, nested mappings to map[string]interface{} and
+// sequences to []interface{}.
+func parseFrontmatter(lines []string, firstLine int) (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(strings.Join(lines, "\n")), &doc); err != nil {
+		fmErr := &FrontmatterError{Line: firstLine, Msg: err.Error()}
+		if m := yamlLinePattern.FindStringSubmatch(err.Error()); m != nil {
+			n, _ := strconv.Atoi(m[1])
+			fmErr.Line = firstLine + n - 1
+			fmErr.Msg = m[2]
+		}
+		return nil, fmErr
+	}
+
+	// An empty block is valid and yields an empty map
+	if doc.Kind == 0 || len(doc.Content) == 0 {
+		return result, nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind == yaml.ScalarNode && root.Tag == "!!null" {
+		return result, nil
+	}
+	if root.Kind != yaml.MappingNode {
+		return nil, nodeError(root, firstLine, "expected a mapping of keys to values")
+	}
+
+	if err := decodeMapping(root, firstLine, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// decodeMapping converts a YAML mapping node into dst, keeping every key
+// as a string so the result can always be marshalled to JSON.
+func decodeMapping(node *yaml.Node, firstLine int, dst map[string]interface{}) error {
+	seen := make(map[string]*yaml.Node)
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode := resolveAlias(node.Content[i])
+		valueNode := node.Content[i+1]
+
+		if keyNode.Kind != yaml.ScalarNode {
+			return nodeError(keyNode, firstLine, "mapping keys must be scalars")
+		}
+
+		// Merge keys (<<: *anchor) copy the referenced mapping in place
+		if keyNode.Tag == "!!merge" {
+			merged := resolveAlias(valueNode)
+			if merged.Kind != yaml.MappingNode {
+				return nodeError(valueNode, firstLine, "merge value must be a mapping")
+			}
+			if err := decodeMapping(merged, firstLine, dst); err != nil {
+				return err
+			}
+			continue
+		}
+
+		key := keyNode.Value
+		if prev, ok := seen[key]; ok {
+			return nodeError(keyNode, firstLine,
+				fmt.Sprintf("key %q already defined at line %d", key, firstLine+prev.Line-1))
+		}
+		seen[key] = keyNode
+
+		value, err := decodeNode(valueNode, firstLine)
+		if err != nil {
+			return err
+		}
+		dst[key] = value
+	}
+
+	return nil
+}
+
+// decodeNode converts any YAML node into its Go value.
+func decodeNode(node *yaml.Node, firstLine int) (interface{}, error) {
+	node = resolveAlias(node)
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		m := make(map[string]interface{})
+		if err := decodeMapping(node, firstLine, m); err != nil {
+			return nil, err
+		}
+		return m, nil
+
+	case yaml.SequenceNode:
+		items := make([]interface{}, 0, len(node.Content))
+		for _, child := range node.Content {
+			item, err := decodeNode(child, firstLine)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+
+	case yaml.ScalarNode:
+		var value interface{}
+		if err := node.Decode(&value); err != nil {
+			return nil, nodeError(node, firstLine, err.Error())
+		}
+		return value, nil
+	}
+
+	return nil, nodeError(node, firstLine, "unsupported YAML node")
+}
+
+// resolveAlias follows alias nodes to the anchored node they reference.
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
+
+func nodeError(node *yaml.Node, firstLine int, msg string) *FrontmatterError {
+	return &FrontmatterError{
+		Line:   firstLine + node.Line - 1,
+		Column: node.Column,
+		Msg:    msg,
+	}
+}
+
+// Typed frontmatter accessors.
+//
+// Frontmatter reaches the managers either straight from the parser, with
+// YAML types, or from the cache, where it has been through JSON and dates
+// are strings. Inline fields are always strings. These helpers accept all
+// of those shapes, so they work on Frontmatter, Fields and Properties alike.
+
+// FrontmatterString returns a string value for key.
+func FrontmatterString(fm map[string]interface{}, key string) (string, bool) {
+	switch v := fm[key].(type) {
+	case string:
+		return v, true
+	case nil:
+		return "", false
+	case time.Time:
+		return v.Format("2006-01-02"), true
+	case []interface{}, map[string]interface{}:
+		return "", false
+	default:
+		return fmt.Sprint(v), true
+	}
+}
+
+// FrontmatterInt returns an integer value for key.
+// Integral floats and numeric strings are accepted.
+func FrontmatterInt(fm map[string]interface{}, key string) (int, bool) {
+	switch v := fm[key].(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case uint64:
+		return int(v), true
+	case float64:
+		if v == math.Trunc(v) {
+			return int(v), true
+		}
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return int(n), true
+		}
+	case string:
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			return n, true
+		}
+	}
+	return 0, false
+}
+
+// FrontmatterFloat returns a floating point value for key.
+func FrontmatterFloat(fm map[string]interface{}, key string) (float64, bool) {
+	switch v := fm[key].(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case json.Number:
+		if f, err := v.Float64(); err == nil {
+			return f, true
+		}
+	case string:
+		if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			return f, true
+		}
+	}
+	return 0, false
+}
+
+// FrontmatterBool returns a boolean value for key.
+func FrontmatterBool(fm map[string]interface{}, key string) (bool, bool) {
+	switch v := fm[key].(type) {
+	case bool:
+		return v, true
+	case string:
+		if b, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+			return b, true
+		}
+	}
+	return false, false
+}
+
+// frontmatterDateLayouts are tried in order when a date arrives as a string.
+var frontmatterDateLayouts = []string{
+	"2006-01-02",
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+}
+
+// FrontmatterDate returns a date value for key.
+// Dates written as wikilinks ("[[2024-05-01]]") are also accepted.
+func FrontmatterDate(fm map[string]interface{}, key string) (time.Time, bool) {
+	switch v := fm[key].(type) {
+	case time.Time:
+		return v, true
+	case string:
+		return parseDateString(v)
+	}
+	return time.Time{}, false
+}
+
+// parseDateString parses a date from a frontmatter or inline value.
+func parseDateString(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "[["), "]]")
+	for _, layout := range frontmatterDateLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// FrontmatterStrings returns a list of strings for key.
+// A single scalar value is returned as a one-element list.
+func FrontmatterStrings(fm map[string]interface{}, key string) []string {
+	switch v := fm[key].(type) {
+	case []interface{}:
+		var result []string
+		for _, item := range v {
+			if item == nil {
+				continue
+			}
+			result = append(result, fmt.Sprint(item))
+		}
+		return result
+	case []string:
+		return v
+	case nil:
+		return nil
+	}
+	if s, ok := FrontmatterString(fm, key); ok && s != "" {
+		return []string{s}
+	}
+	return nil
+}
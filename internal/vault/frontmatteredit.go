@@ -0,0 +1,533 @@
+package vault
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/BioWare/lazyobsidian/pkg/types"
+)
+
+// FrontmatterChange is one change to a note's frontmatter.
+type FrontmatterChange struct {
+	Key    string
+	Value  interface{}
+	Remove bool // remove Key; Value is ignored
+	Append bool // add Value to the list in Key, turning a single value into a list
+}
+
+// SetFrontmatter sets a frontmatter property of a note, adding the key, or
+// the whole frontmatter block, when missing.
+func (w *Writer) SetFrontmatter(filePath, key string, value interface{}) error {
+	return w.EditFrontmatter(filePath, "set "+key, FrontmatterChange{Key: key, Value: value})
+}
+
+// RemoveFrontmatter removes a frontmatter property of a note, if set.
+func (w *Writer) RemoveFrontmatter(filePath, key string) error {
+	return w.EditFrontmatter(filePath, "remove "+key, FrontmatterChange{Key: key, Remove: true})
+}
+
+// AppendFrontmatter adds values to a list property of a note, such as
+// tags or aliases.
+func (w *Writer) AppendFrontmatter(filePath, key string, values ...interface{}) error {
+	changes := make([]FrontmatterChange, 0, len(values))
+	for _, v := range values {
+		changes = append(changes, FrontmatterChange{Key: key, Value: v, Append: true})
+	}
+	return w.EditFrontmatter(filePath, "append to "+key, changes...)
+}
+
+// EditFrontmatter applies changes to a note's frontmatter as one edit,
+// recorded in the journal under op.
+//
+// Only the lines of the keys that change are rewritten: the other keys,
+// their order, comments and blank lines stay as they are. A scalar that is
+// replaced by another keeps its quoting style and trailing comment. Notes
+// with malformed frontmatter are left alone.
+func (w *Writer) EditFrontmatter(filePath, op string, changes ...FrontmatterChange) error {
+	return w.editFile(filePath, op, func(doc *document) error {
+		for _, change := range changes {
+			if err := editFrontmatter(doc, change); err != nil {
+				return err
+			}
+		}
+
+		// Never write frontmatter the parser cannot read back
+		if block, err := findFrontmatter(doc.lines); err != nil {
+			return fmt.Errorf("frontmatter edit produced invalid YAML: %w", err)
+		} else if block != nil {
+			for _, change := range changes {
+				if change.Remove {
+					continue
+				}
+				if _, ok := block.entry(change.Key); !ok {
+					return fmt.Errorf("frontmatter edit lost key %q", change.Key)
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// frontmatterBlock is the frontmatter of a note, as document lines.
+type frontmatterBlock struct {
+	open, close int // lines of the "---" delimiters
+	entries     []frontmatterEntry
+}
+
+// frontmatterEntry is a top-level key and the lines its value spans.
+type frontmatterEntry struct {
+	key         string
+	keyNode     *yaml.Node
+	value       *yaml.Node
+	first, last int // document lines, inclusive
+}
+
+// findFrontmatter locates the frontmatter of a note. It returns nil when
+// the note has none.
+func findFrontmatter(lines []string) (*frontmatterBlock, error) {
+	if len(lines) == 0 || !frontmatterStart.MatchString(lines[0]) {
+		return nil, nil
+	}
+	block := &frontmatterBlock{open: 0, close: -1}
+	for i := 1; i < len(lines); i++ {
+		if frontmatterStart.MatchString(lines[i]) {
+			block.close = i
+			break
+		}
+	}
+	if block.close < 0 {
+		return nil, &FrontmatterError{Line: 1, Msg: "unterminated frontmatter block"}
+	}
+
+	body := lines[1:block.close]
+	if _, err := parseFrontmatter(body, 2); err != nil {
+		return nil, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(strings.Join(body, "\n")), &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		// Empty or null frontmatter has no keys yet
+		return block, nil
+	}
+
+	root := doc.Content[0]
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		keyNode, value := root.Content[i], root.Content[i+1]
+		if keyNode.Tag == "!!merge" {
+			continue
+		}
+		block.entries = append(block.entries, frontmatterEntry{
+			key:     keyNode.Value,
+			keyNode: keyNode,
+			value:   value,
+			first:   block.open + keyNode.Line,
+		})
+	}
+
+	// A value runs until the next key, less the blank lines and comments
+	// in between, which belong to the next key
+	for i := range block.entries {
+		next := block.close
+		if i+1 < len(block.entries) {
+			next = block.entries[i+1].first
+		}
+		last := next - 1
+		for last > block.entries[i].first {
+			trimmed := strings.TrimSpace(lines[last])
+			if trimmed != "" && !strings.HasPrefix(lines[last], "#") {
+				break
+			}
+			last--
+		}
+		block.entries[i].last = last
+	}
+	return block, nil
+}
+
+// entry returns the entry of key.
+func (b *frontmatterBlock) entry(key string) (frontmatterEntry, bool) {
+	for _, e := range b.entries {
+		if e.key == key {
+			return e, true
+		}
+	}
+	return frontmatterEntry{}, false
+}
+
+// editFrontmatter applies one change to the lines of a document.
+func editFrontmatter(doc *document, change FrontmatterChange) error {
+	block, err := findFrontmatter(doc.lines)
+	if err != nil {
+		var fmErr *FrontmatterError
+		if errors.As(err, &fmErr) {
+			fmErr.Path = doc.path
+		}
+		return fmt.Errorf("cannot edit frontmatter: %w", err)
+	}
+
+	if block == nil {
+		if change.Remove {
+			return nil
+		}
+		value := change.Value
+		if change.Append {
+			value = []interface{}{value}
+		}
+		rendered, err := renderProperty(change.Key, value, false)
+		if err != nil {
+			return err
+		}
+		lines := append([]string{"---"}, rendered...)
+		doc.lines = insertLines(doc.lines, 0, append(lines, "---"))
+		return nil
+	}
+
+	entry, exists := block.entry(change.Key)
+	switch {
+	case change.Remove:
+		if exists {
+			doc.lines = removeLines(doc.lines, entry.first, entry.last+1)
+		}
+		return nil
+
+	case !exists:
+		value := change.Value
+		if change.Append {
+			value = []interface{}{value}
+		}
+		rendered, err := renderProperty(change.Key, value, false)
+		if err != nil {
+			return err
+		}
+		doc.lines = insertLines(doc.lines, block.close, rendered)
+		return nil
+
+	case change.Append:
+		return appendProperty(doc, entry, change.Value)
+	}
+
+	// A single-line scalar replaced by a scalar is changed in place
+	if line, ok := replaceScalar(doc.lines[entry.first], entry, change.Value); ok && entry.first == entry.last {
+		doc.lines[entry.first] = line
+		return nil
+	}
+	rendered, err := renderProperty(change.Key, change.Value, entry.value.Style&yaml.FlowStyle != 0)
+	if err != nil {
+		return err
+	}
+	rendered = keepKey(doc.lines[entry.first], entry, rendered)
+	if entry.value.Kind == yaml.SequenceNode && entry.value.Style&yaml.FlowStyle == 0 {
+		rendered = reindentItems(rendered, itemPrefix(doc.lines, entry))
+	}
+	doc.lines = removeLines(doc.lines, entry.first, entry.last+1)
+	doc.lines = insertLines(doc.lines, entry.first, rendered)
+	return nil
+}
+
+// appendProperty adds a value to an existing property.
+func appendProperty(doc *document, entry frontmatterEntry, value interface{}) error {
+	switch {
+	case entry.value.Kind == yaml.SequenceNode && entry.value.Style&yaml.FlowStyle == 0 && len(entry.value.Content) > 0:
+		// Block list: add an item in the style of the others
+		item, err := renderScalar(value, 0)
+		if err != nil {
+			return err
+		}
+		if len(item) != 1 {
+			return fmt.Errorf("cannot append a multi-line value to %q", entry.key)
+		}
+		line := itemPrefix(doc.lines, entry) + item[0]
+		doc.lines = insertLines(doc.lines, entry.last+1, []string{line})
+		return nil
+
+	case entry.value.Kind == yaml.SequenceNode && entry.first == entry.last:
+		// Flow list on one line, such as "tags: [a, b]"
+		var node yaml.Node
+		if err := node.Encode(value); err != nil {
+			return err
+		}
+		list := *entry.value
+		list.Content = append(append([]*yaml.Node(nil), list.Content...), &node)
+		list.Style |= yaml.FlowStyle
+		list.LineComment = ""
+		out, err := yaml.Marshal(&list)
+		if err != nil {
+			return err
+		}
+		line := doc.lines[entry.first]
+		runes := []rune(line)
+		start := entry.value.Column - 1
+		if start > len(runes) {
+			return fmt.Errorf("cannot append to %q", entry.key)
+		}
+		newLine := string(runes[:start]) + strings.TrimSpace(string(out))
+		if entry.value.LineComment != "" {
+			newLine += " " + entry.value.LineComment
+		}
+		doc.lines[entry.first] = newLine
+		return nil
+	}
+
+	// A single value, or none, becomes a list
+	var items []interface{}
+	if !(entry.value.Kind == yaml.ScalarNode && entry.value.Tag == "!!null") {
+		var existing interface{}
+		if err := entry.value.Decode(&existing); err != nil {
+			return err
+		}
+		if list, ok := existing.([]interface{}); ok {
+			items = list
+		} else {
+			items = []interface{}{existing}
+		}
+	}
+	items = append(items, value)
+	rendered, err := renderProperty(entry.key, items, false)
+	if err != nil {
+		return err
+	}
+	rendered = keepKey(doc.lines[entry.first], entry, rendered)
+	doc.lines = removeLines(doc.lines, entry.first, entry.last+1)
+	doc.lines = insertLines(doc.lines, entry.first, rendered)
+	return nil
+}
+
+// replaceScalar replaces the value on a "key: value" line, keeping the
+// key, the spacing, the quoting style and any trailing comment.
+func replaceScalar(line string, entry frontmatterEntry, value interface{}) (string, bool) {
+	old := entry.value
+	if old.Kind != yaml.ScalarNode || old.Line != entry.keyNode.Line || old.Tag == "!!null" ||
+		old.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 || old.Anchor != "" {
+		return "", false
+	}
+	rendered, err := renderScalar(value, old.Style)
+	if err != nil || len(rendered) != 1 {
+		return "", false
+	}
+
+	runes := []rune(line)
+	start := old.Column - 1
+	if start < 0 || start >= len(runes) {
+		return "", false
+	}
+	end := scalarEnd(runes, start, old.Style)
+	if end < 0 {
+		return "", false
+	}
+	return string(runes[:start]) + rendered[0] + string(runes[end:]), true
+}
+
+// scalarEnd returns where the scalar starting at start ends on its line,
+// or -1 if it cannot tell.
+func scalarEnd(runes []rune, start int, style yaml.Style) int {
+	switch {
+	case style&yaml.DoubleQuotedStyle != 0:
+		for i := start + 1; i < len(runes); i++ {
+			switch runes[i] {
+			case '\\':
+				i++
+			case '"':
+				return i + 1
+			}
+		}
+		return -1
+	case style&yaml.SingleQuotedStyle != 0:
+		for i := start + 1; i < len(runes); i++ {
+			if runes[i] == '\'' {
+				if i+1 < len(runes) && runes[i+1] == '\'' {
+					i++
+					continue
+				}
+				return i + 1
+			}
+		}
+		return -1
+	}
+
+	// A plain scalar ends at a comment or the end of the line
+	end := len(runes)
+	for i := start; i < len(runes); i++ {
+		if runes[i] == '#' && i > start && (runes[i-1] == ' ' || runes[i-1] == '\t') {
+			end = i
+			break
+		}
+	}
+	for end > start && (runes[end-1] == ' ' || runes[end-1] == '\t') {
+		end--
+	}
+	return end
+}
+
+// renderProperty renders "key: value" as frontmatter lines. Lists and
+// mappings are written on one line when flow is set, and otherwise as
+// blocks indented by two spaces, as Obsidian does.
+func renderProperty(key string, value interface{}, flow bool) ([]string, error) {
+	valueNode, err := valueToNode(value, 0)
+	if err != nil {
+		return nil, err
+	}
+	if flow && valueNode.Kind != yaml.ScalarNode {
+		valueNode.Style |= yaml.FlowStyle
+	}
+	keyNode := &yaml.Node{Kind: yaml.ScalarNode, Value: key}
+	if valueNode.Kind == yaml.ScalarNode && valueNode.Tag == "!!null" {
+		out, err := marshalNode(keyNode)
+		if err != nil {
+			return nil, err
+		}
+		return []string{out[0] + ":"}, nil
+	}
+	return marshalNode(&yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{keyNode, valueNode}})
+}
+
+// renderScalar renders a value on its own, quoting strings in style when
+// that style is a quoted one.
+func renderScalar(value interface{}, style yaml.Style) ([]string, error) {
+	node, err := valueToNode(value, style)
+	if err != nil {
+		return nil, err
+	}
+	return marshalNode(node)
+}
+
+// valueToNode converts a value to a YAML node. Dates without a time of day
+// are written as plain dates.
+func valueToNode(value interface{}, style yaml.Style) (*yaml.Node, error) {
+	if t, ok := value.(time.Time); ok && t.Equal(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())) {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!timestamp", Value: t.Format("2006-01-02")}, nil
+	}
+	if value == nil {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null"}, nil
+	}
+
+	node := &yaml.Node{}
+	if err := node.Encode(value); err != nil {
+		return nil, fmt.Errorf("cannot write %v as YAML: %w", value, err)
+	}
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!str" && style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0 &&
+		!strings.Contains(node.Value, "\n") {
+		node.Style = style & (yaml.DoubleQuotedStyle | yaml.SingleQuotedStyle)
+	}
+	return node, nil
+}
+
+// marshalNode renders a node as YAML lines.
+func marshalNode(node *yaml.Node) ([]string, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(node); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return strings.Split(strings.TrimRight(buf.String(), "\n"), "\n"), nil
+}
+
+// keepKey puts the key of the original line, as written, in place of the
+// rendered one, so that a quoted key stays quoted.
+func keepKey(original string, entry frontmatterEntry, rendered []string) []string {
+	key, err := marshalNode(&yaml.Node{Kind: yaml.ScalarNode, Value: entry.key})
+	if err != nil || !strings.HasPrefix(rendered[0], key[0]) {
+		return rendered
+	}
+
+	runes := []rune(original)
+	start := entry.keyNode.Column - 1
+	end := start + len([]rune(entry.key))
+	if entry.keyNode.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0 {
+		end = scalarEnd(runes, start, entry.keyNode.Style)
+	}
+	if start < 0 || end < 0 || end > len(runes) {
+		return rendered
+	}
+	rendered[0] = string(runes[:end]) + rendered[0][len(key[0]):]
+	return rendered
+}
+
+// itemPrefix returns how the items of a block list start, such as "  - ".
+func itemPrefix(lines []string, entry frontmatterEntry) string {
+	for i := entry.first + 1; i <= entry.last; i++ {
+		trimmed := strings.TrimLeft(lines[i], " \t")
+		if strings.HasPrefix(trimmed, "- ") || trimmed == "-" {
+			return lines[i][:len(lines[i])-len(trimmed)] + "- "
+		}
+	}
+	return "  - "
+}
+
+// reindentItems rewrites the "  - " items of a rendered list with prefix.
+func reindentItems(rendered []string, prefix string) []string {
+	for i := 1; i < len(rendered); i++ {
+		if strings.HasPrefix(rendered[i], "  - ") {
+			rendered[i] = prefix + strings.TrimPrefix(rendered[i], "  - ")
+		}
+	}
+	return rendered
+}
+
+// SetBookPage sets the page a book is at.
+func (w *Writer) SetBookPage(book *types.Book, page int) error {
+	if page < 0 {
+		page = 0
+	}
+	if book.TotalPages > 0 && page > book.TotalPages {
+		page = book.TotalPages
+	}
+	return w.EditFrontmatter(book.Path, "set current page", FrontmatterChange{Key: "current_page", Value: page})
+}
+
+// SetBookStatus sets a book's reading status. Starting or finishing a book
+// also records the day, unless the note already has that date.
+func (w *Writer) SetBookStatus(book *types.Book, status types.BookStatus) error {
+	changes := []FrontmatterChange{{Key: "status", Value: string(status)}}
+	today := time.Now()
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.Local)
+
+	switch status {
+	case types.BookReading:
+		if book.StartedAt == nil {
+			changes = append(changes, FrontmatterChange{Key: bookStartedKeys[0], Value: today})
+		}
+	case types.BookFinished:
+		if book.StartedAt == nil {
+			changes = append(changes, FrontmatterChange{Key: bookStartedKeys[0], Value: today})
+		}
+		if book.FinishedAt == nil {
+			changes = append(changes, FrontmatterChange{Key: bookFinishedKeys[0], Value: today})
+		}
+	}
+	return w.EditFrontmatter(book.Path, "set book status", changes...)
+}
+
+// SetGoalProgress sets the progress of a goal note, from 0 to 1. It is
+// written the way the note already has it, as a fraction or a percentage;
+// new progress is a fraction. Goals defined by headings take their
+// progress from their tasks and cannot be set.
+func (w *Writer) SetGoalProgress(goal *types.Goal, progress float64) error {
+	if goal.Line > 0 {
+		return fmt.Errorf("the progress of %q comes from its tasks", goal.Title)
+	}
+	progress = math.Max(0, math.Min(1, progress))
+
+	var value interface{} = math.Round(progress*100) / 100
+	file, err := w.parser.ParseFile(goal.Path)
+	if err != nil && !IsFrontmatterError(err) {
+		return err
+	}
+	// Keep percentages, except 1%, which would read back as a fraction
+	if old, ok := FrontmatterFloat(file.Frontmatter, "progress"); ok && old > 1 && progress > 0.01 {
+		value = int(math.Round(progress * 100))
+	}
+	return w.EditFrontmatter(goal.Path, "set goal progress", FrontmatterChange{Key: "progress", Value: value})
+}
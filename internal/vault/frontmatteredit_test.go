@@ -0,0 +1,165 @@
+package vault
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEditFrontmatter(t *testing.T) {
+	tests := []struct {
+		name   string
+		note   string
+		change FrontmatterChange
+		want   string
+	}{
+		{
+			name:   "double quotes and comment kept",
+			note:   "---\ntitle: \"Old\" # shown in lists\nrating: 3\n---\nbody",
+			change: FrontmatterChange{Key: "title", Value: "New"},
+			want:   "---\ntitle: \"New\" # shown in lists\nrating: 3\n---\nbody",
+		},
+		{
+			name:   "single quotes kept",
+			note:   "---\nstatus: 'to read'\n---\n",
+			change: FrontmatterChange{Key: "status", Value: "it's done"},
+			want:   "---\nstatus: 'it''s done'\n---\n",
+		},
+		{
+			name:   "plain scalar and spacing kept",
+			note:   "---\npage:   10   # of 300\n---\n",
+			change: FrontmatterChange{Key: "page", Value: 42},
+			want:   "---\npage:   42   # of 300\n---\n",
+		},
+		{
+			name:   "plain value that needs quotes",
+			note:   "---\nstatus: reading\n---\n",
+			change: FrontmatterChange{Key: "status", Value: "a: b"},
+			want:   "---\nstatus: 'a: b'\n---\n",
+		},
+		{
+			name:   "date",
+			note:   "---\nfinished:\n---\n",
+			change: FrontmatterChange{Key: "finished", Value: time.Date(2026, 3, 5, 0, 0, 0, 0, time.Local)},
+			want:   "---\nfinished: 2026-03-05\n---\n",
+		},
+		{
+			name:   "new key goes last",
+			note:   "---\n# about\ntitle: x\n\n---\nbody",
+			change: FrontmatterChange{Key: "page", Value: 1},
+			want:   "---\n# about\ntitle: x\n\npage: 1\n---\nbody",
+		},
+		{
+			name:   "new frontmatter",
+			note:   "# Heading\n",
+			change: FrontmatterChange{Key: "tags", Value: "book", Append: true},
+			want:   "---\ntags:\n  - book\n---\n# Heading\n",
+		},
+		{
+			name:   "block list replaced in its indentation",
+			note:   "---\ntags:\n- a # first\n- b\nnext: 1\n---\n",
+			change: FrontmatterChange{Key: "tags", Value: []string{"c", "d"}},
+			want:   "---\ntags:\n- c\n- d\nnext: 1\n---\n",
+		},
+		{
+			name:   "flow list replaced on one line",
+			note:   "---\ntags: [a, b]\n---\n",
+			change: FrontmatterChange{Key: "tags", Value: []string{"c"}},
+			want:   "---\ntags: [c]\n---\n",
+		},
+		{
+			name:   "scalar replaced by a list keeps a quoted key",
+			note:   "---\n\"my key\": one\n---\n",
+			change: FrontmatterChange{Key: "my key", Value: []string{"a", "b"}},
+			want:   "---\n\"my key\":\n  - a\n  - b\n---\n",
+		},
+		{
+			name:   "appended to a block list",
+			note:   "---\ntags:\n    - a\n    - b\n# people\nauthor: x\n---\n",
+			change: FrontmatterChange{Key: "tags", Value: "c", Append: true},
+			want:   "---\ntags:\n    - a\n    - b\n    - c\n# people\nauthor: x\n---\n",
+		},
+		{
+			name:   "appended to a flow list with a comment",
+			note:   "---\ntags: [a, \"b c\"] # topics\n---\n",
+			change: FrontmatterChange{Key: "tags", Value: "d", Append: true},
+			want:   "---\ntags: [a, \"b c\", d] # topics\n---\n",
+		},
+		{
+			name:   "single value turned into a list",
+			note:   "---\naliases: one\n---\n",
+			change: FrontmatterChange{Key: "aliases", Value: "two", Append: true},
+			want:   "---\naliases:\n  - one\n  - two\n---\n",
+		},
+		{
+			name:   "empty value turned into a list",
+			note:   "---\naliases:\n---\n",
+			change: FrontmatterChange{Key: "aliases", Value: "one", Append: true},
+			want:   "---\naliases:\n  - one\n---\n",
+		},
+		{
+			name:   "removed with its lines but not the next key's comment",
+			note:   "---\ntitle: x\ntags:\n  - a\n  - b\n\n# when\ndate: 2026-01-01\n---\n",
+			change: FrontmatterChange{Key: "tags", Remove: true},
+			want:   "---\ntitle: x\n\n# when\ndate: 2026-01-01\n---\n",
+		},
+		{
+			name:   "removing a missing key",
+			note:   "---\ntitle: x # keep\n---\n",
+			change: FrontmatterChange{Key: "tags", Remove: true},
+			want:   "---\ntitle: x # keep\n---\n",
+		},
+		{
+			name:   "removing from a note without frontmatter",
+			note:   "text\n",
+			change: FrontmatterChange{Key: "tags", Remove: true},
+			want:   "text\n",
+		},
+	}
+	for _, tt := range tests {
+		doc := &document{path: "note.md", lines: strings.Split(tt.note, "\n")}
+		if err := editFrontmatter(doc, tt.change); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got := strings.Join(doc.lines, "\n"); got != tt.want {
+			t.Errorf("%s:\ngot  %q\nwant %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEditFrontmatterLeavesMalformedNotes(t *testing.T) {
+	dir, w := newJournaledWriter(t)
+	for name, note := range map[string]string{
+		"unterminated.md": "---\ntitle: x\n\nbody\n",
+		"invalid.md":      "---\ntitle: [x\n---\nbody\n",
+	} {
+		path := filepath.Join(dir, name)
+		writeNote(t, path, note)
+		if err := w.SetFrontmatter(path, "title", "y"); err == nil {
+			t.Errorf("%s: SetFrontmatter succeeded", name)
+		}
+		checkNote(t, path, note)
+	}
+}
+
+func TestEditFrontmatterIsOneEdit(t *testing.T) {
+	dir, w := newJournaledWriter(t)
+	path := filepath.Join(dir, "book.md")
+	note := "---\r\nstatus: \"reading\"\r\npage: 10\r\n---\r\nNotes\r\n"
+	writeNote(t, path, note)
+
+	err := w.EditFrontmatter(path, "finish book",
+		FrontmatterChange{Key: "status", Value: "finished"},
+		FrontmatterChange{Key: "page", Remove: true},
+		FrontmatterChange{Key: "tags", Value: "read", Append: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkNote(t, path, "---\r\nstatus: \"finished\"\r\ntags:\r\n  - read\r\n---\r\nNotes\r\n")
+
+	// The changes are undone together
+	replay(t, w.Undo, 1)
+	checkNote(t, path, note)
+}
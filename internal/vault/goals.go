@@ -0,0 +1,303 @@
+package vault
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/BioWare/lazyobsidian/internal/periodic"
+	"github.com/BioWare/lazyobsidian/pkg/types"
+)
+
+// planNamePattern matches plan note names such as "2026", "2026-Q2",
+// "2026-03" or "2026-W11", optionally followed by a space and more text.
+var planNamePattern = regexp.MustCompile(`(?i)^\d{4}(?:-(Q[1-4]|W\d{1,2}|\d{2}))?(?:\s.*)?$`)
+
+// goalPeriod returns the planning period of a goal note, from its
+// "period" property or, failing that, its file name.
+func goalPeriod(file *types.File, props map[string]interface{}) types.GoalPeriod {
+	if period, ok := FrontmatterString(props, "period"); ok {
+		switch strings.ToLower(strings.TrimSpace(period)) {
+		case "year", "yearly", "annual":
+			return types.GoalPeriodYear
+		case "quarter", "quarterly":
+			return types.GoalPeriodQuarter
+		case "month", "monthly":
+			return types.GoalPeriodMonth
+		case "week", "weekly":
+			return types.GoalPeriodWeek
+		}
+	}
+
+	name := strings.TrimSuffix(filepath.Base(file.Path), ".md")
+	m := planNamePattern.FindStringSubmatch(name)
+	switch {
+	case m == nil:
+		return types.GoalPeriodNone
+	case m[1] == "":
+		return types.GoalPeriodYear
+	case m[1][0] == 'Q' || m[1][0] == 'q':
+		return types.GoalPeriodQuarter
+	case m[1][0] == 'W' || m[1][0] == 'w':
+		return types.GoalPeriodWeek
+	default:
+		return types.GoalPeriodMonth
+	}
+}
+
+// periodRank orders periods from broadest to narrowest; 0 means none.
+func periodRank(period types.GoalPeriod) int {
+	switch period {
+	case types.GoalPeriodYear:
+		return 1
+	case types.GoalPeriodQuarter:
+		return 2
+	case types.GoalPeriodMonth:
+		return 3
+	case types.GoalPeriodWeek:
+		return 4
+	}
+	return 0
+}
+
+// goalFromFile builds the goal a note describes.
+func goalFromFile(file *types.File) types.Goal {
+	goal := types.Goal{
+		Path:  file.Path,
+		Title: file.Title,
+	}
+
+	// Extract description from frontmatter and inline fields
+	props := Properties(file)
+	if desc, ok := FrontmatterString(props, "description"); ok {
+		goal.Description = desc
+	}
+	if progress, ok := FrontmatterFloat(props, "progress"); ok {
+		// Accept both fractions (0.4) and percentages (40)
+		if progress > 1 {
+			progress /= 100
+		}
+		goal.Progress = progress
+	}
+	if due, ok := FrontmatterDate(props, "due"); ok {
+		goal.DueDate = &due
+	}
+	goal.Period = goalPeriod(file, props)
+
+	// Calculate progress from tasks if not set
+	if goal.Progress == 0 {
+		goal.Progress = taskProgress(file.Tasks)
+	}
+	goal.OwnPomodoros = CountPomodoros(file.Tasks)
+	return goal
+}
+
+// taskProgress returns the fraction of tasks done, or 0 without tasks.
+func taskProgress(tasks []types.Task) float64 {
+	completed, total := CountTasks(tasks)
+	if total == 0 {
+		return 0
+	}
+	return float64(completed) / float64(total)
+}
+
+// headingGoals returns the sub-goals a note defines with headings. A
+// section becomes a goal when it contains tasks and has a sibling section
+// that does too; a lone section, such as the note's title heading or a
+// single "Tasks" heading, is looked through instead. Goals are numbered
+// from *nextID and nested with ParentID.
+func headingGoals(file *types.File, headings []types.Heading, parentID int64, nextID *int64) []types.Goal {
+	var sections []types.Heading
+	for _, h := range headings {
+		if len(SectionTasks(file.Tasks, &h)) > 0 {
+			sections = append(sections, h)
+		}
+	}
+	if len(sections) == 1 {
+		return headingGoals(file, sections[0].Children, parentID, nextID)
+	}
+
+	var result []types.Goal
+	for i := range sections {
+		h := &sections[i]
+		id := *nextID
+		*nextID++
+		parent := parentID
+		result = append(result, types.Goal{
+			ID:           id,
+			Path:         file.Path,
+			Line:         h.Line,
+			Title:        h.Text,
+			Progress:     taskProgress(SectionTasks(file.Tasks, h)),
+			OwnPomodoros: CountPomodoros(SectionTasks(file.Tasks, h)),
+			ParentID:     &parent,
+		})
+		result = append(result, headingGoals(file, h.Children, id, nextID)...)
+	}
+	return result
+}
+
+// ownPomodoros takes the pomodoros of a note's sub-goals out of those of
+// the goals they are under, which count every task they cover, so that
+// each task counts once when goals are rolled up.
+func ownPomodoros(goals []types.Goal) {
+	covered := make(map[int64]int, len(goals))
+	for _, g := range goals {
+		covered[g.ID] = g.OwnPomodoros
+	}
+	for _, g := range goals {
+		if g.ParentID == nil {
+			continue
+		}
+		for i := range goals {
+			if goals[i].ID == *g.ParentID {
+				goals[i].OwnPomodoros -= covered[g.ID]
+			}
+		}
+	}
+}
+
+// parentLinks returns the links in a note's "parent" property. Plain
+// names are accepted as well as wikilinks, including the unquoted
+// [[Note]] that YAML reads as a nested list.
+func parentLinks(props map[string]interface{}) []types.Link {
+	var links []types.Link
+	for _, value := range FrontmatterStrings(props, "parent") {
+		if found := extractLinks(value, 0); len(found) > 0 {
+			links = append(links, found...)
+			continue
+		}
+		target := strings.TrimSpace(strings.Trim(value, "[]"))
+		if target != "" {
+			links = append(links, types.Link{Type: types.LinkTypeWikilink, Target: target})
+		}
+	}
+	return links
+}
+
+// buildGoals turns goal notes into a flat list of goals linked by
+// ParentID. A note's parent comes from its "parent" property; without
+// one, a link between plan notes of different periods makes the broader
+// note the parent of the narrower, preferring the closest period. Periodic
+// notes left without a parent go under the closest broader periodic note
+// whose period contains theirs. Sub-goals from headings follow the note
+// they belong to.
+//
+// periodOf recognises periodic notes by path; it may be nil.
+func buildGoals(vaultPath string, files []*types.File, periodOf func(path string) (types.GoalPeriod, time.Time, bool)) []types.Goal {
+	resolver := NewLinkResolver(vaultPath)
+	for _, f := range files {
+		resolver.AddFile(f)
+	}
+
+	var goals []types.Goal
+	var nextID int64 = 1
+	byPath := make(map[string]int) // note path -> index of its goal
+	for _, f := range files {
+		goal := goalFromFile(f)
+		if periodOf != nil {
+			if period, start, ok := periodOf(f.Path); ok {
+				goal.Period = period
+				goal.PeriodStart = &start
+			}
+		}
+		goal.ID = nextID
+		nextID++
+		byPath[f.Path] = len(goals)
+		noteGoals := append([]types.Goal{goal}, headingGoals(f, f.Headings, goal.ID, &nextID)...)
+		ownPomodoros(noteGoals)
+		goals = append(goals, noteGoals...)
+	}
+
+	resolve := func(source string, link types.Link) (int, bool) {
+		resolver.Resolve(source, &link)
+		if link.Status != types.LinkResolved || link.TargetPath == source {
+			return 0, false
+		}
+		i, ok := byPath[link.TargetPath]
+		return i, ok
+	}
+
+	// Explicit parents
+	for _, f := range files {
+		child := byPath[f.Path]
+		for _, link := range parentLinks(Properties(f)) {
+			if parent, ok := resolve(f.Path, link); ok {
+				id := goals[parent].ID
+				goals[child].ParentID = &id
+				break
+			}
+		}
+	}
+
+	// Links between plan notes, in either direction
+	best := make(map[int]int) // narrower goal -> broader goal
+	consider := func(a, b int) {
+		ra, rb := periodRank(goals[a].Period), periodRank(goals[b].Period)
+		if ra == 0 || rb == 0 || ra == rb {
+			return
+		}
+		narrow, broad := a, b
+		if ra < rb {
+			narrow, broad = b, a
+		}
+		if goals[narrow].ParentID != nil {
+			return
+		}
+		current, ok := best[narrow]
+		if !ok || periodRank(goals[broad].Period) > periodRank(goals[current].Period) {
+			best[narrow] = broad
+		}
+	}
+	for _, f := range files {
+		for _, link := range f.Links {
+			if target, ok := resolve(f.Path, link); ok {
+				consider(byPath[f.Path], target)
+			}
+		}
+	}
+	for narrow, broad := range best {
+		id := goals[broad].ID
+		goals[narrow].ParentID = &id
+	}
+
+	// Periodic notes nest by date
+	for i := range goals {
+		if goals[i].PeriodStart == nil || goals[i].ParentID != nil {
+			continue
+		}
+		if parent, ok := containingPeriod(goals, i); ok {
+			id := goals[parent].ID
+			goals[i].ParentID = &id
+		}
+	}
+
+	return goals
+}
+
+// containingPeriod returns the narrowest periodic goal broader than
+// goals[i] whose period contains it. A week counts as part of the month,
+// quarter and year holding its Thursday, as in ISO week numbering.
+func containingPeriod(goals []types.Goal, i int) (int, bool) {
+	day := *goals[i].PeriodStart
+	if goals[i].Period == types.GoalPeriodWeek {
+		day = day.AddDate(0, 0, 3)
+	}
+	rank := periodRank(goals[i].Period)
+
+	best, found := 0, false
+	for j := range goals {
+		r := periodRank(goals[j].Period)
+		if goals[j].PeriodStart == nil || r == 0 || r >= rank {
+			continue
+		}
+		if !periodic.Contains(goals[j].Period, *goals[j].PeriodStart, day) {
+			continue
+		}
+		if !found || r > periodRank(goals[best].Period) {
+			best, found = j, true
+		}
+	}
+	return best, found
+}
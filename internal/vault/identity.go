@@ -0,0 +1,105 @@
+package vault
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/BioWare/lazyobsidian/internal/logging"
+	"github.com/BioWare/lazyobsidian/pkg/types"
+)
+
+// TaskKey returns what identifies a task across edits to its note: its
+// block ID if it has one, otherwise its fingerprint. Neither depends on
+// the task's line, status or Tasks plugin metadata, so a task keeps its
+// key when lines are added above it or it is completed.
+func TaskKey(task *types.Task) string {
+	if task.BlockID != "" {
+		return "^" + task.BlockID
+	}
+	return task.Fingerprint
+}
+
+// setFingerprints fills in Fingerprint for tasks and their subtasks.
+// ancestors holds the texts of the enclosing tasks.
+func setFingerprints(tasks []types.Task, ancestors []string) {
+	for i := range tasks {
+		t := &tasks[i]
+		t.Fingerprint = taskFingerprint(t.Section, ancestors, t.Text)
+		setFingerprints(t.Subtasks, append(ancestors[:len(ancestors):len(ancestors)], t.Text))
+	}
+}
+
+// taskFingerprint hashes a task's heading, the texts of its parent tasks
+// and its own text.
+func taskFingerprint(section string, ancestors []string, text string) string {
+	h := sha256.New()
+	h.Write([]byte(section))
+	for _, a := range ancestors {
+		h.Write([]byte{0})
+		h.Write([]byte(a))
+	}
+	h.Write([]byte{0})
+	h.Write([]byte(text))
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// locateTask finds a task's line in doc by its identity and returns its
+// 0-indexed position, updating task.Line if the task moved. It returns a
+// *ConflictError if the task is gone or cannot be told apart from
+// another task with the same identity.
+func (w *Writer) locateTask(doc *document, task *types.Task) (int, error) {
+	key := TaskKey(task)
+	if key == "" {
+		// A task built by hand rather than parsed has only its line
+		return w.taskAtLine(doc, task)
+	}
+
+	var matches []types.Task
+	for _, t := range FlattenTasks(doc.parsed.Tasks) {
+		if TaskKey(&t) == key {
+			matches = append(matches, t)
+		}
+	}
+
+	var found *types.Task
+	switch {
+	case len(matches) == 1:
+		found = &matches[0]
+	case len(matches) > 1 && !doc.changed:
+		// Identical tasks can only be told apart by line, which holds as
+		// long as the note is as it was parsed
+		for i := range matches {
+			if matches[i].Line == task.Line {
+				found = &matches[i]
+			}
+		}
+	}
+	if found == nil {
+		if len(matches) == 0 {
+			return 0, &ConflictError{Path: doc.path, Msg: fmt.Sprintf("task %q is no longer in the note", task.Text)}
+		}
+		return 0, &ConflictError{Path: doc.path, Msg: fmt.Sprintf("task %q appears %d times", task.Text, len(matches))}
+	}
+
+	if found.Line != task.Line {
+		logging.Debug("Task %q moved from line %d to %d", task.Text, task.Line, found.Line)
+		task.Line = found.Line
+	}
+	return found.Line - 1, nil
+}
+
+// taskAtLine returns the 0-indexed position of a task known only by its
+// line, checking that the line still holds a task with its text.
+func (w *Writer) taskAtLine(doc *document, task *types.Task) (int, error) {
+	if task.Line < 1 || task.Line > len(doc.lines) {
+		return 0, fmt.Errorf("invalid line number: %d (file has %d lines)", task.Line, len(doc.lines))
+	}
+	for _, t := range FlattenTasks(doc.parsed.Tasks) {
+		if t.Line == task.Line && (task.Text == "" || strings.TrimSpace(t.Text) == strings.TrimSpace(task.Text)) {
+			return task.Line - 1, nil
+		}
+	}
+	return 0, &ConflictError{Path: doc.path, Msg: fmt.Sprintf("line %d no longer holds task %q", task.Line, task.Text)}
+}
@@ -0,0 +1,407 @@
+package vault
+
+import (
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/BioWare/lazyobsidian/internal/ignore"
+	"github.com/BioWare/lazyobsidian/internal/logging"
+	"github.com/BioWare/lazyobsidian/pkg/types"
+)
+
+var (
+	// urlSchemePattern matches external targets such as https:, mailto: or obsidian:
+	urlSchemePattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.\-]*:`)
+	// linkTitlePattern matches an optional title after a markdown link target
+	linkTitlePattern = regexp.MustCompile(`\s+(?:"[^"]*"|'[^']*')\s*$`)
+)
+
+// extractLinks returns every internal link on a line.
+// Embeds are recognised by a leading "!" on either link syntax.
+func extractLinks(line string, lineNum int) []types.Link {
+	var links []types.Link
+
+	for _, m := range wikilinkPattern.FindAllStringSubmatchIndex(line, -1) {
+		link := types.Link{
+			Type:   types.LinkTypeWikilink,
+			Line:   lineNum,
+			Status: types.LinkUnresolved,
+		}
+		if m[3] > m[2] {
+			link.Type = types.LinkTypeEmbed
+		}
+		splitLinkFragment(&link, strings.TrimSpace(line[m[4]:m[5]]))
+		if m[6] >= 0 {
+			link.Display = strings.TrimSpace(line[m[6]:m[7]])
+		}
+		links = append(links, link)
+	}
+
+	for _, m := range markdownLinkPattern.FindAllStringSubmatch(line, -1) {
+		target := strings.TrimSpace(m[3])
+		target = linkTitlePattern.ReplaceAllString(target, "")
+		target = strings.TrimSuffix(strings.TrimPrefix(target, "<"), ">")
+		if target == "" || urlSchemePattern.MatchString(target) {
+			continue // Skip external links
+		}
+		if decoded, err := url.PathUnescape(target); err == nil {
+			target = decoded
+		}
+
+		link := types.Link{
+			Type:    types.LinkTypeMarkdown,
+			Display: m[2],
+			Line:    lineNum,
+			Status:  types.LinkUnresolved,
+		}
+		if m[1] == "!" {
+			link.Type = types.LinkTypeEmbed
+		}
+		splitLinkFragment(&link, target)
+		links = append(links, link)
+	}
+
+	return links
+}
+
+// splitLinkFragment splits "note#heading" or "note#^block" into the
+// link's Target, Heading and Block.
+func splitLinkFragment(link *types.Link, target string) {
+	idx := strings.Index(target, "#")
+	if idx == -1 {
+		link.Target = target
+		return
+	}
+
+	link.Target = strings.TrimSpace(target[:idx])
+	fragment := strings.TrimSpace(target[idx+1:])
+	if strings.HasPrefix(fragment, "^") {
+		link.Block = fragment[1:]
+	} else {
+		link.Heading = fragment
+	}
+}
+
+// LinkResolver maps link targets to files in the vault following
+// Obsidian's rules: exact vault paths, paths relative to the linking note,
+// the shortest unique path (a bare file name or a trailing path suffix)
+// and frontmatter aliases. Matching is case-insensitive.
+//
+// A resolver is safe for concurrent use.
+type LinkResolver struct {
+	vaultPath string
+	ignore    *ignore.Matcher // files skipped by IndexNotes and IndexAttachments
+
+	mu          sync.RWMutex
+	paths       map[string]string   // lower-case vault-relative path -> file path
+	names       map[string][]string // lower-case file name -> file paths
+	aliases     map[string][]string // lower-case alias -> file paths
+	fileAliases map[string][]string
+}
+
+// NewLinkResolver creates an empty resolver for the vault.
+func NewLinkResolver(vaultPath string) *LinkResolver {
+	return &LinkResolver{
+		vaultPath:   vaultPath,
+		paths:       make(map[string]string),
+		names:       make(map[string][]string),
+		aliases:     make(map[string][]string),
+		fileAliases: make(map[string][]string),
+	}
+}
+
+// NewLinkResolver creates an empty resolver for the vault that skips the
+// parser's ignored files when indexing.
+func (p *Parser) NewLinkResolver() *LinkResolver {
+	r := NewLinkResolver(p.vaultPath)
+	r.ignore = p.ignore
+	return r
+}
+
+// LinkResolver returns the parser's shared link resolver, which the
+// indexer keeps up to date as notes are parsed, added and deleted.
+func (p *Parser) LinkResolver() *LinkResolver {
+	return p.links
+}
+
+// noteLinks returns the shared link resolver, first registering every
+// note in the vault by path, once, so that links resolve before the
+// indexer has seen every note.
+func (p *Parser) noteLinks() *LinkResolver {
+	p.linksIndexed.Do(func() {
+		if err := p.links.IndexNotes(); err != nil {
+			logging.Warn("Failed to index notes for links: %v", err)
+		}
+	})
+	return p.links
+}
+
+// ResolveNote returns the note a wikilink to name, written in the note at
+// from, points at.
+func (p *Parser) ResolveNote(from, name string) (string, bool) {
+	link := types.Link{Type: types.LinkTypeWikilink, Target: name}
+	p.noteLinks().Resolve(from, &link)
+	return link.TargetPath, link.Status == types.LinkResolved
+}
+
+// NoteLink returns a wikilink to a note: by its name, as Obsidian writes
+// links, or by its path in the vault when the name is not unique.
+func (p *Parser) NoteLink(notePath string) string {
+	name := strings.TrimSuffix(filepath.Base(notePath), ".md")
+	if target, ok := p.ResolveNote("", name); ok && target == notePath {
+		return "[[" + name + "]]"
+	}
+	return "[[" + strings.TrimSuffix(p.links.relPath(notePath), ".md") + "]]"
+}
+
+// IndexAttachments registers every non-markdown file in the vault so that
+// embeds of images, PDFs and other attachments resolve.
+func (r *LinkResolver) IndexAttachments() error {
+	return r.indexFiles(func(p string) bool { return !strings.HasSuffix(p, ".md") })
+}
+
+// IndexNotes registers every markdown file in the vault by path alone,
+// for resolving links without parsing the whole vault. Aliases are only
+// known for notes added with AddFile, and notes already registered keep
+// theirs.
+func (r *LinkResolver) IndexNotes() error {
+	return r.indexFiles(func(p string) bool { return strings.HasSuffix(p, ".md") })
+}
+
+// indexFiles registers the vault files accepted by keep.
+func (r *LinkResolver) indexFiles(keep func(path string) bool) error {
+	return r.ignore.Walk(r.vaultPath, func(p string, _ os.FileInfo) error {
+		if keep(p) && !r.has(p) {
+			r.Add(p, nil)
+		}
+		return nil
+	})
+}
+
+// AddFile registers a parsed note together with its frontmatter aliases.
+func (r *LinkResolver) AddFile(file *types.File) {
+	r.Add(file.Path, FileAliases(file))
+}
+
+// Add registers a file as a link target. Adding a file again replaces
+// its aliases.
+func (r *LinkResolver) Add(filePath string, aliases []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.removeLocked(filePath)
+
+	for _, key := range r.pathKeys(filePath) {
+		r.paths[key] = filePath
+	}
+	for _, key := range nameKeys(filePath) {
+		r.names[key] = append(r.names[key], filePath)
+	}
+	for _, alias := range aliases {
+		key := strings.ToLower(strings.TrimSpace(alias))
+		if key == "" {
+			continue
+		}
+		r.aliases[key] = append(r.aliases[key], filePath)
+	}
+	if len(aliases) > 0 {
+		r.fileAliases[filePath] = aliases
+	}
+}
+
+// has reports whether a file is registered.
+func (r *LinkResolver) has(filePath string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.paths[r.pathKeys(filePath)[0]] == filePath
+}
+
+// Remove unregisters a file, for example after it was deleted.
+func (r *LinkResolver) Remove(filePath string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.removeLocked(filePath)
+}
+
+func (r *LinkResolver) removeLocked(filePath string) {
+	for _, key := range r.pathKeys(filePath) {
+		if r.paths[key] == filePath {
+			delete(r.paths, key)
+		}
+	}
+	for _, key := range nameKeys(filePath) {
+		r.names[key] = removePath(r.names[key], filePath)
+		if len(r.names[key]) == 0 {
+			delete(r.names, key)
+		}
+	}
+	for _, alias := range r.fileAliases[filePath] {
+		key := strings.ToLower(strings.TrimSpace(alias))
+		r.aliases[key] = removePath(r.aliases[key], filePath)
+		if len(r.aliases[key]) == 0 {
+			delete(r.aliases, key)
+		}
+	}
+	delete(r.fileAliases, filePath)
+}
+
+// ResolveLinks resolves every link in file.
+func (r *LinkResolver) ResolveLinks(file *types.File) {
+	for i := range file.Links {
+		r.Resolve(file.Path, &file.Links[i])
+	}
+}
+
+// Resolve sets link.TargetPath and link.Status for a link found in the
+// note at sourcePath.
+func (r *LinkResolver) Resolve(sourcePath string, link *types.Link) {
+	link.TargetPath = ""
+	link.Status = types.LinkUnresolved
+
+	// [[#heading]] and [[#^block]] point into the linking note itself
+	if link.Target == "" {
+		if link.Heading != "" || link.Block != "" {
+			link.TargetPath = sourcePath
+			link.Status = types.LinkResolved
+		}
+		return
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	target := strings.ToLower(filepath.ToSlash(link.Target))
+	sourceDir := path.Dir(strings.ToLower(r.relPath(sourcePath)))
+	explicitlyRelative := strings.HasPrefix(target, "./") || strings.HasPrefix(target, "../")
+
+	// Markdown links and explicit ./ or ../ paths are relative to the note
+	if link.Type == types.LinkTypeMarkdown || explicitlyRelative {
+		if p, ok := r.paths[path.Join(sourceDir, target)]; ok {
+			r.resolved(link, p)
+			return
+		}
+	}
+
+	// Exact vault path, then a path relative to the note
+	if p, ok := r.paths[strings.TrimPrefix(target, "/")]; ok {
+		r.resolved(link, p)
+		return
+	}
+	if p, ok := r.paths[path.Join(sourceDir, target)]; ok {
+		r.resolved(link, p)
+		return
+	}
+
+	// Shortest path: a bare name, or a suffix of the full path
+	var candidates []string
+	if strings.Contains(target, "/") {
+		suffix := "/" + strings.TrimPrefix(path.Clean(target), "/")
+		for key, p := range r.paths {
+			if strings.HasSuffix(key, suffix) && !containsString(candidates, p) {
+				candidates = append(candidates, p)
+			}
+		}
+	} else {
+		candidates = r.names[target]
+	}
+
+	if len(candidates) == 0 {
+		candidates = r.aliases[strings.ToLower(strings.TrimSpace(link.Target))]
+	}
+
+	r.choose(link, sourcePath, candidates)
+}
+
+// choose picks the target among candidate files. A single candidate, or a
+// single one in the linking note's folder, resolves the link; otherwise
+// it is ambiguous and the shortest path is kept as the best guess.
+func (r *LinkResolver) choose(link *types.Link, sourcePath string, candidates []string) {
+	switch len(candidates) {
+	case 0:
+		return
+	case 1:
+		r.resolved(link, candidates[0])
+		return
+	}
+
+	sourceDir := filepath.Dir(sourcePath)
+	var sameDir []string
+	for _, c := range candidates {
+		if filepath.Dir(c) == sourceDir {
+			sameDir = append(sameDir, c)
+		}
+	}
+	if len(sameDir) == 1 {
+		r.resolved(link, sameDir[0])
+		return
+	}
+
+	sorted := append([]string(nil), candidates...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if len(sorted[i]) != len(sorted[j]) {
+			return len(sorted[i]) < len(sorted[j])
+		}
+		return sorted[i] < sorted[j]
+	})
+	link.TargetPath = sorted[0]
+	link.Status = types.LinkAmbiguous
+}
+
+func (r *LinkResolver) resolved(link *types.Link, filePath string) {
+	link.TargetPath = filePath
+	link.Status = types.LinkResolved
+}
+
+// relPath returns filePath relative to the vault, with forward slashes.
+func (r *LinkResolver) relPath(filePath string) string {
+	rel, err := filepath.Rel(r.vaultPath, filePath)
+	if err != nil {
+		rel = filePath
+	}
+	return filepath.ToSlash(rel)
+}
+
+// pathKeys returns the lookup keys for a file's vault path. Notes can be
+// linked with or without their ".md" extension.
+func (r *LinkResolver) pathKeys(filePath string) []string {
+	rel := strings.ToLower(r.relPath(filePath))
+	if strings.HasSuffix(rel, ".md") {
+		return []string{rel, strings.TrimSuffix(rel, ".md")}
+	}
+	return []string{rel}
+}
+
+// nameKeys returns the lookup keys for a file's name.
+func nameKeys(filePath string) []string {
+	name := strings.ToLower(filepath.Base(filePath))
+	if strings.HasSuffix(name, ".md") {
+		return []string{name, strings.TrimSuffix(name, ".md")}
+	}
+	return []string{name}
+}
+
+func removePath(paths []string, filePath string) []string {
+	result := paths[:0]
+	for _, p := range paths {
+		if p != filePath {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// FileAliases returns the frontmatter aliases of a note.
+// Both the current "aliases" key and the legacy "alias" key are read.
+func FileAliases(file *types.File) []string {
+	if file.Frontmatter == nil {
+		return nil
+	}
+	aliases := FrontmatterStrings(file.Frontmatter, "aliases")
+	return append(aliases, FrontmatterStrings(file.Frontmatter, "alias")...)
+}
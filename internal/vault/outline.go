@@ -0,0 +1,162 @@
+package vault
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/BioWare/lazyobsidian/pkg/types"
+)
+
+var (
+	// blockIDPattern matches a block reference ID at the end of a line
+	blockIDPattern = regexp.MustCompile(`(?:^|\s)\^([A-Za-z0-9-]+)\s*$`)
+	// closingHashesPattern matches optional closing hashes ("## Title ##")
+	closingHashesPattern = regexp.MustCompile(`\s+#+\s*$`)
+)
+
+// parseHeading parses an ATX heading line.
+func parseHeading(line string) (level int, text string, ok bool) {
+	m := headingPattern.FindStringSubmatch(line)
+	if m == nil {
+		return 0, "", false
+	}
+	text = closingHashesPattern.ReplaceAllString(m[2], "")
+	return len(m[1]), strings.TrimSpace(text), true
+}
+
+// outlineBuilder collects headings and block IDs line by line and
+// assembles them into a heading tree.
+type outlineBuilder struct {
+	flat []types.Heading
+}
+
+// heading records a heading found on line.
+func (b *outlineBuilder) heading(level int, text string, line int) {
+	b.flat = append(b.flat, types.Heading{Level: level, Text: text, Line: line})
+}
+
+// blockID records a block ID found on a line inside the current section.
+func (b *outlineBuilder) blockID(id string) {
+	if len(b.flat) == 0 {
+		return
+	}
+	current := &b.flat[len(b.flat)-1]
+	current.BlockIDs = append(current.BlockIDs, id)
+}
+
+// text records a non-heading line inside the current section.
+func (b *outlineBuilder) text(line string) {
+	if len(b.flat) == 0 || strings.TrimSpace(line) == "" {
+		return
+	}
+	b.flat[len(b.flat)-1].HasContent = true
+}
+
+// current returns the heading whose section the next line belongs to.
+func (b *outlineBuilder) current() *types.Heading {
+	if len(b.flat) == 0 {
+		return nil
+	}
+	return &b.flat[len(b.flat)-1]
+}
+
+// build sets each section's end line and nests the headings.
+// lastLine is the number of lines in the file.
+func (b *outlineBuilder) build(lastLine int) []types.Heading {
+	for i := range b.flat {
+		b.flat[i].EndLine = lastLine
+		for j := i + 1; j < len(b.flat); j++ {
+			if b.flat[j].Level <= b.flat[i].Level {
+				b.flat[i].EndLine = b.flat[j].Line - 1
+				break
+			}
+		}
+	}
+
+	roots, _ := nestHeadings(b.flat, 0, 0)
+	return roots
+}
+
+// nestHeadings turns flat[start:] into a tree, stopping at the first
+// heading at or above parentLevel. It returns the nodes and the index of
+// the first heading not consumed.
+func nestHeadings(flat []types.Heading, start, parentLevel int) ([]types.Heading, int) {
+	var nodes []types.Heading
+	i := start
+	for i < len(flat) && flat[i].Level > parentLevel {
+		node := flat[i]
+		node.Children, i = nestHeadings(flat, i+1, node.Level)
+		nodes = append(nodes, node)
+	}
+	return nodes, i
+}
+
+// ParseOutline builds the heading tree of a note from its lines.
+// Headings inside the frontmatter block, code blocks and comments are
+// ignored.
+func ParseOutline(lines []string) []types.Heading {
+	var b outlineBuilder
+	start := 0
+	if len(lines) > 0 && frontmatterStart.MatchString(lines[0]) {
+		for i := 1; i < len(lines); i++ {
+			if frontmatterStart.MatchString(lines[i]) {
+				start = i + 1
+				break
+			}
+		}
+	}
+
+	var tok tokenizer
+	for i := start; i < len(lines); i++ {
+		tl := tok.next(lines[i])
+		if tl.kind != lineText {
+			continue
+		}
+		if level, text, ok := parseHeading(tl.visible); ok {
+			b.heading(level, text, i+1)
+			continue
+		}
+		if m := blockIDPattern.FindStringSubmatch(tl.visible); m != nil {
+			b.blockID(m[1])
+		}
+		b.text(tl.visible)
+	}
+	return b.build(len(lines))
+}
+
+// FindHeading returns the first heading whose text matches text,
+// ignoring case and surrounding whitespace, searching depth first.
+func FindHeading(headings []types.Heading, text string) *types.Heading {
+	text = strings.TrimSpace(text)
+	for i := range headings {
+		if strings.EqualFold(headings[i].Text, text) {
+			return &headings[i]
+		}
+		if h := FindHeading(headings[i].Children, text); h != nil {
+			return h
+		}
+	}
+	return nil
+}
+
+// FlattenHeadings returns every heading in document order.
+func FlattenHeadings(headings []types.Heading) []types.Heading {
+	var result []types.Heading
+	for _, h := range headings {
+		result = append(result, h)
+		result = append(result, FlattenHeadings(h.Children)...)
+	}
+	return result
+}
+
+// SectionTasks returns the top-level tasks that lie within a heading's
+// section, including its subsections.
+func SectionTasks(tasks []types.Task, heading *types.Heading) []types.Task {
+	var result []types.Task
+	for _, task := range tasks {
+		if task.Line > heading.Line && task.Line <= heading.EndLine {
+			result = append(result, task)
+		}
+	}
+	return result
+}
@@ -3,26 +3,34 @@ package vault
 
 import (
 	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/BioWare/lazyobsidian/internal/config"
+	"github.com/BioWare/lazyobsidian/internal/ignore"
+	"github.com/BioWare/lazyobsidian/internal/logging"
+	"github.com/BioWare/lazyobsidian/internal/periodic"
 	"github.com/BioWare/lazyobsidian/pkg/types"
 )
 
 var (
 	// Regex patterns for parsing markdown
 	taskPattern       = regexp.MustCompile(`^(\s*)-\s*\[([ x\-/>?])\]\s*(.+)$`)
-	wikilinkPattern   = regexp.MustCompile(`\[\[([^\]|]+)(?:\|[^\]]+)?\]\]`)
-	markdownLinkPattern = regexp.MustCompile(`\[([^\]]+)\]\(([^)]+)\)`)
-	embedPattern      = regexp.MustCompile(`!\[\[([^\]]+)\]\]`)
+	wikilinkPattern   = regexp.MustCompile(`(!?)\[\[([^\]|]+)(?:\|([^\]]+))?\]\]`)
+	markdownLinkPattern = regexp.MustCompile(`(!?)\[([^\]]*)\]\(([^)]+)\)`)
 	frontmatterStart  = regexp.MustCompile(`^---\s*$`)
-	tagPattern        = regexp.MustCompile(`#([a-zA-Z0-9_/-]+)`)
+	tagPattern        = regexp.MustCompile(`(?:^|\s)#([\p{L}\p{N}_/\-]+)`)
+	numericTagPattern = regexp.MustCompile(`^[0-9/]+$`)
 	headingPattern    = regexp.MustCompile(`^(#{1,6})\s+(.+)$`)
-	pomodoroPattern   = regexp.MustCompile(`🍅`)
+	pomodoroPattern   = regexp.MustCompile(pomodoroMark)
 	schedulePattern   = regexp.MustCompile(`^-\s*(\d{1,2}:\d{2})(?:-(\d{1,2}:\d{2}))?\s*\|\s*(.+?)(?:\s*\|\s*(.+))?$`)
 )
 
@@ -30,14 +38,74 @@ var (
 type Parser struct {
 	vaultPath string
 	config    *config.Config
+	ignore    *ignore.Matcher
+
+	links        *LinkResolver // shared by everything resolving links
+	linksIndexed sync.Once
+
+	mu     sync.Mutex
+	hashes map[string]string // path -> content hash when last parsed or written
 }
 
-// NewParser creates a new vault parser.
+// NewParser creates a new vault parser. Files matched by the vault's
+// ignore rules are skipped when walking the vault.
 func NewParser(vaultPath string, cfg *config.Config) *Parser {
-	return &Parser{
+	matcher, err := ignore.New(vaultPath, cfg)
+	if err != nil {
+		logging.Warn("Failed to load ignore rules: %v", err)
+	}
+	p := &Parser{
 		vaultPath: vaultPath,
 		config:    cfg,
+		ignore:    matcher,
+		hashes:    make(map[string]string),
 	}
+	p.links = p.NewLinkResolver()
+	return p
+}
+
+// ParsedHash returns the content hash of a file when it was last parsed,
+// or written by a Writer, or "" if it was not.
+func (p *Parser) ParsedHash(path string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.hashes[path]
+}
+
+func (p *Parser) rememberHash(path, hash string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.hashes[path] = hash
+}
+
+// Ignore returns the vault's ignore rules.
+func (p *Parser) Ignore() *ignore.Matcher {
+	return p.ignore
+}
+
+// Ignored reports whether a vault path is excluded by the ignore rules.
+func (p *Parser) Ignored(path string, isDir bool) bool {
+	return p.ignore.Ignored(path, isDir)
+}
+
+// WalkNotes calls fn for every markdown file under root that is not
+// ignored. Unreadable files and folders are skipped; an error returned by
+// fn ends the walk.
+func (p *Parser) WalkNotes(root string, fn func(path string, info os.FileInfo) error) error {
+	return p.ignore.Walk(root, func(path string, info os.FileInfo) error {
+		if !strings.HasSuffix(path, ".md") {
+			return nil
+		}
+		return fn(path, info)
+	})
+}
+
+// walkNotes is WalkNotes for callers that only need the paths.
+func (p *Parser) walkNotes(root string, fn func(path string)) error {
+	return p.WalkNotes(root, func(path string, _ os.FileInfo) error {
+		fn(path)
+		return nil
+	})
 }
 
 // ParseFile parses a single markdown file.
@@ -53,30 +121,57 @@ func (p *Parser) ParseFile(path string) (*types.File, error) {
 		return nil, err
 	}
 
+	result, err := p.parse(path, file, info.ModTime(), info.Size())
+	if result != nil && result.Hash != "" {
+		p.rememberHash(path, result.Hash)
+	}
+	return result, err
+}
+
+// parse parses a note's content read from r.
+func (p *Parser) parse(path string, r io.Reader, modTime time.Time, size int64) (*types.File, error) {
 	result := &types.File{
 		Path:       path,
 		Title:      strings.TrimSuffix(filepath.Base(path), ".md"),
-		ModifiedAt: info.ModTime(),
+		ModifiedAt: modTime,
 		ParsedAt:   time.Now(),
+		Size:       size,
 		Tags:       []string{},
 		Tasks:      []types.Task{},
 		Links:      []types.Link{},
+		Fields:     make(map[string]interface{}),
 	}
 
-	scanner := bufio.NewScanner(file)
+	// Hash the content as it is read, for change detection
+	hasher := sha256.New()
+	scanner := bufio.NewScanner(io.TeeReader(r, hasher))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
 	lineNum := 0
 	inFrontmatter := false
 	frontmatterLineCount := 0
 	frontmatterLines := []string{}
+	var frontmatterErr error
 	var contentBuilder strings.Builder
 
 	// For task tree building
 	var taskStack []*types.Task
 	var allTasks []types.Task
 
+	// For the heading outline
+	var outline outlineBuilder
+
+	// Schedule lines, placed on the note's date once it is known
+	var schedule []scheduleEntry
+
+	// Block-level state: code fences, math blocks and comments
+	var tok tokenizer
+
 	for scanner.Scan() {
 		lineNum++
 		line := scanner.Text()
+		if lineNum == 1 {
+			line = strings.TrimPrefix(line, utf8BOM)
+		}
 
 		// Handle frontmatter
 		if lineNum == 1 && frontmatterStart.MatchString(line) {
@@ -89,17 +184,40 @@ func (p *Parser) ParseFile(path string) (*types.File, error) {
 			frontmatterLineCount++
 			if frontmatterStart.MatchString(line) && frontmatterLineCount > 1 {
 				inFrontmatter = false
-				result.Frontmatter = parseFrontmatter(frontmatterLines)
+				// Frontmatter body starts on line 2, right after the opening delimiter
+				result.Frontmatter, frontmatterErr = parseFrontmatter(frontmatterLines, 2)
 				continue
 			}
 			frontmatterLines = append(frontmatterLines, line)
 			continue
 		}
 
+		contentBuilder.WriteString(line)
+		contentBuilder.WriteString("\n")
+
+		// Nothing is extracted from code, math or comments. Structure is
+		// read from the visible text, tags, links and fields from prose,
+		// which also has inline code and math blanked out.
+		tl := tok.next(line)
+		if tl.kind != lineText {
+			continue
+		}
+		visible, prose := tl.visible, tl.prose
+
+		// Track headings and block IDs for the outline
+		if level, text, ok := parseHeading(visible); ok {
+			outline.heading(level, text, lineNum)
+		} else {
+			if m := blockIDPattern.FindStringSubmatch(visible); m != nil {
+				outline.blockID(m[1])
+			}
+			outline.text(visible)
+		}
+
 		// Parse tasks with indentation for subtasks
-		if matches := taskPattern.FindStringSubmatch(line); matches != nil {
-			indent := len(matches[1])
-			indentLevel := indent / 2 // Assuming 2 spaces per indent level
+		if matches := taskPattern.FindStringSubmatch(visible); matches != nil {
+			indent := len(strings.ReplaceAll(matches[1], "\t", "  "))
+			indentLevel := indent / 2 // Assuming 2 spaces (or a tab) per indent level
 
 			// Convert symbol to named status
 			statusSymbol := matches[2]
@@ -111,6 +229,16 @@ func (p *Parser) ParseFile(path string) (*types.File, error) {
 				Text:     matches[3],
 				Subtasks: []types.Task{},
 			}
+			if section := outline.current(); section != nil {
+				task.Section = section.Text
+				task.SectionLine = section.Line
+			}
+
+			// A block ID at the end of the line identifies the task
+			if m := blockIDPattern.FindStringSubmatchIndex(task.Text); m != nil {
+				task.BlockID = task.Text[m[2]:m[3]]
+				task.Text = strings.TrimSpace(task.Text[:m[0]])
+			}
 
 			// Check for inline comment
 			if idx := strings.Index(task.Text, " // "); idx != -1 {
@@ -125,13 +253,21 @@ func (p *Parser) ParseFile(path string) (*types.File, error) {
 				task.Text = strings.TrimSpace(task.Text)
 			}
 
-			// Count pomodoros in task text
-			pomodoroCount := len(pomodoroPattern.FindAllString(task.Text, -1))
-			if pomodoroCount > 0 {
+			// Count pomodoros in task text, before they can be taken for
+			// part of a metadata value such as a recurrence rule
+			task.Pomodoros = len(pomodoroPattern.FindAllString(task.Text, -1))
+			if task.Pomodoros > 0 {
 				// Remove pomodoros from text for cleaner display
 				task.Text = strings.TrimSpace(pomodoroPattern.ReplaceAllString(task.Text, ""))
 			}
 
+			// Extract Dataview inline fields; they also count as note fields
+			parseTaskFields(&task)
+			mergeFields(result.Fields, task.Fields)
+
+			// Extract Tasks plugin metadata (dates, priority, recurrence, dependencies)
+			parseTaskMetadata(&task)
+
 			// Build task tree
 			if indentLevel == 0 {
 				// Root level task
@@ -152,133 +288,171 @@ func (p *Parser) ParseFile(path string) (*types.File, error) {
 					taskStack = []*types.Task{&allTasks[len(allTasks)-1]}
 				}
 			}
+		} else if entry, ok := parseScheduleLine(visible, lineNum); ok {
+			schedule = append(schedule, entry)
+		} else {
+			// Dataview inline fields on regular lines
+			extractLineFields(prose, result.Fields)
 		}
 
-		// Extract wikilinks
-		for range wikilinkPattern.FindAllStringSubmatch(line, -1) {
-			result.Links = append(result.Links, types.Link{
-				Type: types.LinkTypeWikilink,
-				// TargetID will be resolved later when we have file IDs
-			})
-		}
-
-		// Extract markdown links
-		for _, match := range markdownLinkPattern.FindAllStringSubmatch(line, -1) {
-			if strings.HasPrefix(match[2], "http") {
-				continue // Skip external links
-			}
-			result.Links = append(result.Links, types.Link{
-				Type: types.LinkTypeMarkdown,
-			})
-		}
+		// Extract wikilinks, markdown links and embeds; targets are
+		// resolved later by a LinkResolver once the whole vault is known
+		result.Links = append(result.Links, extractLinks(prose, lineNum)...)
 
-		// Extract embeds
-		for range embedPattern.FindAllStringSubmatch(line, -1) {
-			result.Links = append(result.Links, types.Link{
-				Type: types.LinkTypeEmbed,
-			})
-		}
-
-		// Extract tags
-		for _, match := range tagPattern.FindAllStringSubmatch(line, -1) {
+		// Extract tags. A tag follows whitespace, so URL fragments and
+		// words like "C#" don't count, and needs a non-numeric character.
+		for _, match := range tagPattern.FindAllStringSubmatch(prose, -1) {
 			tag := match[1]
+			if numericTagPattern.MatchString(tag) {
+				continue
+			}
 			if !containsString(result.Tags, tag) {
 				result.Tags = append(result.Tags, tag)
 			}
 		}
+	}
 
-		contentBuilder.WriteString(line)
-		contentBuilder.WriteString("\n")
+	if err := scanner.Err(); err != nil {
+		return result, err
 	}
 
+	if inFrontmatter {
+		frontmatterErr = &FrontmatterError{Line: 1, Msg: "unterminated frontmatter block"}
+	}
+
+	result.Hash = hex.EncodeToString(hasher.Sum(nil))
+	setFingerprints(allTasks, nil)
 	result.Tasks = allTasks
+	result.Headings = outline.build(lineNum)
 	result.Content = contentBuilder.String()
 
 	// Determine file type based on path and frontmatter
 	result.Type = p.determineFileType(path, result.Frontmatter)
 
+	// Time blocks only make sense on a note for a specific day
+	if result.Type == types.FileTypeDaily && len(schedule) > 0 {
+		if date, ok := p.noteDate(path, result.Frontmatter); ok {
+			result.Schedule = buildSchedule(schedule, date)
+		}
+	}
+
 	// Extract title from frontmatter or first heading
 	if result.Frontmatter != nil {
-		if title, ok := result.Frontmatter["title"].(string); ok && title != "" {
+		if title, ok := FrontmatterString(result.Frontmatter, "title"); ok && title != "" {
 			result.Title = title
 		}
 	}
 
-	return result, scanner.Err()
+	// A malformed frontmatter block doesn't invalidate the rest of the note:
+	// the body is still returned alongside the error.
+	if fmErr, ok := frontmatterErr.(*FrontmatterError); ok {
+		fmErr.Path = path
+		return result, fmErr
+	}
+
+	return result, nil
 }
 
-// ParseVault parses all markdown files in the vault.
-func (p *Parser) ParseVault() ([]*types.File, error) {
-	var files []*types.File
+// HashFile returns the content hash ParseFile records in File.Hash.
+func HashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
 
-	err := filepath.Walk(p.vaultPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
 
-		// Skip directories and non-markdown files
-		if info.IsDir() {
-			// Skip hidden directories
-			if strings.HasPrefix(info.Name(), ".") && info.Name() != "." {
-				return filepath.SkipDir
-			}
-			return nil
-		}
+// IsFrontmatterError reports whether err only describes malformed
+// frontmatter, in which case the parsed file is still usable.
+func IsFrontmatterError(err error) bool {
+	var fmErr *FrontmatterError
+	return errors.As(err, &fmErr)
+}
 
-		if !strings.HasSuffix(path, ".md") {
-			return nil
-		}
+// ParseVault parses all markdown files in the vault that are not ignored.
+func (p *Parser) ParseVault() ([]*types.File, error) {
+	var files []*types.File
 
+	err := p.walkNotes(p.vaultPath, func(path string) {
 		file, err := p.ParseFile(path)
 		if err != nil {
 			// Log error but continue parsing other files
-			return nil
+			logging.Warn("Failed to parse %s: %v", path, err)
+			if !IsFrontmatterError(err) {
+				return
+			}
 		}
 
 		files = append(files, file)
-		return nil
 	})
 
 	return files, err
 }
 
-// ParseDailyNote parses a daily note file for the given date.
-func (p *Parser) ParseDailyNote(date time.Time) (*types.File, error) {
-	folder := p.config.Daily.Folder
-	format := p.config.Daily.FilenameFormat
-	if format == "" {
-		format = "2006-01-02"
+// DailyNotePath returns the path of the daily note for the given date,
+// whether or not it exists.
+func (p *Parser) DailyNotePath(date time.Time) string {
+	folder, format := p.dailyNoteFormat()
+	filename := periodic.Format(date, format) + ".md"
+	return filepath.Join(p.vaultPath, folder, filepath.FromSlash(filename))
+}
+
+// dailyNoteFormat returns the daily notes' folder and Moment.js format.
+func (p *Parser) dailyNoteFormat() (folder, format string) {
+	format = "YYYY-MM-DD"
+	if p.config != nil {
+		folder = p.config.Daily.Folder
+		if p.config.Daily.Format != "" {
+			format = p.config.Daily.Format
+		}
 	}
+	return folder, format
+}
 
-	filename := date.Format(format) + ".md"
-	path := filepath.Join(p.vaultPath, folder, filename)
+// dailyNoteDate returns the date a daily note is for, read from its path
+// in the daily notes folder, so that formats with folders such as
+// "YYYY/MM/YYYY-MM-DD" are read too. A note outside the folder, or whose
+// path doesn't match, is tried by its file name alone.
+func (p *Parser) dailyNoteDate(path string) (time.Time, bool) {
+	folder, format := p.dailyNoteFormat()
 
-	return p.ParseFile(path)
+	names := []string{filepath.Base(path)}
+	if rel, err := filepath.Rel(filepath.Join(p.vaultPath, folder), path); err == nil && !strings.HasPrefix(rel, "..") {
+		names = append([]string{filepath.ToSlash(rel)}, names...)
+	}
+	for _, name := range names {
+		if date, ok := periodic.Parse(format, strings.TrimSuffix(name, ".md"), time.Local); ok {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// ParseDailyNote parses a daily note file for the given date.
+func (p *Parser) ParseDailyNote(date time.Time) (*types.File, error) {
+	return p.ParseFile(p.DailyNotePath(date))
 }
 
 // DailyNoteExists checks if a daily note exists for the given date.
 func (p *Parser) DailyNoteExists(date time.Time) bool {
-	folder := p.config.Daily.Folder
-	format := p.config.Daily.FilenameFormat
-	if format == "" {
-		format = "2006-01-02"
-	}
-
-	filename := date.Format(format) + ".md"
-	path := filepath.Join(p.vaultPath, folder, filename)
-
-	_, err := os.Stat(path)
+	_, err := os.Stat(p.DailyNotePath(date))
 	return err == nil
 }
 
 func (p *Parser) determineFileType(path string, frontmatter map[string]interface{}) types.FileType {
 	// Check frontmatter type field first
 	if frontmatter != nil {
-		if t, ok := frontmatter["type"].(string); ok {
+		if t, ok := FrontmatterString(frontmatter, "type"); ok {
 			switch t {
 			case "daily":
 				return types.FileTypeDaily
-			case "goal", "yearly_plan", "monthly_plan", "weekly_plan":
+			case "goal", "yearly_plan", "quarterly_plan", "monthly_plan", "weekly_plan":
 				return types.FileTypeGoal
 			case "course":
 				return types.FileTypeCourse
@@ -288,23 +462,24 @@ func (p *Parser) determineFileType(path string, frontmatter map[string]interface
 		}
 	}
 
+	// Periodic notes are plans wherever they are kept
+	if _, _, ok := p.PeriodicNotePeriod(path); ok {
+		return types.FileTypeGoal
+	}
+
 	// Check path against configured folders
 	if p.config != nil {
 		relPath, err := filepath.Rel(p.vaultPath, path)
 		if err == nil {
-			parts := strings.Split(relPath, string(filepath.Separator))
-			if len(parts) > 0 {
-				folder := parts[0]
-				switch folder {
-				case p.config.Folders.Daily, p.config.Daily.Folder:
-					return types.FileTypeDaily
-				case p.config.Folders.Goals:
-					return types.FileTypeGoal
-				case p.config.Folders.Courses:
-					return types.FileTypeCourse
-				case p.config.Folders.Books:
-					return types.FileTypeBook
-				}
+			switch {
+			case inFolder(relPath, p.config.Folders.Daily), inFolder(relPath, p.config.Daily.Folder):
+				return types.FileTypeDaily
+			case inFolder(relPath, p.config.Folders.Goals):
+				return types.FileTypeGoal
+			case inFolder(relPath, p.config.Folders.Courses):
+				return types.FileTypeCourse
+			case inFolder(relPath, p.config.Folders.Books):
+				return types.FileTypeBook
 			}
 		}
 	}
@@ -312,77 +487,14 @@ func (p *Parser) determineFileType(path string, frontmatter map[string]interface
 	return types.FileTypeNote
 }
 
-func parseFrontmatter(lines []string) map[string]interface{} {
-	result := make(map[string]interface{})
-	var currentKey string
-	var inList bool
-	var listItems []string
-
-	for _, line := range lines {
-		// Check for list item
-		if strings.HasPrefix(strings.TrimSpace(line), "- ") && currentKey != "" {
-			inList = true
-			item := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "- "))
-			listItems = append(listItems, item)
-			continue
-		}
-
-		// If we were in a list, save it
-		if inList && !strings.HasPrefix(strings.TrimSpace(line), "- ") {
-			result[currentKey] = listItems
-			listItems = nil
-			inList = false
-		}
-
-		// Parse key: value
-		parts := strings.SplitN(line, ":", 2)
-		if len(parts) == 2 {
-			currentKey = strings.TrimSpace(parts[0])
-			value := strings.TrimSpace(parts[1])
-
-			if value == "" {
-				// Could be start of list or nested object
-				continue
-			}
-
-			// Handle quoted strings
-			if (strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"")) ||
-				(strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'")) {
-				value = value[1 : len(value)-1]
-			}
-
-			// Handle arrays like [item1, item2]
-			if strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]") {
-				inner := value[1 : len(value)-1]
-				items := strings.Split(inner, ",")
-				var cleanItems []string
-				for _, item := range items {
-					cleanItems = append(cleanItems, strings.TrimSpace(item))
-				}
-				result[currentKey] = cleanItems
-				continue
-			}
-
-			// Handle booleans
-			if value == "true" {
-				result[currentKey] = true
-				continue
-			}
-			if value == "false" {
-				result[currentKey] = false
-				continue
-			}
-
-			result[currentKey] = value
-		}
-	}
-
-	// Handle trailing list
-	if inList && currentKey != "" {
-		result[currentKey] = listItems
+// inFolder reports whether the vault-relative relPath lies inside folder,
+// which may be nested like "Input/Books". The vault root matches nothing.
+func inFolder(relPath, folder string) bool {
+	folder = strings.Trim(filepath.ToSlash(folder), "/")
+	if folder == "" {
+		return false
 	}
-
-	return result
+	return strings.HasPrefix(filepath.ToSlash(relPath), folder+"/")
 }
 
 func containsString(slice []string, str string) bool {
@@ -468,6 +580,15 @@ func CountTasks(tasks []types.Task) (completed, total int) {
 	return completed, total
 }
 
+// CountPomodoros returns the pomodoros run for tasks and their subtasks.
+func CountPomodoros(tasks []types.Task) int {
+	total := 0
+	for _, task := range tasks {
+		total += task.Pomodoros + CountPomodoros(task.Subtasks)
+	}
+	return total
+}
+
 // FlattenTasks flattens a task tree into a slice.
 func FlattenTasks(tasks []types.Task) []types.Task {
 	var result []types.Task
@@ -478,65 +599,50 @@ func FlattenTasks(tasks []types.Task) []types.Task {
 	return result
 }
 
-// ParseGoals parses goal files from the Goals folder.
+// ParseGoals parses goal files from the Goals folder, together with the
+// periodic notes wherever they are kept. Goals come back as a flat list
+// whose parent relationships are set through ParentID; goals.Build
+// assembles them into a tree.
 func (p *Parser) ParseGoals() ([]types.Goal, error) {
 	goalsFolder := p.config.Folders.Goals
 	if goalsFolder == "" {
 		goalsFolder = "Goals"
 	}
 
-	goalsPath := filepath.Join(p.vaultPath, goalsFolder)
-
-	var goals []types.Goal
-
-	err := filepath.Walk(goalsPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil || info.IsDir() || !strings.HasSuffix(path, ".md") {
-			return nil
+	var files []*types.File
+	seen := make(map[string]bool)
+	add := func(path string) {
+		if seen[path] {
+			return
 		}
-
+		seen[path] = true
 		file, err := p.ParseFile(path)
-		if err != nil {
-			return nil
-		}
-
-		goal := types.Goal{
-			Title:       file.Title,
-			Description: "",
-			Progress:    0,
-			Children:    []types.Goal{},
+		if err != nil && !IsFrontmatterError(err) {
+			return
 		}
+		files = append(files, file)
+	}
 
-		// Extract description from frontmatter
-		if file.Frontmatter != nil {
-			if desc, ok := file.Frontmatter["description"].(string); ok {
-				goal.Description = desc
-			}
-			if progress, ok := file.Frontmatter["progress"].(float64); ok {
-				goal.Progress = progress
-			}
-			if due, ok := file.Frontmatter["due"].(string); ok {
-				if t, err := time.Parse("2006-01-02", due); err == nil {
-					goal.DueDate = &t
-				}
-			}
-		}
+	err := p.walkNotes(filepath.Join(p.vaultPath, goalsFolder), add)
+	if os.IsNotExist(err) {
+		err = nil
+	}
 
-		// Calculate progress from tasks if not set
-		if goal.Progress == 0 && len(file.Tasks) > 0 {
-			completed, total := CountTasks(file.Tasks)
-			if total > 0 {
-				goal.Progress = float64(completed) / float64(total)
+	// Periodic notes outside the Goals folder
+	for _, folder := range p.periodicFolders() {
+		p.walkNotes(filepath.Join(p.vaultPath, folder), func(path string) {
+			if _, _, ok := p.PeriodicNotePeriod(path); ok {
+				add(path)
 			}
-		}
-
-		goals = append(goals, goal)
-		return nil
-	})
+		})
+	}
 
-	return goals, err
+	return buildGoals(p.vaultPath, files, p.PeriodicNotePeriod), err
 }
 
-// ParseCourses parses course files from the Courses folder.
+// ParseCourses parses course files from the Courses folder. Lesson links
+// are resolved against every note in the vault so lesson notes are
+// detected.
 func (p *Parser) ParseCourses() ([]types.Course, error) {
 	coursesFolder := p.config.Folders.Courses
 	if coursesFolder == "" {
@@ -545,48 +651,22 @@ func (p *Parser) ParseCourses() ([]types.Course, error) {
 
 	coursesPath := filepath.Join(p.vaultPath, coursesFolder)
 
-	var courses []types.Course
+	resolver := p.noteLinks()
 
-	err := filepath.Walk(coursesPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil || info.IsDir() || !strings.HasSuffix(path, ".md") {
-			return nil
-		}
+	var courses []types.Course
 
+	err := p.walkNotes(coursesPath, func(path string) {
 		file, err := p.ParseFile(path)
-		if err != nil {
-			return nil
-		}
-
-		course := types.Course{
-			Title:    file.Title,
-			Sections: []types.CourseSection{},
+		if err != nil && !IsFrontmatterError(err) {
+			return
 		}
 
-		// Extract from frontmatter
-		if file.Frontmatter != nil {
-			if source, ok := file.Frontmatter["source"].(string); ok {
-				course.Source = source
-			}
-			if url, ok := file.Frontmatter["url"].(string); ok {
-				course.URL = url
-			}
-			if total, ok := file.Frontmatter["total_lessons"].(int); ok {
-				course.TotalLessons = total
-			}
-		}
-
-		// Calculate progress from tasks
-		if len(file.Tasks) > 0 {
-			completed, total := CountTasks(file.Tasks)
-			course.Completed = completed
-			if course.TotalLessons == 0 {
-				course.TotalLessons = total
-			}
-		}
-
-		courses = append(courses, course)
-		return nil
+		resolver.ResolveLinks(file)
+		courses = append(courses, CourseFromFile(file))
 	})
+	if os.IsNotExist(err) {
+		err = nil
+	}
 
 	return courses, err
 }
@@ -600,39 +680,22 @@ func (p *Parser) ParseBooks() ([]types.Book, error) {
 
 	booksPath := filepath.Join(p.vaultPath, booksFolder)
 
-	var books []types.Book
+	resolver := p.noteLinks()
 
-	err := filepath.Walk(booksPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil || info.IsDir() || !strings.HasSuffix(path, ".md") {
-			return nil
-		}
+	var books []types.Book
 
+	err := p.walkNotes(booksPath, func(path string) {
 		file, err := p.ParseFile(path)
-		if err != nil {
-			return nil
-		}
-
-		book := types.Book{
-			Title:    file.Title,
-			Chapters: []types.BookChapter{},
+		if err != nil && !IsFrontmatterError(err) {
+			return
 		}
 
-		// Extract from frontmatter
-		if file.Frontmatter != nil {
-			if author, ok := file.Frontmatter["author"].(string); ok {
-				book.Author = author
-			}
-			if currentPage, ok := file.Frontmatter["current_page"].(int); ok {
-				book.CurrentPage = currentPage
-			}
-			if totalPages, ok := file.Frontmatter["total_pages"].(int); ok {
-				book.TotalPages = totalPages
-			}
-		}
-
-		books = append(books, book)
-		return nil
+		resolver.ResolveLinks(file)
+		books = append(books, BookFromFile(file))
 	})
+	if os.IsNotExist(err) {
+		err = nil
+	}
 
 	return books, err
 }
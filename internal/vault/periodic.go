@@ -0,0 +1,156 @@
+package vault
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BioWare/lazyobsidian/internal/config"
+	"github.com/BioWare/lazyobsidian/internal/periodic"
+	"github.com/BioWare/lazyobsidian/internal/templates"
+	"github.com/BioWare/lazyobsidian/pkg/types"
+)
+
+// periodicNoteConfig returns the folder and format of a period's notes.
+func (p *Parser) periodicNoteConfig(period types.GoalPeriod) (config.PeriodicNoteConfig, bool) {
+	if p.config == nil {
+		return config.PeriodicNoteConfig{}, false
+	}
+	var cfg config.PeriodicNoteConfig
+	switch period {
+	case types.GoalPeriodWeek:
+		cfg = p.config.Periodic.Weekly
+	case types.GoalPeriodMonth:
+		cfg = p.config.Periodic.Monthly
+	case types.GoalPeriodQuarter:
+		cfg = p.config.Periodic.Quarterly
+	case types.GoalPeriodYear:
+		cfg = p.config.Periodic.Yearly
+	default:
+		return cfg, false
+	}
+	return cfg, cfg.Format != ""
+}
+
+// PeriodicNotePath returns the path of the note for the period containing
+// date, whether or not it exists.
+func (p *Parser) PeriodicNotePath(period types.GoalPeriod, date time.Time) (string, error) {
+	cfg, ok := p.periodicNoteConfig(period)
+	if !ok {
+		return "", fmt.Errorf("no format configured for %s notes", period)
+	}
+	name := periodic.Format(periodic.Start(period, date), cfg.Format) + ".md"
+	return filepath.Join(p.vaultPath, cfg.Folder, filepath.FromSlash(name)), nil
+}
+
+// PeriodicNoteExists checks if the note for the period containing date exists.
+func (p *Parser) PeriodicNoteExists(period types.GoalPeriod, date time.Time) bool {
+	path, err := p.PeriodicNotePath(period, date)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+// ParsePeriodicNote parses the note for the period containing date.
+func (p *Parser) ParsePeriodicNote(period types.GoalPeriod, date time.Time) (*types.File, error) {
+	path, err := p.PeriodicNotePath(period, date)
+	if err != nil {
+		return nil, err
+	}
+	return p.ParseFile(path)
+}
+
+// PeriodicNotePeriod reports whether path is a periodic note, and if so
+// its period and the period's first day.
+func (p *Parser) PeriodicNotePeriod(path string) (types.GoalPeriod, time.Time, bool) {
+	if !strings.HasSuffix(path, ".md") {
+		return types.GoalPeriodNone, time.Time{}, false
+	}
+	rel, err := filepath.Rel(p.vaultPath, path)
+	if err != nil {
+		return types.GoalPeriodNone, time.Time{}, false
+	}
+	rel = strings.TrimSuffix(filepath.ToSlash(rel), ".md")
+
+	for _, period := range periodic.Periods {
+		cfg, ok := p.periodicNoteConfig(period)
+		if !ok {
+			continue
+		}
+		name := rel
+		if folder := strings.Trim(filepath.ToSlash(cfg.Folder), "/"); folder != "" {
+			if !strings.HasPrefix(rel, folder+"/") {
+				continue
+			}
+			name = strings.TrimPrefix(rel, folder+"/")
+		}
+		if start, ok := periodic.Parse(cfg.Format, name, time.Local); ok {
+			return period, periodic.Start(period, start), true
+		}
+	}
+	return types.GoalPeriodNone, time.Time{}, false
+}
+
+// periodicFolders returns the distinct folders periodic notes live in.
+func (p *Parser) periodicFolders() []string {
+	var folders []string
+	for _, period := range periodic.Periods {
+		if cfg, ok := p.periodicNoteConfig(period); ok && !containsString(folders, cfg.Folder) {
+			folders = append(folders, cfg.Folder)
+		}
+	}
+	return folders
+}
+
+// CreatePeriodicNote creates the note for the period containing date
+// from the period's template, or a default body when none is set. An
+// existing note is left untouched.
+func (w *Writer) CreatePeriodicNote(period types.GoalPeriod, date time.Time) (*CreatedNote, error) {
+	path, err := w.parser.PeriodicNotePath(period, date)
+	if err != nil {
+		return nil, err
+	}
+	cfg, _ := w.parser.periodicNoteConfig(period)
+	section := cfg.TaskSection
+	if section == "" {
+		section = "Goals"
+	}
+
+	fallback := fmt.Sprintf(`---
+type: %s_plan
+period: %s
+---
+
+# %s
+
+## %s
+
+- [ ]
+
+## Review
+
+`, planType(period), period, periodic.Label(period, date), section)
+
+	return w.createNote(path, cfg.Template, fallback, templates.Context{
+		Title: strings.TrimSuffix(filepath.Base(path), ".md"),
+		Date:  periodic.Start(period, date),
+	})
+}
+
+// planType returns the note type used for a period's plan notes, such
+// as "weekly" in "weekly_plan".
+func planType(period types.GoalPeriod) string {
+	switch period {
+	case types.GoalPeriodYear:
+		return "yearly"
+	case types.GoalPeriodQuarter:
+		return "quarterly"
+	case types.GoalPeriodMonth:
+		return "monthly"
+	}
+	return "weekly"
+}
@@ -0,0 +1,217 @@
+package vault
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/BioWare/lazyobsidian/pkg/types"
+)
+
+const (
+	// pomodoroSection is the heading sessions are logged under.
+	pomodoroSection = "Pomodoros"
+	// pomodoroMark marks a task with a pomodoro run for it.
+	pomodoroMark = "🍅"
+)
+
+// LogPomodoro writes a finished session to the vault, as the pomodoro
+// logging config says: in the daily note of the day it started, in the
+// note its context links to, or in a single log file. A session whose
+// context is not a link to a note is logged in the daily note. Breaks are
+// only logged with log_breaks. It returns the note written to, or "" when
+// the session is not logged.
+func (w *Writer) LogPomodoro(session types.PomodoroSession) (string, error) {
+	cfg := w.config.Pomodoro.Logging
+	if session.Type != types.PomodoroTypeWork && !cfg.LogBreaks {
+		return "", nil
+	}
+
+	path, daily := w.parser.DailyNotePath(session.StartedAt), true
+	switch cfg.Mode {
+	case "single_file":
+		if cfg.SingleFile != "" {
+			path, daily = w.notePath(cfg.SingleFile), false
+		}
+	case "context":
+		if note, ok := w.contextNote(session.Context); ok {
+			path, daily = note, false
+		}
+	}
+
+	defer w.group("log pomodoro")()
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if daily {
+			_, err = w.CreateDailyNote(path)
+		} else if err = os.MkdirAll(filepath.Dir(path), 0755); err == nil {
+			err = w.createFile(path, "log pomodoro", "")
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to create pomodoro log: %w", err)
+		}
+	}
+
+	err := w.editFile(path, "log pomodoro", func(doc *document) error {
+		if FindHeading(ParseOutline(doc.lines), pomodoroSection) == nil {
+			heading := []string{"## " + pomodoroSection, ""}
+			if strings.TrimSpace(strings.Join(doc.lines, "")) != "" {
+				heading = append([]string{""}, heading...)
+			}
+			doc.lines = appendLines(doc.lines, heading)
+		}
+		doc.lines = addPomodoroEntry(doc.lines, session, cfg.Format, !daily)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// pomodoroRunPattern matches a run of pomodoro marks with the space before it.
+var pomodoroRunPattern = regexp.MustCompile(`[ \t]*(?:` + pomodoroMark + `)+`)
+
+// AddPomodoro marks a task with a 🍅 for a pomodoro run for it. Marks go
+// together right after the task's description, before its metadata, so
+// that they are never read as part of a metadata value.
+func (w *Writer) AddPomodoro(filePath string, task *types.Task) error {
+	err := w.editFile(filePath, "add pomodoro", func(doc *document) error {
+		idx, err := w.locateTask(doc, task)
+		if err != nil {
+			return err
+		}
+		// Marks already on the line move there too
+		line := doc.lines[idx]
+		end := taskTextEnd(line)
+		marks := strings.Count(line[:end], pomodoroMark) + 1
+		line = pomodoroRunPattern.ReplaceAllString(line[:end], "") + line[end:]
+		doc.lines[idx] = insertTaskMeta(line, descriptionEnd(line), strings.Repeat(pomodoroMark, marks))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	task.Pomodoros++
+	return nil
+}
+
+// descriptionEnd returns where the description of a task line ends: at its
+// first Tasks plugin signifier or inline field, or else at its comment or
+// block ID.
+func descriptionEnd(line string) int {
+	start := 0
+	if m := taskPattern.FindStringSubmatchIndex(line); m != nil {
+		start = m[6]
+	}
+	end := taskTextEnd(line)
+	if i := strings.IndexAny(line[start:end], taskSignifiers); i != -1 {
+		end = start + i
+	}
+	for _, pattern := range []*regexp.Regexp{bracketFieldPattern, parenFieldPattern} {
+		if loc := pattern.FindStringIndex(line[start:end]); loc != nil {
+			end = start + loc[0]
+		}
+	}
+	return end
+}
+
+// addPomodoroEntry adds a session to the Pomodoros section of a note: as a
+// list item, a list item of Dataview fields ("inline"), or a table row. Logs
+// outside daily notes give the date too.
+func addPomodoroEntry(lines []string, session types.PomodoroSession, format string, withDate bool) []string {
+	date := session.StartedAt.Format("2006-01-02")
+	start := session.StartedAt.Format("15:04")
+	end := session.EndedAt.Format("15:04")
+	duration := fmt.Sprintf("%d min", session.Duration)
+	context := strings.TrimSpace(session.Context)
+
+	kind := "Focus"
+	switch session.Type {
+	case types.PomodoroTypeShortBreak:
+		kind = "Short break"
+	case types.PomodoroTypeLongBreak:
+		kind = "Long break"
+	}
+
+	if format == "table" {
+		header := []string{"Start", "End", "Type", "Duration", "Context"}
+		row := []string{start, end, kind, duration, context}
+		if withDate {
+			header = append([]string{"Date"}, header...)
+			row = append([]string{date}, row...)
+		}
+		return addTableRow(lines, header, row)
+	}
+
+	when := start + "-" + end
+	if withDate {
+		when = date + " " + when
+	}
+	var entry string
+	if format == "inline" {
+		entry = fmt.Sprintf("- %s (pomodoro:: %s) (duration:: %s)", when, strings.ToLower(kind), duration)
+		if context != "" {
+			entry += " (context:: " + context + ")"
+		}
+	} else {
+		entry = fmt.Sprintf("- %s %s (%s)", when, kind, duration)
+		if context != "" {
+			entry += " " + context
+		}
+	}
+	return insertInSection(lines, pomodoroSection, []string{entry})
+}
+
+// addTableRow adds a row to the table in the Pomodoros section, starting
+// the table when there is none.
+func addTableRow(lines []string, header, row []string) []string {
+	section := FindHeading(ParseOutline(lines), pomodoroSection)
+	end := section.EndLine
+	if len(section.Children) > 0 {
+		end = section.Children[0].Line - 1
+	}
+	last := -1
+	for i := section.Line; i < end && i < len(lines); i++ {
+		if strings.HasPrefix(strings.TrimSpace(lines[i]), "|") {
+			last = i
+		}
+	}
+
+	// A "|" in a cell, as in "[[Project|P]]", would end the cell
+	tableRow := func(cells []string) string {
+		escaped := make([]string, len(cells))
+		for i, cell := range cells {
+			escaped[i] = strings.ReplaceAll(cell, "|", `\|`)
+		}
+		return "| " + strings.Join(escaped, " | ") + " |"
+	}
+	if last != -1 {
+		return insertLines(lines, last+1, []string{tableRow(row)})
+	}
+	rule := make([]string, len(header))
+	for i := range rule {
+		rule[i] = "---"
+	}
+	return insertInSection(lines, pomodoroSection, []string{tableRow(header), tableRow(rule), tableRow(row)})
+}
+
+// contextNote returns the note a pomodoro context links to, when it is a
+// wikilink to an existing note.
+func (w *Writer) contextNote(context string) (string, bool) {
+	links := extractLinks(context, 1)
+	if len(links) == 0 || links[0].Type != types.LinkTypeWikilink {
+		return "", false
+	}
+	return w.parser.ResolveNote("", links[0].Target)
+}
+
+// notePath returns the path of a note given relative to the vault, with
+// or without its extension.
+func (w *Writer) notePath(name string) string {
+	if filepath.Ext(name) == "" {
+		name += ".md"
+	}
+	return filepath.Join(w.vaultPath, filepath.FromSlash(name))
+}
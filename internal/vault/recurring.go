@@ -0,0 +1,84 @@
+package vault
+
+import (
+	"strings"
+	"time"
+
+	"github.com/BioWare/lazyobsidian/internal/logging"
+	"github.com/BioWare/lazyobsidian/internal/recurrence"
+	"github.com/BioWare/lazyobsidian/pkg/types"
+)
+
+// nextOccurrence returns the line of the next occurrence of a recurring
+// task, given the task's line before it was completed on day done, or
+// false if the task does not recur.
+//
+// As in the Tasks plugin, the next due, scheduled and start dates keep
+// their distance from the task's reference date, the first of its due,
+// scheduled and start dates. The rule counts from the reference date, or
+// from the day the task is done for "when done" rules. A task without any
+// of those dates recurs without dates.
+func (w *Writer) nextOccurrence(line string, done time.Time) (string, bool) {
+	m := taskPattern.FindStringSubmatch(line)
+	if m == nil {
+		return "", false
+	}
+
+	// The new occurrence is not the same block, and no pomodoros were run
+	// for it yet
+	text := m[3]
+	if b := blockIDPattern.FindStringSubmatchIndex(text); b != nil {
+		text = strings.TrimRight(text[:b[0]], " \t")
+	}
+	text = pomodoroRunPattern.ReplaceAllString(text, "")
+
+	meta := types.Task{Text: text}
+	parseTaskMetadata(&meta)
+	if meta.Recurrence == "" {
+		return "", false
+	}
+	rule, err := recurrence.Parse(meta.Recurrence)
+	if err != nil {
+		logging.Warn("Not repeating task %q: %v", meta.Text, err)
+		return "", false
+	}
+
+	var ref *time.Time
+	for _, d := range []*time.Time{meta.DueDate, meta.ScheduledDate, meta.StartDate} {
+		if d != nil {
+			ref = d
+			break
+		}
+	}
+
+	if ref != nil {
+		base := *ref
+		if rule.WhenDone {
+			base = done
+		}
+		next := rule.Next(base)
+		text = taskDatePattern.ReplaceAllStringFunc(text, func(s string) string {
+			dm := taskDatePattern.FindStringSubmatch(s)
+			if dm[1] != emojiDue && dm[1] != emojiScheduled && dm[1] != emojiStart {
+				return s
+			}
+			date, err := time.ParseInLocation("2006-01-02", dm[2], time.Local)
+			if err != nil {
+				return s
+			}
+			shifted := next.AddDate(0, 0, recurrence.DaysBetween(*ref, date))
+			return strings.Replace(s, dm[2], shifted.Format("2006-01-02"), 1)
+		})
+	}
+
+	// It is not done yet, and it is created today
+	text = taskDoneDatePattern.ReplaceAllString(text, "")
+	text = taskDatePattern.ReplaceAllStringFunc(text, func(s string) string {
+		if dm := taskDatePattern.FindStringSubmatch(s); dm[1] == emojiCreated {
+			return strings.Replace(s, dm[2], done.Format("2006-01-02"), 1)
+		}
+		return s
+	})
+
+	return m[1] + "- [" + w.parser.statusToSymbol("open") + "] " + text, true
+}
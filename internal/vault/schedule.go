@@ -0,0 +1,99 @@
+package vault
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BioWare/lazyobsidian/pkg/types"
+)
+
+// scheduleEntry is a schedule line before it is placed on the note's date.
+// Times are minutes since midnight.
+type scheduleEntry struct {
+	start   int
+	end     int
+	hasEnd  bool
+	title   string
+	context string
+	line    int
+}
+
+// parseScheduleLine parses a "- 09:00-10:30 | Title | context" line.
+func parseScheduleLine(line string, lineNum int) (scheduleEntry, bool) {
+	m := schedulePattern.FindStringSubmatch(strings.TrimSpace(line))
+	if m == nil {
+		return scheduleEntry{}, false
+	}
+
+	start, ok := parseClock(m[1])
+	if !ok {
+		return scheduleEntry{}, false
+	}
+	entry := scheduleEntry{
+		start:   start,
+		end:     start,
+		title:   strings.TrimSpace(m[3]),
+		context: strings.TrimSpace(m[4]),
+		line:    lineNum,
+	}
+	if m[2] != "" {
+		end, ok := parseClock(m[2])
+		if !ok {
+			return scheduleEntry{}, false
+		}
+		entry.end = end
+		entry.hasEnd = true
+	}
+	return entry, true
+}
+
+// parseClock parses "H:MM" or "HH:MM" into minutes since midnight.
+// "24:00" is accepted as the end of the day.
+func parseClock(s string) (int, bool) {
+	parts := strings.SplitN(s, ":", 2)
+	if len(parts) != 2 {
+		return 0, false
+	}
+	h, err1 := strconv.Atoi(parts[0])
+	m, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil || m > 59 || h > 24 || (h == 24 && m > 0) {
+		return 0, false
+	}
+	return h*60 + m, true
+}
+
+// buildSchedule places schedule entries on date. A block ending before it
+// starts runs past midnight.
+func buildSchedule(entries []scheduleEntry, date time.Time) []types.ScheduleBlock {
+	// Times are placed on the wall clock rather than added to midnight, so
+	// that they stay put on days the clocks change
+	at := func(minutes int) time.Time {
+		return time.Date(date.Year(), date.Month(), date.Day(), minutes/60, minutes%60, 0, 0, date.Location())
+	}
+
+	blocks := make([]types.ScheduleBlock, 0, len(entries))
+	for _, e := range entries {
+		end := e.end
+		if e.hasEnd && end < e.start {
+			end += 24 * 60
+		}
+		blocks = append(blocks, types.ScheduleBlock{
+			Start:   at(e.start),
+			End:     at(end),
+			Title:   e.title,
+			Context: e.context,
+			Line:    e.line,
+		})
+	}
+	return blocks
+}
+
+// noteDate returns the date a daily note is for, from its "date"
+// frontmatter or, failing that, its path.
+func (p *Parser) noteDate(path string, frontmatter map[string]interface{}) (time.Time, bool) {
+	if date, ok := FrontmatterDate(frontmatter, "date"); ok {
+		return date, true
+	}
+	return p.dailyNoteDate(path)
+}
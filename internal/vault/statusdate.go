@@ -0,0 +1,69 @@
+package vault
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Dataview fields the Tasks plugin writes done and cancelled dates to in
+// its Dataview format.
+const (
+	fieldDone      = "completion"
+	fieldCancelled = "cancelled"
+)
+
+// taskDoneDatePattern matches a done or cancelled date, as an emoji or a
+// Dataview field, with the space before it.
+var taskDoneDatePattern = regexp.MustCompile(`\s*(?:(?:✅|❌)\x{FE0F}?\s*\d{4}-\d{2}-\d{2}` +
+	`|\[(?:` + fieldDone + `|` + fieldCancelled + `)::[^\[\]]*\]` +
+	`|\((?:` + fieldDone + `|` + fieldCancelled + `)::[^()]*\))`)
+
+// stampStatusDate updates the done and cancelled dates on a task line whose
+// status changed to status on day: the old dates are removed, and a task
+// that is now done or cancelled gets the day it happened.
+func (w *Writer) stampStatusDate(line, status string, day time.Time) string {
+	if w.config == nil || !w.config.Tasks.Dates.Enabled {
+		return line
+	}
+	line = taskDoneDatePattern.ReplaceAllString(line, "")
+
+	date := day.Format("2006-01-02")
+	dataview := w.config.Tasks.Dates.Format == "dataview"
+	switch {
+	case status == "done" && dataview:
+		return appendTaskMeta(line, "["+fieldDone+":: "+date+"]")
+	case status == "done":
+		return appendTaskMeta(line, emojiDone+" "+date)
+	case status == "cancelled" && dataview:
+		return appendTaskMeta(line, "["+fieldCancelled+":: "+date+"]")
+	case status == "cancelled":
+		return appendTaskMeta(line, emojiCancelled+" "+date)
+	}
+	return line
+}
+
+// appendTaskMeta adds meta to the end of a task's text, before its comment
+// and block ID, where the parser looks for metadata.
+func appendTaskMeta(line, meta string) string {
+	return insertTaskMeta(line, taskTextEnd(line), meta)
+}
+
+// insertTaskMeta adds meta to a task line at end, after the text before it.
+func insertTaskMeta(line string, end int, meta string) string {
+	head := strings.TrimRight(line[:end], " \t")
+	return head + " " + meta + line[len(head):]
+}
+
+// taskTextEnd returns where a task line's text and metadata end: at its
+// comment or block ID, if any.
+func taskTextEnd(line string) int {
+	end := len(line)
+	if b := blockIDPattern.FindStringIndex(line); b != nil {
+		end = b[0]
+	}
+	if c := strings.Index(line[:end], " // "); c != -1 {
+		end = c
+	}
+	return end
+}
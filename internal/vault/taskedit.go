@@ -0,0 +1,387 @@
+package vault
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/BioWare/lazyobsidian/internal/logging"
+	"github.com/BioWare/lazyobsidian/pkg/types"
+)
+
+// Task editing. Every operation finds its task by identity (see
+// TaskKey) and treats a task together with its subtasks and any lines
+// indented under it as one block.
+
+// listItemPattern matches a list item line, task or not.
+var listItemPattern = regexp.MustCompile(`^\s*(?:[-*+]|\d+[.)])\s`)
+
+// AddTask adds an open task with text at the end of a section's own
+// text, like InsertAtSection. With an empty heading, or one the note
+// does not have, the task goes at the end of the note.
+func (w *Writer) AddTask(filePath, heading, text string) error {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return fmt.Errorf("task text is empty")
+	}
+	line := "- [" + w.parser.statusToSymbol("open") + "] " + text
+
+	defer w.group("add task")()
+	if heading == "" {
+		return w.editFile(filePath, "add task", func(doc *document) error {
+			doc.lines = appendLines(doc.lines, []string{line})
+			return nil
+		})
+	}
+	return w.InsertAtSection(filePath, heading, line)
+}
+
+// AddSubtask adds an open task with text as the last subtask of parent.
+func (w *Writer) AddSubtask(filePath string, parent *types.Task, text string) error {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return fmt.Errorf("task text is empty")
+	}
+
+	return w.editFile(filePath, "add subtask", func(doc *document) error {
+		idx, err := w.locateTask(doc, parent)
+		if err != nil {
+			return err
+		}
+		end := blockEnd(doc.lines, idx)
+		indent := leadingSpace(doc.lines[idx]) + indentUnit(doc.lines)
+		line := indent + "- [" + w.parser.statusToSymbol("open") + "] " + text
+		doc.lines = insertLines(doc.lines, end, []string{line})
+		return nil
+	})
+}
+
+// EditTaskText replaces a task's text, keeping its status, indentation
+// and metadata such as dates, fields and block ID.
+func (w *Writer) EditTaskText(filePath string, task *types.Task, text string) error {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return fmt.Errorf("task text is empty")
+	}
+
+	err := w.editFile(filePath, "edit task", func(doc *document) error {
+		idx, err := w.locateTask(doc, task)
+		if err != nil {
+			return err
+		}
+		line := doc.lines[idx]
+		m := taskPattern.FindStringSubmatchIndex(line)
+		if m == nil {
+			return fmt.Errorf("could not find task pattern in line")
+		}
+		body := line[m[6]:m[7]]
+		pos := strings.Index(body, task.Text)
+		if task.Text == "" || pos == -1 {
+			// The text is broken up by metadata and cannot be told apart
+			return fmt.Errorf("the text of task %q cannot be edited in place", task.Text)
+		}
+		body = body[:pos] + text + body[pos+len(task.Text):]
+		doc.lines[idx] = line[:m[6]] + body + line[m[7]:]
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	logging.Info("Task text changed: %s -> %s", task.Text, text)
+	task.Text = text
+	return nil
+}
+
+// DeleteTask removes a task together with its subtasks.
+func (w *Writer) DeleteTask(filePath string, task *types.Task) error {
+	return w.editFile(filePath, "delete task", func(doc *document) error {
+		idx, err := w.locateTask(doc, task)
+		if err != nil {
+			return err
+		}
+		doc.lines = removeLines(doc.lines, idx, blockEnd(doc.lines, idx))
+		return nil
+	})
+}
+
+// IndentTask makes a task, with its subtasks, a subtask of the list item
+// above it.
+func (w *Writer) IndentTask(filePath string, task *types.Task) error {
+	return w.editFile(filePath, "indent task", func(doc *document) error {
+		idx, err := w.locateTask(doc, task)
+		if err != nil {
+			return err
+		}
+		if prevSibling(doc.lines, idx) == -1 {
+			return fmt.Errorf("no task above %q to indent under", task.Text)
+		}
+		unit := indentUnit(doc.lines)
+		end := blockEnd(doc.lines, idx)
+		for i := idx; i < end; i++ {
+			if strings.TrimSpace(doc.lines[i]) != "" {
+				doc.lines[i] = unit + doc.lines[i]
+			}
+		}
+		return nil
+	})
+}
+
+// OutdentTask moves a subtask, with its own subtasks, one level out.
+func (w *Writer) OutdentTask(filePath string, task *types.Task) error {
+	return w.editFile(filePath, "outdent task", func(doc *document) error {
+		idx, err := w.locateTask(doc, task)
+		if err != nil {
+			return err
+		}
+		if indentWidth(doc.lines[idx]) == 0 {
+			return fmt.Errorf("task %q is not indented", task.Text)
+		}
+		end := blockEnd(doc.lines, idx)
+		for i := idx; i < end; i++ {
+			doc.lines[i] = dedentOnce(doc.lines[i])
+		}
+		return nil
+	})
+}
+
+// MoveTaskUp swaps a task, with its subtasks, with the sibling above it.
+func (w *Writer) MoveTaskUp(filePath string, task *types.Task) error {
+	return w.editFile(filePath, "move task up", func(doc *document) error {
+		idx, err := w.locateTask(doc, task)
+		if err != nil {
+			return err
+		}
+		prev := prevSibling(doc.lines, idx)
+		if prev == -1 {
+			return fmt.Errorf("task %q is already first", task.Text)
+		}
+		doc.lines = swapBlocks(doc.lines, prev, idx, blockEnd(doc.lines, idx))
+		return nil
+	})
+}
+
+// MoveTaskDown swaps a task, with its subtasks, with the sibling below it.
+func (w *Writer) MoveTaskDown(filePath string, task *types.Task) error {
+	return w.editFile(filePath, "move task down", func(doc *document) error {
+		idx, err := w.locateTask(doc, task)
+		if err != nil {
+			return err
+		}
+		next := nextSibling(doc.lines, idx)
+		if next == -1 {
+			return fmt.Errorf("task %q is already last", task.Text)
+		}
+		doc.lines = swapBlocks(doc.lines, idx, next, blockEnd(doc.lines, next))
+		return nil
+	})
+}
+
+// MoveTaskToNote moves a task, with its subtasks, to the end of a
+// section of another note, or to the end of that note when heading is
+// empty or missing. The task is added to the destination before it is
+// removed from its note, so a failure never loses it.
+func (w *Writer) MoveTaskToNote(filePath string, task *types.Task, destPath, heading string) error {
+	if filepath.Clean(destPath) == filepath.Clean(filePath) {
+		return w.editFile(filePath, "move task", func(doc *document) error {
+			idx, err := w.locateTask(doc, task)
+			if err != nil {
+				return err
+			}
+			end := blockEnd(doc.lines, idx)
+			block := dedentBlock(doc.lines[idx:end])
+			doc.lines = insertInSection(removeLines(doc.lines, idx, end), heading, block)
+			return nil
+		})
+	}
+
+	block, err := w.taskBlock(filePath, task)
+	if err != nil {
+		return err
+	}
+
+	// Both notes are undone together
+	defer w.group("move task")()
+	err = w.editFile(destPath, "move task", func(doc *document) error {
+		doc.lines = insertInSection(doc.lines, heading, dedentBlock(block))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := w.DeleteTask(filePath, task); err != nil {
+		return fmt.Errorf("task copied to %s but not removed: %w", destPath, err)
+	}
+
+	logging.Info("Task moved: %s -> %s", task.Text, destPath)
+	return nil
+}
+
+// taskBlock returns a task's lines with those of its subtasks, without
+// changing the file.
+func (w *Writer) taskBlock(filePath string, task *types.Task) ([]string, error) {
+	doc, err := w.loadDocument(filePath)
+	if err != nil {
+		return nil, err
+	}
+	idx, err := w.locateTask(doc, task)
+	if err != nil {
+		return nil, err
+	}
+	return doc.lines[idx:blockEnd(doc.lines, idx)], nil
+}
+
+// insertInSection inserts block at the end of a section's own text, or
+// at the end of lines when there is no such section.
+func insertInSection(lines []string, heading string, block []string) []string {
+	if _, text, ok := parseHeading(heading); ok {
+		heading = text
+	}
+	var section *types.Heading
+	if heading != "" {
+		section = FindHeading(ParseOutline(lines), heading)
+	}
+	if section == nil {
+		return appendLines(lines, block)
+	}
+
+	end := section.EndLine
+	if len(section.Children) > 0 {
+		end = section.Children[0].Line - 1
+	}
+	insertIdx := end
+	for insertIdx > section.Line && strings.TrimSpace(lines[insertIdx-1]) == "" {
+		insertIdx--
+	}
+	return insertLines(lines, insertIdx, block)
+}
+
+// appendLines adds lines after the last non-blank line, keeping the
+// note's trailing newline.
+func appendLines(lines []string, added []string) []string {
+	end := len(lines)
+	for end > 0 && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+	return insertLines(lines, end, added)
+}
+
+// insertLines inserts added before lines[at].
+func insertLines(lines []string, at int, added []string) []string {
+	result := make([]string, 0, len(lines)+len(added))
+	result = append(result, lines[:at]...)
+	result = append(result, added...)
+	return append(result, lines[at:]...)
+}
+
+// removeLines removes lines[from:to].
+func removeLines(lines []string, from, to int) []string {
+	return append(lines[:from:from], lines[to:]...)
+}
+
+// swapBlocks swaps the adjacent blocks lines[a:b] and lines[b:end].
+func swapBlocks(lines []string, a, b, end int) []string {
+	result := make([]string, 0, len(lines))
+	result = append(result, lines[:a]...)
+	result = append(result, lines[b:end]...)
+	result = append(result, lines[a:b]...)
+	return append(result, lines[end:]...)
+}
+
+// blockEnd returns the index just past the block starting at lines[idx]:
+// the line and every following line indented deeper, up to the last
+// non-blank one.
+func blockEnd(lines []string, idx int) int {
+	base := indentWidth(lines[idx])
+	end := idx + 1
+	for i := idx + 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "" {
+			continue
+		}
+		if indentWidth(lines[i]) <= base {
+			break
+		}
+		end = i + 1
+	}
+	return end
+}
+
+// prevSibling returns the index of the list item above lines[idx] at the
+// same depth and under the same parent, or -1.
+func prevSibling(lines []string, idx int) int {
+	base := indentWidth(lines[idx])
+	for i := idx - 1; i >= 0; i-- {
+		if strings.TrimSpace(lines[i]) == "" {
+			continue
+		}
+		switch width := indentWidth(lines[i]); {
+		case width > base:
+			continue // inside the sibling's block
+		case width == base && listItemPattern.MatchString(lines[i]):
+			return i
+		default:
+			return -1
+		}
+	}
+	return -1
+}
+
+// nextSibling returns the index of the list item below lines[idx]'s block
+// at the same depth, past any blank lines, or -1.
+func nextSibling(lines []string, idx int) int {
+	base := indentWidth(lines[idx])
+	i := blockEnd(lines, idx)
+	for i < len(lines) && strings.TrimSpace(lines[i]) == "" {
+		i++
+	}
+	if i < len(lines) && indentWidth(lines[i]) == base && listItemPattern.MatchString(lines[i]) {
+		return i
+	}
+	return -1
+}
+
+// leadingSpace returns a line's indentation.
+func leadingSpace(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+// indentWidth returns a line's indentation, counting a tab as two spaces
+// like the parser.
+func indentWidth(line string) int {
+	return len(strings.ReplaceAll(leadingSpace(line), "\t", "  "))
+}
+
+// indentUnit returns the indentation a note uses for nested list items:
+// a tab if its list items are indented with tabs, two spaces otherwise.
+func indentUnit(lines []string) string {
+	for _, line := range lines {
+		if strings.HasPrefix(line, "\t") && listItemPattern.MatchString(line) {
+			return "\t"
+		}
+	}
+	return "  "
+}
+
+// dedentOnce removes one level of indentation from a line.
+func dedentOnce(line string) string {
+	if strings.HasPrefix(line, "\t") {
+		return line[1:]
+	}
+	for i := 0; i < 2 && strings.HasPrefix(line, " "); i++ {
+		line = line[1:]
+	}
+	return line
+}
+
+// dedentBlock shifts a block left so that its first line is not indented.
+func dedentBlock(block []string) []string {
+	result := make([]string, len(block))
+	copy(result, block)
+	for indentWidth(result[0]) > 0 {
+		for i := range result {
+			result[i] = dedentOnce(result[i])
+		}
+	}
+	return result
+}
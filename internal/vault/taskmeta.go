@@ -0,0 +1,130 @@
+package vault
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/BioWare/lazyobsidian/pkg/types"
+)
+
+// Obsidian Tasks plugin signifiers.
+// Each emoji may be followed by an optional variation selector (U+FE0F).
+const (
+	emojiDue       = "📅"
+	emojiScheduled = "⏳"
+	emojiStart     = "🛫"
+	emojiCreated   = "➕"
+	emojiDone      = "✅"
+	emojiCancelled = "❌"
+	emojiRecurring = "🔁"
+	emojiID        = "🆔"
+	emojiDependsOn = "⛔"
+	emojiHighest   = "🔺"
+	emojiHigh      = "⏫"
+	emojiMedium    = "🔼"
+	emojiLow       = "🔽"
+	emojiLowest    = "⏬"
+)
+
+// taskSignifiers lists every metadata emoji, used to find where a task's
+// description ends.
+const taskSignifiers = emojiDue + emojiScheduled + emojiStart + emojiCreated +
+	emojiDone + emojiCancelled + emojiRecurring + emojiID + emojiDependsOn +
+	emojiHighest + emojiHigh + emojiMedium + emojiLow + emojiLowest
+
+var (
+	taskDatePattern       = regexp.MustCompile(`(📅|⏳|🛫|➕|✅|❌)\x{FE0F}?\s*(\d{4}-\d{2}-\d{2})`)
+	taskRecurrencePattern = regexp.MustCompile(`🔁\x{FE0F}?\s*([a-zA-Z0-9, !]+)`) // rule characters, as in the Tasks plugin
+	taskIDPattern         = regexp.MustCompile(`🆔\x{FE0F}?\s*([A-Za-z0-9_-]+)`)
+	taskDependsPattern    = regexp.MustCompile(`⛔\x{FE0F}?\s*([A-Za-z0-9_-]+(?:\s*,\s*[A-Za-z0-9_-]+)*)`)
+	taskPriorityPattern   = regexp.MustCompile(`(🔺|⏫|🔼|🔽|⏬)\x{FE0F}?`)
+	multiSpacePattern     = regexp.MustCompile(`\s{2,}`)
+)
+
+// parseTaskMetadata extracts Tasks plugin metadata from task.Text into
+// the typed fields of task and removes it from the display text.
+func parseTaskMetadata(task *types.Task) {
+	text := task.Text
+
+	for _, m := range taskDatePattern.FindAllStringSubmatch(text, -1) {
+		date, err := time.ParseInLocation("2006-01-02", m[2], time.Local)
+		if err != nil {
+			continue
+		}
+		switch m[1] {
+		case emojiDue:
+			task.DueDate = &date
+		case emojiScheduled:
+			task.ScheduledDate = &date
+		case emojiStart:
+			task.StartDate = &date
+		case emojiCreated:
+			task.CreatedDate = &date
+		case emojiDone:
+			task.DoneDate = &date
+		case emojiCancelled:
+			task.CancelledDate = &date
+		}
+	}
+	text = taskDatePattern.ReplaceAllString(text, "")
+
+	// The Tasks plugin's Dataview format keeps the same dates in fields
+	for key, date := range map[string]**time.Time{
+		"due":          &task.DueDate,
+		"scheduled":    &task.ScheduledDate,
+		"start":        &task.StartDate,
+		"created":      &task.CreatedDate,
+		fieldDone:      &task.DoneDate,
+		fieldCancelled: &task.CancelledDate,
+	} {
+		if *date == nil {
+			if d, ok := FrontmatterDate(task.Fields, key); ok {
+				*date = &d
+			}
+		}
+	}
+
+	if m := taskRecurrencePattern.FindStringSubmatch(text); m != nil {
+		task.Recurrence = strings.TrimSpace(m[1])
+		text = taskRecurrencePattern.ReplaceAllString(text, "")
+	}
+
+	if m := taskIDPattern.FindStringSubmatch(text); m != nil {
+		task.TaskID = m[1]
+		text = taskIDPattern.ReplaceAllString(text, "")
+	}
+
+	for _, m := range taskDependsPattern.FindAllStringSubmatch(text, -1) {
+		for _, id := range strings.Split(m[1], ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				task.DependsOn = append(task.DependsOn, id)
+			}
+		}
+	}
+	text = taskDependsPattern.ReplaceAllString(text, "")
+
+	if m := taskPriorityPattern.FindStringSubmatch(text); m != nil {
+		task.Priority = emojiToPriority(m[1])
+		text = taskPriorityPattern.ReplaceAllString(text, "")
+	}
+
+	task.Text = strings.TrimSpace(multiSpacePattern.ReplaceAllString(text, " "))
+}
+
+func emojiToPriority(emoji string) types.TaskPriority {
+	switch emoji {
+	case emojiHighest:
+		return types.PriorityHighest
+	case emojiHigh:
+		return types.PriorityHigh
+	case emojiMedium:
+		return types.PriorityMedium
+	case emojiLow:
+		return types.PriorityLow
+	case emojiLowest:
+		return types.PriorityLowest
+	default:
+		return types.PriorityNone
+	}
+}
@@ -0,0 +1,164 @@
+package vault
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BioWare/lazyobsidian/internal/templates"
+	"github.com/BioWare/lazyobsidian/pkg/types"
+)
+
+// CreatedNote describes a note created from a template.
+type CreatedNote struct {
+	Path    string
+	Existed bool               // the note was already there and was left alone
+	Cursors []templates.Cursor // where the template's cursor markers were
+}
+
+// Built-in note bodies, used when no template is configured.
+const (
+	goalNoteBody = `---
+type: goal
+---
+
+# {{title}}
+
+## Tasks
+
+- [ ]
+`
+
+	courseNoteBody = `---
+type: course
+---
+
+# {{title}}
+
+## Lessons
+
+- [ ]
+`
+
+	bookNoteBody = `---
+type: book
+status: to-read
+---
+
+# {{title}}
+
+## Chapters
+
+- [ ]
+`
+)
+
+// invalidNameChars are characters Obsidian does not allow in note names.
+const invalidNameChars = `*"\/<>:|?#^[]`
+
+// NoteFileName turns a title into a note file name, dropping characters
+// Obsidian does not allow.
+func NoteFileName(title string) string {
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(invalidNameChars, r) {
+			return -1
+		}
+		return r
+	}, title)
+	return strings.TrimSpace(name) + ".md"
+}
+
+// CreateNote creates a goal, course or book note with the given title
+// in its folder, from the template configured for its kind. vars are
+// extra {{name}} variables for the template.
+func (w *Writer) CreateNote(kind types.FileType, title string, vars map[string]string) (*CreatedNote, error) {
+	var folder, template, fallback string
+	switch kind {
+	case types.FileTypeGoal:
+		folder, template, fallback = w.config.Folders.Goals, w.config.Templates.Goal, goalNoteBody
+	case types.FileTypeCourse:
+		folder, template, fallback = w.config.Folders.Courses, w.config.Templates.Course, courseNoteBody
+	case types.FileTypeBook:
+		folder, template, fallback = w.config.Folders.Books, w.config.Templates.Book, bookNoteBody
+	default:
+		return nil, fmt.Errorf("cannot create %s notes", kind)
+	}
+
+	name := NoteFileName(title)
+	if name == ".md" {
+		return nil, fmt.Errorf("invalid note title %q", title)
+	}
+	path := filepath.Join(w.vaultPath, folder, name)
+
+	now := time.Now()
+	return w.createNote(path, template, fallback, templates.Context{
+		Title: strings.TrimSuffix(name, ".md"),
+		Date:  now,
+		Now:   now,
+		Vars:  vars,
+	})
+}
+
+// createNote writes a new note at path from the named template, or from
+// fallback when none is set. An existing note is left untouched.
+func (w *Writer) createNote(path, template, fallback string, ctx templates.Context) (*CreatedNote, error) {
+	if _, err := os.Stat(path); err == nil {
+		return &CreatedNote{Path: path, Existed: true}, nil
+	}
+
+	body := fallback
+	if template != "" {
+		templatePath, err := w.templatePath(template)
+		if err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(templatePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template: %w", err)
+		}
+		body = string(data)
+	}
+
+	if ctx.Folder == "" {
+		if rel, err := filepath.Rel(w.vaultPath, filepath.Dir(path)); err == nil && rel != "." {
+			ctx.Folder = filepath.ToSlash(rel)
+		}
+	}
+	if ctx.Prompt == nil {
+		ctx.Prompt = w.Prompt
+	}
+	result, err := templates.Render(body, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := w.createFile(path, "create note", result.Content); err != nil {
+		return nil, err
+	}
+	return &CreatedNote{Path: path, Cursors: result.Cursors}, nil
+}
+
+// templatePath finds a template by name: in the templates folder first,
+// then as a vault path, with or without the ".md" extension.
+func (w *Writer) templatePath(name string) (string, error) {
+	name = filepath.FromSlash(strings.Trim(name, "/"))
+	var candidates []string
+	if w.config.Folders.Templates != "" {
+		candidates = append(candidates, filepath.Join(w.vaultPath, w.config.Folders.Templates, name))
+	}
+	candidates = append(candidates, filepath.Join(w.vaultPath, name))
+
+	for _, c := range candidates {
+		for _, p := range []string{c, c + ".md"} {
+			if info, err := os.Stat(p); err == nil && !info.IsDir() {
+				return p, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("template not found: %s", name)
+}
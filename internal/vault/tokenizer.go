@@ -0,0 +1,238 @@
+package vault
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// maxLineSize is the longest line the parser accepts. bufio.Scanner's
+// 64KB default is too small for notes with embedded data or long tables.
+const maxLineSize = 16 * 1024 * 1024
+
+// lineKind classifies a line of markdown.
+type lineKind int
+
+const (
+	lineText    lineKind = iota // regular markdown, possibly with inline code or comments
+	lineFence                   // opening or closing code fence
+	lineCode                    // inside a fenced code block
+	lineMath                    // inside or delimiting a $$ math block
+	lineComment                 // entirely inside an HTML or %% comment
+)
+
+// tokenizer tracks block-level markdown state across lines so extraction
+// can ignore code blocks, math blocks and comments.
+type tokenizer struct {
+	fence         string // closing fence marker of the open code block
+	inMath        bool
+	inHTMLComment bool
+	inComment     bool // Obsidian %% comment
+}
+
+// tokenLine is a classified line.
+type tokenLine struct {
+	kind lineKind
+	// visible is the line with HTML and %% comments removed.
+	visible string
+	// prose is visible with inline code and inline math blanked out; it is
+	// what tags, links and fields are extracted from.
+	prose string
+}
+
+// next classifies the next line of the document.
+func (t *tokenizer) next(line string) tokenLine {
+	// Inside a fenced code block only a matching fence matters
+	if t.fence != "" {
+		if isClosingFence(line, t.fence) {
+			t.fence = ""
+			return tokenLine{kind: lineFence}
+		}
+		return tokenLine{kind: lineCode}
+	}
+
+	if t.inMath {
+		if strings.Contains(line, "$$") {
+			t.inMath = false
+		}
+		return tokenLine{kind: lineMath}
+	}
+
+	if !t.inHTMLComment && !t.inComment {
+		if fence, ok := openingFence(line); ok {
+			t.fence = fence
+			return tokenLine{kind: lineFence}
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "$$") {
+			// A block opened and closed on one line leaves no state behind
+			if !strings.Contains(trimmed[2:], "$$") {
+				t.inMath = true
+			}
+			return tokenLine{kind: lineMath}
+		}
+	}
+
+	wasInComment := t.inHTMLComment || t.inComment
+	visible, prose := t.scanInline(line)
+	if wasInComment && strings.TrimSpace(visible) == "" {
+		return tokenLine{kind: lineComment}
+	}
+	return tokenLine{kind: lineText, visible: visible, prose: prose}
+}
+
+// scanInline strips comments from a line and blanks out inline code and
+// math. Comments may start or end partway through the line.
+func (t *tokenizer) scanInline(line string) (visible, prose string) {
+	var vis, pro strings.Builder
+
+	for i := 0; i < len(line); {
+		switch {
+		case t.inHTMLComment:
+			end := strings.Index(line[i:], "-->")
+			if end == -1 {
+				i = len(line)
+				continue
+			}
+			i += end + len("-->")
+			t.inHTMLComment = false
+
+		case t.inComment:
+			end := strings.Index(line[i:], "%%")
+			if end == -1 {
+				i = len(line)
+				continue
+			}
+			i += end + len("%%")
+			t.inComment = false
+
+		case strings.HasPrefix(line[i:], "<!--"):
+			t.inHTMLComment = true
+			i += len("<!--")
+
+		case strings.HasPrefix(line[i:], "%%"):
+			t.inComment = true
+			i += len("%%")
+
+		case line[i] == '`':
+			// A code span closes with a backtick run of the same length
+			run := backtickRun(line[i:])
+			end := findBacktickRun(line[i+run:], run)
+			if end == -1 {
+				vis.WriteString(line[i : i+run])
+				pro.WriteString(line[i : i+run])
+				i += run
+				continue
+			}
+			span := line[i : i+run+end+run]
+			vis.WriteString(span)
+			pro.WriteString(blank(span))
+			i += len(span)
+
+		case line[i] == '$':
+			if end := inlineMathEnd(line[i:]); end > 0 {
+				span := line[i : i+end]
+				vis.WriteString(span)
+				pro.WriteString(blank(span))
+				i += end
+				continue
+			}
+			vis.WriteByte('$')
+			pro.WriteByte('$')
+			i++
+
+		default:
+			_, size := utf8.DecodeRuneInString(line[i:])
+			vis.WriteString(line[i : i+size])
+			pro.WriteString(line[i : i+size])
+			i += size
+		}
+	}
+
+	return vis.String(), pro.String()
+}
+
+// openingFence reports whether line opens a fenced code block and returns
+// the fence characters needed to close it. Fences may be indented, since
+// Obsidian allows them inside list items.
+func openingFence(line string) (string, bool) {
+	trimmed := strings.TrimLeft(line, " \t")
+	if len(trimmed) < 3 || (trimmed[0] != '`' && trimmed[0] != '~') {
+		return "", false
+	}
+
+	ch := trimmed[0]
+	n := 0
+	for n < len(trimmed) && trimmed[n] == ch {
+		n++
+	}
+	if n < 3 {
+		return "", false
+	}
+	// A backtick fence's info string may not contain backticks
+	if ch == '`' && strings.Contains(trimmed[n:], "`") {
+		return "", false
+	}
+	return trimmed[:n], true
+}
+
+// isClosingFence reports whether line closes a block opened with fence:
+// the same character, at least as many times, and nothing else.
+func isClosingFence(line, fence string) bool {
+	trimmed := strings.TrimSpace(line)
+	if len(trimmed) < len(fence) {
+		return false
+	}
+	return strings.Trim(trimmed, fence[:1]) == ""
+}
+
+// backtickRun returns the number of backticks at the start of s.
+func backtickRun(s string) int {
+	n := 0
+	for n < len(s) && s[n] == '`' {
+		n++
+	}
+	return n
+}
+
+// findBacktickRun returns the offset of the first run of exactly n
+// backticks in s, or -1.
+func findBacktickRun(s string, n int) int {
+	for i := 0; i < len(s); {
+		if s[i] != '`' {
+			i++
+			continue
+		}
+		run := backtickRun(s[i:])
+		if run == n {
+			return i
+		}
+		i += run
+	}
+	return -1
+}
+
+// inlineMathEnd returns the length of an inline $math$ span at the start
+// of s, or 0. Like Obsidian, the opening $ must be followed and the
+// closing $ preceded by a non-space, and the closing $ may not be
+// followed by a digit, so "$5 and $10" is not math.
+func inlineMathEnd(s string) int {
+	if len(s) < 3 || s[1] == ' ' || s[1] == '$' {
+		return 0
+	}
+	for i := 2; i < len(s); i++ {
+		if s[i] != '$' || s[i-1] == ' ' || s[i-1] == '\\' {
+			continue
+		}
+		if i+1 < len(s) && s[i+1] >= '0' && s[i+1] <= '9' {
+			continue
+		}
+		return i + 1
+	}
+	return 0
+}
+
+// blank replaces every character of s with a space.
+func blank(s string) string {
+	return strings.Repeat(" ", utf8.RuneCountInString(s))
+}
@@ -0,0 +1,119 @@
+package vault
+
+import (
+	"strings"
+	"testing"
+)
+
+// text is a lineText token whose visible and prose parts are the same.
+func text(s string) tokenLine {
+	return tokenLine{kind: lineText, visible: s, prose: s}
+}
+
+func TestTokenizer(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want []tokenLine
+	}{
+		{
+			name: "code fence",
+			doc:  "#a\n```go\n#b [[c]]\n```\n#d",
+			want: []tokenLine{text("#a"), {kind: lineFence}, {kind: lineCode}, {kind: lineFence}, text("#d")},
+		},
+		{
+			name: "longer and tilde fences",
+			doc:  "~~~~\n```\n~~~\n~~~~~\n````md\n~~~\n````",
+			want: []tokenLine{{kind: lineFence}, {kind: lineCode}, {kind: lineCode}, {kind: lineFence},
+				{kind: lineFence}, {kind: lineCode}, {kind: lineFence}},
+		},
+		{
+			name: "indented fence in a list",
+			doc:  "- item\n  ```\n  #x\n  ```",
+			want: []tokenLine{text("- item"), {kind: lineFence}, {kind: lineCode}, {kind: lineFence}},
+		},
+		{
+			name: "not a fence",
+			doc:  "``\n```a`b```",
+			want: []tokenLine{text("``"), {kind: lineText, visible: "```a`b```", prose: "         "}},
+		},
+		{
+			name: "closing fence with text is code",
+			doc:  "```\n``` x\n```",
+			want: []tokenLine{{kind: lineFence}, {kind: lineCode}, {kind: lineFence}},
+		},
+		{
+			name: "inline code",
+			doc:  "a `#b` c ``d ` e`` #f `g",
+			want: []tokenLine{{kind: lineText,
+				visible: "a `#b` c ``d ` e`` #f `g",
+				prose:   "a      c           #f `g"}},
+		},
+		{
+			name: "inline math",
+			doc:  "$x^2$ costs $5 and $10, $ a $",
+			want: []tokenLine{{kind: lineText,
+				visible: "$x^2$ costs $5 and $10, $ a $",
+				prose:   "      costs $5 and $10, $ a $"}},
+		},
+		{
+			name: "math block",
+			doc:  "$$\n#a\n$$\n#b\n$$ x = 1 $$\n#c",
+			want: []tokenLine{{kind: lineMath}, {kind: lineMath}, {kind: lineMath}, text("#b"), {kind: lineMath}, text("#c")},
+		},
+		{
+			name: "obsidian comments",
+			doc:  "a %%#b%% c\nd %%e\n#f [[g]]\nh%% i\n%%\n%%",
+			want: []tokenLine{text("a  c"), text("d "), {kind: lineComment}, text(" i"), text(""), {kind: lineComment}},
+		},
+		{
+			name: "html comments",
+			doc:  "a <!-- #b --> c\n<!--\n```\n-->\n#d",
+			want: []tokenLine{text("a  c"), text(""), {kind: lineComment}, {kind: lineComment}, text("#d")},
+		},
+		{
+			name: "a fence inside a comment does not open",
+			doc:  "%%\n```\n%%\n#a",
+			want: []tokenLine{text(""), {kind: lineComment}, {kind: lineComment}, text("#a")},
+		},
+		{
+			name: "comment markers inside code are text",
+			doc:  "`%%` #a\n```\n%%\n```\n#b",
+			want: []tokenLine{{kind: lineText, visible: "`%%` #a", prose: "     #a"},
+				{kind: lineFence}, {kind: lineCode}, {kind: lineFence}, text("#b")},
+		},
+	}
+	for _, tt := range tests {
+		var tok tokenizer
+		lines := strings.Split(tt.doc, "\n")
+		if len(lines) != len(tt.want) {
+			t.Fatalf("%s: %d lines, want has %d", tt.name, len(lines), len(tt.want))
+		}
+		for i, line := range lines {
+			if got := tok.next(line); got != tt.want[i] {
+				t.Errorf("%s: line %d %q = %+v, want %+v", tt.name, i+1, line, got, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestInlineMathEnd(t *testing.T) {
+	tests := []struct {
+		s    string
+		want int
+	}{
+		{"$x$", 3},
+		{"$a + b$ rest", 7},
+		{"$ x$", 0},
+		{"$x $", 0},
+		{"$5 and $10", 0},
+		{`$a\$b$`, 6},
+		{"$$", 0},
+		{"$x", 0},
+	}
+	for _, tt := range tests {
+		if got := inlineMathEnd(tt.s); got != tt.want {
+			t.Errorf("inlineMathEnd(%q) = %d, want %d", tt.s, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,332 @@
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BioWare/lazyobsidian/internal/journal"
+	"github.com/BioWare/lazyobsidian/internal/logging"
+)
+
+// group makes the edits until the returned function is called one
+// journal operation named op, undone and redone together. Inside a group,
+// the names of the edits themselves are ignored. A Writer is not meant
+// for concurrent edits, so groups are tracked on the Writer itself.
+func (w *Writer) group(op string) func() {
+	if w.Journal == nil || w.pending != nil {
+		return func() {}
+	}
+	w.pending = &journal.Entry{Op: op}
+	return func() {
+		entry := w.pending
+		w.pending = nil
+		if len(entry.Changes) == 0 {
+			return
+		}
+		if err := w.Journal.Record(*entry); err != nil {
+			logging.Warn("Failed to record %s in the journal: %v", op, err)
+		}
+	}
+}
+
+// record adds a change to the journal, as its own operation or as part
+// of the open group.
+func (w *Writer) record(op string, change journal.Change) {
+	if w.Journal == nil {
+		return
+	}
+	if w.pending != nil {
+		w.pending.Changes = append(w.pending.Changes, change)
+		return
+	}
+	if err := w.Journal.Record(journal.Entry{Op: op, Changes: []journal.Change{change}}); err != nil {
+		logging.Warn("Failed to record %s in the journal: %v", op, err)
+	}
+}
+
+// createFile writes a new note and records its creation. It fails rather
+// than replace a note that exists by then, which undoing the creation
+// would delete.
+func (w *Writer) createFile(path, op, content string) error {
+	if err := createFileAtomic(path, []byte(content), 0644); err != nil {
+		return err
+	}
+	w.parser.rememberHash(path, hashBytes([]byte(content)))
+	w.record(op, journal.Change{
+		Path:    w.relPath(path),
+		Line:    1,
+		After:   strings.Split(content, "\n"),
+		Created: true,
+	})
+	return nil
+}
+
+// relPath returns a path relative to the vault, as the journal keeps it.
+func (w *Writer) relPath(path string) string {
+	if rel, err := filepath.Rel(w.vaultPath, path); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return filepath.ToSlash(path)
+}
+
+// Undo reverts the last n operations in the journal, newest first, and
+// returns those it reverted. It stops at an operation whose lines were
+// changed since, with a *ConflictError, leaving that operation's files
+// untouched.
+func (w *Writer) Undo(n int) ([]journal.Entry, error) {
+	return w.replay(n, true)
+}
+
+// Redo applies again the last n operations reverted by Undo, oldest
+// first, and returns them.
+func (w *Writer) Redo(n int) ([]journal.Entry, error) {
+	return w.replay(n, false)
+}
+
+func (w *Writer) replay(n int, undo bool) ([]journal.Entry, error) {
+	if w.Journal == nil {
+		return nil, fmt.Errorf("no journal")
+	}
+
+	var done []journal.Entry
+	for len(done) < n {
+		var entry journal.Entry
+		var ok bool
+		var err error
+		if undo {
+			entry, ok, err = w.Journal.LastDone()
+		} else {
+			entry, ok, err = w.Journal.NextRedo()
+		}
+		if err != nil {
+			return done, err
+		}
+		if !ok {
+			break
+		}
+
+		if err := w.applyChanges(entry.Changes, undo); err != nil {
+			return done, err
+		}
+		if err := w.Journal.SetUndone(entry.ID, undo); err != nil {
+			return done, err
+		}
+		if undo {
+			logging.Info("Undone: %s", entry.Op)
+		} else {
+			logging.Info("Redone: %s", entry.Op)
+		}
+		done = append(done, entry)
+	}
+	return done, nil
+}
+
+// fileState is a file being changed by applyChanges.
+type fileState struct {
+	doc     *document // nil when the file is to be missing
+	created bool      // it does not exist yet
+
+	before []byte      // the content as read, nil when the file was missing
+	hash   string      // the hash of before
+	mode   os.FileMode // the mode as read
+	target string      // where the content goes: the file, or its symlink's target
+	tmp    string      // the new content, staged next to target
+}
+
+// applyChanges reverts changes, or applies them again. Every file is
+// checked, and its new content staged, before any is replaced, so a
+// conflict found then leaves all of them alone. Each file is checked once
+// more right before it is replaced or removed; if one changed in between
+// or cannot be written, the files already replaced are put back as they
+// were read.
+func (w *Writer) applyChanges(changes []journal.Change, undo bool) error {
+	files := make(map[string]*fileState)
+	var order []string
+
+	// Undo walks the changes backwards, redo forwards
+	for i := range changes {
+		c := changes[i]
+		if undo {
+			c = changes[len(changes)-1-i]
+		}
+		path := filepath.Join(w.vaultPath, filepath.FromSlash(c.Path))
+
+		state, ok := files[path]
+		if !ok {
+			doc, err := readDocument(path)
+			if err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to read file: %w", err)
+			}
+			// Replace the target of a symlink rather than the link itself
+			state = &fileState{doc: doc, target: path}
+			if t, err := filepath.EvalSymlinks(path); err == nil {
+				state.target = t
+			}
+			if doc != nil {
+				state.before, state.hash, state.mode = doc.bytes(), doc.hash, doc.mode
+			}
+			files[path] = state
+			order = append(order, path)
+		}
+
+		from, to := c.After, c.Before
+		if !undo {
+			from, to = c.Before, c.After
+		}
+
+		if c.Created {
+			if undo {
+				if state.doc == nil || !equalLines(state.doc.lines, from) {
+					return &ConflictError{Path: path, Msg: "the note was edited after it was created"}
+				}
+				state.doc = nil
+				continue
+			}
+			if state.doc != nil {
+				return &ConflictError{Path: path, Msg: "the note exists again"}
+			}
+			state.doc = &document{path: path, lines: append([]string(nil), to...), mode: 0644}
+			state.created = true
+			continue
+		}
+
+		if state.doc == nil {
+			return &ConflictError{Path: path, Msg: "the note is gone"}
+		}
+		at, ok := findHunk(state.doc.lines, c.Line-1, from)
+		if !ok {
+			return &ConflictError{Path: path, Msg: "the changed lines were edited since"}
+		}
+		lines := append([]string(nil), state.doc.lines[:at]...)
+		lines = append(lines, to...)
+		state.doc.lines = append(lines, state.doc.lines[at+len(from):]...)
+	}
+
+	for _, path := range order {
+		state := files[path]
+		if state.doc == nil {
+			continue
+		}
+		if state.created {
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return fmt.Errorf("failed to create directory: %w", err)
+			}
+		}
+		tmp, err := writeTemp(state.target, state.doc.bytes(), state.doc.mode)
+		if err != nil {
+			return fmt.Errorf("failed to write file: %w", err)
+		}
+		defer os.Remove(tmp)
+		state.tmp = tmp
+	}
+	for _, path := range order {
+		if err := files[path].unchanged(path); err != nil {
+			return err
+		}
+	}
+
+	var replaced []string
+	for _, path := range order {
+		if err := w.replaceFile(path, files[path]); err != nil {
+			for i := len(replaced) - 1; i >= 0; i-- {
+				w.restoreFile(replaced[i], files[replaced[i]])
+			}
+			return err
+		}
+		replaced = append(replaced, path)
+	}
+	return nil
+}
+
+// unchanged returns a *ConflictError if a file is no longer as
+// applyChanges read it.
+func (s *fileState) unchanged(path string) error {
+	if s.before == nil {
+		if _, err := os.Lstat(path); !os.IsNotExist(err) {
+			return &ConflictError{Path: path, Msg: "the note exists again"}
+		}
+		return nil
+	}
+	if current, err := HashFile(path); err != nil || current != s.hash {
+		return &ConflictError{Path: path, Msg: "it changed while being restored"}
+	}
+	return nil
+}
+
+// replaceFile puts a file's staged content in place, or removes it, once
+// it is checked to be as it was read.
+func (w *Writer) replaceFile(path string, s *fileState) error {
+	if err := s.unchanged(path); err != nil {
+		return err
+	}
+	switch {
+	case s.doc == nil:
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove file: %w", err)
+		}
+		return nil
+	case s.before == nil:
+		if err := linkNew(s.tmp, path); err != nil {
+			if errors.Is(err, os.ErrExist) {
+				return &ConflictError{Path: path, Msg: "the note exists again"}
+			}
+			return fmt.Errorf("failed to write file: %w", err)
+		}
+	default:
+		if err := os.Rename(s.tmp, s.target); err != nil {
+			return fmt.Errorf("failed to write file: %w", err)
+		}
+		syncDir(filepath.Dir(s.target))
+	}
+	w.parser.rememberHash(path, hashBytes(s.doc.bytes()))
+	return nil
+}
+
+// restoreFile puts a file replaced by replaceFile back as it was read.
+func (w *Writer) restoreFile(path string, s *fileState) {
+	var err error
+	if s.before == nil {
+		err = os.Remove(path)
+	} else if err = writeFileAtomic(path, s.before, s.mode, ""); err == nil {
+		w.parser.rememberHash(path, s.hash)
+	}
+	if err != nil {
+		logging.Warn("Failed to restore %s: %v", path, err)
+	}
+}
+
+// findHunk finds where hunk sits in lines: at its recorded position if it
+// is still there, otherwise at its only occurrence.
+func findHunk(lines []string, at int, hunk []string) (int, bool) {
+	if at >= 0 && at+len(hunk) <= len(lines) && equalLines(lines[at:at+len(hunk)], hunk) {
+		return at, true
+	}
+	if len(hunk) == 0 {
+		return 0, false
+	}
+	found := -1
+	for i := 0; i+len(hunk) <= len(lines); i++ {
+		if equalLines(lines[i:i+len(hunk)], hunk) {
+			if found != -1 {
+				return 0, false // ambiguous
+			}
+			found = i
+		}
+	}
+	return found, found != -1
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,218 @@
+package vault
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/BioWare/lazyobsidian/internal/config"
+	"github.com/BioWare/lazyobsidian/internal/journal"
+)
+
+func TestFindHunk(t *testing.T) {
+	lines := []string{"a", "b", "c", "a", "b", "d"}
+	tests := []struct {
+		name   string
+		at     int
+		hunk   []string
+		want   int
+		wantOK bool
+	}{
+		{"at its position", 3, []string{"a", "b"}, 3, true},
+		{"moved", 0, []string{"b", "d"}, 4, true},
+		{"past the end", 10, []string{"c"}, 2, true},
+		{"ambiguous", 1, []string{"a", "b"}, 0, false},
+		{"missing", 0, []string{"x"}, 0, false},
+		{"empty at its position", 2, nil, 2, true},
+		{"empty past the end", 10, nil, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := findHunk(lines, tt.at, tt.hunk)
+		if ok != tt.wantOK || (ok && got != tt.want) {
+			t.Errorf("%s: findHunk = %d, %v, want %d, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+// newJournaledWriter returns a writer for a new vault that records its
+// changes.
+func newJournaledWriter(t *testing.T) (string, *Writer) {
+	t.Helper()
+	dir := t.TempDir()
+	cfg := config.DefaultConfig()
+	w := NewWriter(dir, cfg, NewParser(dir, cfg))
+	w.Journal = journal.New(dir)
+	return dir, w
+}
+
+func writeNote(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func checkNote(t *testing.T, path, want string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != want {
+		t.Errorf("%s = %q, want %q", filepath.Base(path), data, want)
+	}
+}
+
+func checkMissing(t *testing.T, path string) {
+	t.Helper()
+	if _, err := os.Lstat(path); !os.IsNotExist(err) {
+		t.Errorf("%s exists, want it removed", filepath.Base(path))
+	}
+}
+
+func replay(t *testing.T, do func(int) ([]journal.Entry, error), want int) {
+	t.Helper()
+	done, err := do(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != want {
+		t.Fatalf("replayed %d operations, want %d", len(done), want)
+	}
+}
+
+const sectionNote = "# Tasks\n- a\n\n# Notes\ntext\n"
+
+func TestUndoRedo(t *testing.T) {
+	dir, w := newJournaledWriter(t)
+	path := filepath.Join(dir, "note.md")
+	writeNote(t, path, sectionNote)
+
+	if err := w.InsertAtSection(path, "Tasks", "- b"); err != nil {
+		t.Fatal(err)
+	}
+	edited := "# Tasks\n- a\n- b\n\n# Notes\ntext\n"
+	checkNote(t, path, edited)
+
+	replay(t, w.Undo, 1)
+	checkNote(t, path, sectionNote)
+	replay(t, w.Undo, 0)
+
+	replay(t, w.Redo, 1)
+	checkNote(t, path, edited)
+	replay(t, w.Redo, 0)
+}
+
+func TestUndoRelocatesHunk(t *testing.T) {
+	dir, w := newJournaledWriter(t)
+	path := filepath.Join(dir, "note.md")
+	writeNote(t, path, sectionNote)
+
+	if err := w.InsertAtSection(path, "Tasks", "- b"); err != nil {
+		t.Fatal(err)
+	}
+	// Lines added above the change since move it down
+	writeNote(t, path, "---\ntags: [x]\n---\n# Tasks\n- a\n- b\n\n# Notes\ntext\nmore\n")
+
+	replay(t, w.Undo, 1)
+	checkNote(t, path, "---\ntags: [x]\n---\n# Tasks\n- a\n\n# Notes\ntext\nmore\n")
+	replay(t, w.Redo, 1)
+	checkNote(t, path, "---\ntags: [x]\n---\n# Tasks\n- a\n- b\n\n# Notes\ntext\nmore\n")
+}
+
+func TestUndoConflict(t *testing.T) {
+	dir, w := newJournaledWriter(t)
+	path := filepath.Join(dir, "note.md")
+	writeNote(t, path, sectionNote)
+
+	if err := w.InsertAtSection(path, "Tasks", "- b"); err != nil {
+		t.Fatal(err)
+	}
+	edited := "# Tasks\n- a\n- b, changed\n\n# Notes\ntext\n"
+	writeNote(t, path, edited)
+
+	done, err := w.Undo(1)
+	if !IsConflictError(err) {
+		t.Fatalf("Undo of edited lines: %v, want a conflict", err)
+	}
+	if len(done) != 0 {
+		t.Errorf("Undo reverted %d operations, want none", len(done))
+	}
+	checkNote(t, path, edited)
+
+	// The operation stays in the journal to undo once fixed
+	if _, ok, _ := w.Journal.LastDone(); !ok {
+		t.Error("the conflicting operation is no longer done")
+	}
+}
+
+func TestUndoGroupConflict(t *testing.T) {
+	dir, w := newJournaledWriter(t)
+	a := filepath.Join(dir, "a.md")
+	b := filepath.Join(dir, "b.md")
+	writeNote(t, a, "one\n")
+
+	func() {
+		defer w.group("both")()
+		if err := w.AppendToFile(a, "two\n"); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.AppendToFile(b, "new\n"); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	checkNote(t, a, "one\ntwo\n")
+	checkNote(t, b, "new\n")
+
+	replay(t, w.Undo, 1)
+	checkNote(t, a, "one\n")
+	checkMissing(t, b)
+	replay(t, w.Redo, 1)
+	checkNote(t, a, "one\ntwo\n")
+	checkNote(t, b, "new\n")
+
+	// A conflict in one file leaves the other alone
+	writeNote(t, b, "new\nmine\n")
+	if _, err := w.Undo(1); !IsConflictError(err) {
+		t.Fatalf("Undo of an edited note: %v, want a conflict", err)
+	}
+	checkNote(t, a, "one\ntwo\n")
+	checkNote(t, b, "new\nmine\n")
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if name := e.Name(); name != "a.md" && name != "b.md" && name[0] != '.' {
+			t.Errorf("left %s in the vault", name)
+		}
+	}
+}
+
+func TestUndoCreatedNote(t *testing.T) {
+	dir, w := newJournaledWriter(t)
+	path := filepath.Join(dir, "sub", "new.md")
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.AppendToFile(path, "hello\n"); err != nil {
+		t.Fatal(err)
+	}
+	replay(t, w.Undo, 1)
+	checkMissing(t, path)
+
+	// Redo refuses to replace a note made again since
+	writeNote(t, path, "other\n")
+	if _, err := w.Redo(1); !IsConflictError(err) {
+		t.Fatalf("Redo over a new note: %v, want a conflict", err)
+	}
+	checkNote(t, path, "other\n")
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	replay(t, w.Redo, 1)
+	checkNote(t, path, "hello\n")
+}
@@ -5,10 +5,14 @@ import (
 	"bufio"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/BioWare/lazyobsidian/internal/config"
+	"github.com/BioWare/lazyobsidian/internal/journal"
 	"github.com/BioWare/lazyobsidian/internal/logging"
+	"github.com/BioWare/lazyobsidian/internal/templates"
 	"github.com/BioWare/lazyobsidian/pkg/types"
 )
 
@@ -17,72 +21,40 @@ type Writer struct {
 	vaultPath string
 	config    *config.Config
 	parser    *Parser
+
+	// Prompt, if set, answers the prompts of templates used to create
+	// notes. Without it, prompts take their default value.
+	Prompt func(question, defaultValue string) (string, error)
+
+	// Journal records every change for Undo and Redo; nil disables it.
+	Journal *journal.Journal
+	pending *journal.Entry // operation being grouped, see group
 }
 
-// NewWriter creates a new vault writer.
+// NewWriter creates a new vault writer that records its changes in the
+// vault's journal.
 func NewWriter(vaultPath string, cfg *config.Config, parser *Parser) *Writer {
 	return &Writer{
 		vaultPath: vaultPath,
 		config:    cfg,
 		parser:    parser,
+		Journal:   journal.New(vaultPath),
 	}
 }
 
-// ToggleTask toggles a task's status in the specified file.
-// It reads the file, modifies the task at the given line, and writes it back.
+// ToggleTask toggles a task's status in the specified file between done
+// and open.
 func (w *Writer) ToggleTask(filePath string, task *types.Task) error {
 	if task == nil {
 		return fmt.Errorf("task is nil")
 	}
 
-	logging.Debug("Toggling task at line %d in %s", task.Line, filePath)
-
-	// Read the file
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		return fmt.Errorf("failed to read file: %w", err)
-	}
-
-	lines := strings.Split(string(content), "\n")
-
-	// Find and modify the task line
-	if task.Line < 1 || task.Line > len(lines) {
-		return fmt.Errorf("invalid line number: %d (file has %d lines)", task.Line, len(lines))
-	}
-
-	lineIdx := task.Line - 1 // Convert to 0-indexed
-	oldLine := lines[lineIdx]
-
-	// Determine the new status
 	newStatus := "done"
 	if task.Status == "done" {
 		newStatus = "open"
 	}
-
-	// Get the symbol for the new status
-	newSymbol := w.parser.statusToSymbol(newStatus)
-
-	// Replace the status in the line
-	newLine := w.replaceTaskStatus(oldLine, newSymbol)
-	if newLine == oldLine {
-		logging.Warn("Task line unchanged, pattern might not match: %s", oldLine)
-		return fmt.Errorf("could not find task pattern in line")
-	}
-
-	lines[lineIdx] = newLine
-	logging.Debug("Changed line from '%s' to '%s'", oldLine, newLine)
-
-	// Write the file back
-	newContent := strings.Join(lines, "\n")
-	if err := os.WriteFile(filePath, []byte(newContent), 0644); err != nil {
-		return fmt.Errorf("failed to write file: %w", err)
-	}
-
-	// Update the task status in memory
-	task.Status = newStatus
-
-	logging.Info("Task toggled: %s -> %s", task.Text, newStatus)
-	return nil
+	defer w.group("toggle task")()
+	return w.UpdateTaskStatus(filePath, task, newStatus)
 }
 
 // replaceTaskStatus replaces the status symbol in a task line.
@@ -110,7 +82,12 @@ func (w *Writer) replaceTaskStatus(line, newSymbol string) string {
 	return newLine
 }
 
-// UpdateTaskStatus updates a task's status to a specific value.
+// UpdateTaskStatus updates a task's status to a specific value. The task
+// is found by its identity (see TaskKey) rather than trusting task.Line;
+// a *ConflictError is returned if it cannot be found unambiguously.
+// Completing a recurring task also adds its next occurrence, and the
+// subtask rules of the config close its subtasks or complete its parent,
+// all in the same edit.
 func (w *Writer) UpdateTaskStatus(filePath string, task *types.Task, newStatus string) error {
 	if task == nil {
 		return fmt.Errorf("task is nil")
@@ -118,125 +95,114 @@ func (w *Writer) UpdateTaskStatus(filePath string, task *types.Task, newStatus s
 
 	logging.Debug("Updating task at line %d to status %s in %s", task.Line, newStatus, filePath)
 
-	// Read the file
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		return fmt.Errorf("failed to read file: %w", err)
-	}
-
-	lines := strings.Split(string(content), "\n")
-
-	// Find and modify the task line
-	if task.Line < 1 || task.Line > len(lines) {
-		return fmt.Errorf("invalid line number: %d (file has %d lines)", task.Line, len(lines))
-	}
-
-	lineIdx := task.Line - 1 // Convert to 0-indexed
-	oldLine := lines[lineIdx]
-
 	// Get the symbol for the new status
 	newSymbol := w.parser.statusToSymbol(newStatus)
 
-	// Replace the status in the line
-	newLine := w.replaceTaskStatus(oldLine, newSymbol)
-	if newLine == oldLine {
-		logging.Warn("Task line unchanged, pattern might not match: %s", oldLine)
-		return fmt.Errorf("could not find task pattern in line")
-	}
+	err := w.editFile(filePath, "change task status", func(doc *document) error {
+		lineIdx, err := w.locateTask(doc, task)
+		if err != nil {
+			return err
+		}
+		oldLine := doc.lines[lineIdx]
 
-	lines[lineIdx] = newLine
+		// Replace the status in the line
+		newLine := w.replaceTaskStatus(oldLine, newSymbol)
+		if newLine == oldLine {
+			logging.Warn("Task line unchanged, pattern might not match: %s", oldLine)
+			return fmt.Errorf("could not find task pattern in line")
+		}
 
-	// Write the file back
-	newContent := strings.Join(lines, "\n")
-	if err := os.WriteFile(filePath, []byte(newContent), 0644); err != nil {
-		return fmt.Errorf("failed to write file: %w", err)
+		lineIdx = w.setLineStatus(doc, lineIdx, newStatus)
+		logging.Debug("Changed line from '%s' to '%s'", oldLine, doc.lines[lineIdx])
+
+		lineIdx += w.cascadeStatus(doc, lineIdx, newStatus)
+		task.Line = lineIdx + 1
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
 	// Update the task status in memory
 	task.Status = newStatus
+	if w.config != nil && w.config.Tasks.Dates.Enabled {
+		today := time.Now()
+		today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.Local)
+		task.DoneDate, task.CancelledDate = nil, nil
+		switch newStatus {
+		case "done":
+			task.DoneDate = &today
+		case "cancelled":
+			task.CancelledDate = &today
+		}
+	}
 
 	logging.Info("Task status updated: %s -> %s", task.Text, newStatus)
 	return nil
 }
 
-// AppendToFile appends content to a file.
+// AppendToFile appends content to a file, creating it if needed.
 func (w *Writer) AppendToFile(filePath string, content string) error {
-	f, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		return fmt.Errorf("failed to open file: %w", err)
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		if err := w.createFile(filePath, "append to note", content); err != nil {
+			return fmt.Errorf("failed to write to file: %w", err)
+		}
+		return nil
 	}
-	defer f.Close()
 
-	if _, err := f.WriteString(content); err != nil {
-		return fmt.Errorf("failed to write to file: %w", err)
-	}
+	return w.editFile(filePath, "append to note", func(doc *document) error {
+		doc.lines = appendText(doc.lines, content)
+		return nil
+	})
+}
 
-	return nil
+// appendText appends text to the end of lines, continuing the last line.
+func appendText(lines []string, text string) []string {
+	added := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	last := len(lines) - 1
+	lines[last] += added[0]
+	return append(lines, added[1:]...)
 }
 
-// InsertAtSection inserts content at a specific section in a file.
-// If the section doesn't exist, it appends to the end.
+// InsertAtSection inserts content at the end of a section's own text,
+// before any subsections. sectionHeading is matched against heading text,
+// ignoring case; a leading "#" marker is allowed. If the section doesn't
+// exist, it appends to the end.
 func (w *Writer) InsertAtSection(filePath string, sectionHeading string, content string) error {
-	data, err := os.ReadFile(filePath)
-	if err != nil {
-		return fmt.Errorf("failed to read file: %w", err)
+	if _, text, ok := parseHeading(sectionHeading); ok {
+		sectionHeading = text
 	}
 
-	lines := strings.Split(string(data), "\n")
-	var newLines []string
-	inserted := false
-
-	for i, line := range lines {
-		newLines = append(newLines, line)
-
-		// Check if this is the target section
-		if !inserted && strings.HasPrefix(line, "#") && strings.Contains(line, sectionHeading) {
-			// Find the end of the section (next heading or end of file)
-			insertIdx := i + 1
-			for insertIdx < len(lines) {
-				if strings.HasPrefix(lines[insertIdx], "#") {
-					break
-				}
-				insertIdx++
-			}
-
-			// Insert content at the end of the section
-			if insertIdx <= i+1 {
-				// Empty section, insert right after heading
-				newLines = append(newLines, content)
-			} else {
-				// Insert before the last empty line in the section
-				for insertIdx > i+1 && strings.TrimSpace(lines[insertIdx-1]) == "" {
-					insertIdx--
-				}
-				// Rebuild with insertion
-				newLines = lines[:insertIdx]
-				newLines = append(newLines, content)
-				newLines = append(newLines, lines[insertIdx:]...)
-			}
-			inserted = true
-			break
+	added := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+
+	return w.editFile(filePath, "insert into note", func(doc *document) error {
+		if FindHeading(ParseOutline(doc.lines), sectionHeading) == nil {
+			// Section not found, append to end
+			doc.lines = append(append(doc.lines, ""), added...)
+			return nil
 		}
-	}
+		doc.lines = insertInSection(doc.lines, sectionHeading, added)
+		return nil
+	})
+}
 
-	if !inserted {
-		// Section not found, append to end
-		newLines = append(newLines, "", content)
+// CreateDailyNote creates a new daily note from the daily template, or a
+// default body when none is set. The note's date is read from its path.
+func (w *Writer) CreateDailyNote(filePath string) (*CreatedNote, error) {
+	date := time.Now()
+	name := strings.TrimSuffix(filepath.Base(filePath), ".md")
+	if d, ok := w.parser.dailyNoteDate(filePath); ok {
+		date = d
 	}
 
-	newContent := strings.Join(newLines, "\n")
-	return os.WriteFile(filePath, []byte(newContent), 0644)
+	return w.createNote(filePath, w.config.Daily.Template, dailyNoteBody, templates.Context{
+		Title: name,
+		Date:  date,
+	})
 }
 
-// CreateDailyNote creates a new daily note from template.
-func (w *Writer) CreateDailyNote(filePath string) error {
-	// Check if file already exists
-	if _, err := os.Stat(filePath); err == nil {
-		return nil // File exists, don't overwrite
-	}
-
-	// Create default content
-	content := `---
+// dailyNoteBody is the daily note created without a template.
+const dailyNoteBody = `---
 type: daily
 ---
 
@@ -250,15 +216,6 @@ type: daily
 
 `
 
-	// Ensure directory exists
-	dir := filePath[:strings.LastIndex(filePath, "/")]
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("failed to create directory: %w", err)
-	}
-
-	return os.WriteFile(filePath, []byte(content), 0644)
-}
-
 // ReadFileLines reads a file and returns its lines.
 func (w *Writer) ReadFileLines(filePath string) ([]string, error) {
 	file, err := os.Open(filePath)
@@ -269,6 +226,7 @@ func (w *Writer) ReadFileLines(filePath string) ([]string, error) {
 
 	var lines []string
 	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
 	for scanner.Scan() {
 		lines = append(lines, scanner.Text())
 	}
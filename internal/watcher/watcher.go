@@ -7,6 +7,8 @@ import (
 	"strings"
 
 	"github.com/fsnotify/fsnotify"
+
+	"github.com/BioWare/lazyobsidian/internal/ignore"
 )
 
 // EventType represents the type of file event.
@@ -32,6 +34,10 @@ type Watcher struct {
 	Events    chan Event
 	Errors    chan error
 	done      chan struct{}
+
+	// Ignore, if set before Start, excludes directories from watching
+	// and files from events.
+	Ignore *ignore.Matcher
 }
 
 // New creates a new file watcher for the vault.
@@ -80,8 +86,8 @@ func (w *Watcher) watch() {
 				return
 			}
 
-			// Only care about markdown files
-			if !strings.HasSuffix(event.Name, ".md") {
+			// Only care about markdown files that are not ignored
+			if !strings.HasSuffix(event.Name, ".md") || w.Ignore.Ignored(event.Name, false) {
 				continue
 			}
 
@@ -120,8 +126,8 @@ func (w *Watcher) addRecursive(path string) error {
 		}
 
 		if info.IsDir() {
-			// Skip hidden directories
-			if strings.HasPrefix(info.Name(), ".") && info.Name() != "." {
+			// Skip hidden and ignored directories
+			if path != w.vaultPath && w.Ignore.Ignored(path, true) {
 				return filepath.SkipDir
 			}
 			return w.watcher.Add(path)
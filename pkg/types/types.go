@@ -13,32 +13,94 @@ type TaskStatus struct {
 
 // Task represents a task parsed from markdown.
 type Task struct {
-	ID        int64
-	FileID    int64
-	Line      int
-	Text      string
-	Status    string
-	ParentID  *int64
-	HasNote   bool
-	Subtasks  []Task
-	Comment   string // inline comment after " // "
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID          int64
+	FileID      int64
+	Line        int
+	Text        string
+	Status      string
+	ParentID    *int64
+	HasNote     bool
+	Subtasks    []Task
+	Comment     string // inline comment after " // "
+	Section     string // text of the heading the task sits under, if any
+	SectionLine int    // line of that heading; 0 before the first heading
+	BlockID     string // "^id" block reference at the end of the line, stripped from Text
+	Fingerprint string // hash of Text, parent tasks and Section, for tasks without a BlockID
+	Pomodoros   int    // 🍅 marks, one per pomodoro run for the task, stripped from Text
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+
+	// Obsidian Tasks plugin metadata, stripped from Text
+	DueDate       *time.Time // 📅
+	ScheduledDate *time.Time // ⏳
+	StartDate     *time.Time // 🛫
+	CreatedDate   *time.Time // ➕
+	DoneDate      *time.Time // ✅
+	CancelledDate *time.Time // ❌
+	Priority      TaskPriority
+	Recurrence    string   // 🔁 rule text, e.g. "every week"
+	TaskID        string   // 🆔 identifier other tasks can depend on
+	DependsOn     []string // ⛔ identifiers of blocking tasks
+
+	// Dataview inline fields ([key:: value] or (key:: value)), stripped from Text
+	Fields map[string]interface{}
 }
 
+// TaskPriority represents a Tasks plugin priority.
+// Higher values are more urgent; the zero value means no priority.
+type TaskPriority int
+
+const (
+	PriorityLowest TaskPriority = iota - 2 // ⏬
+	PriorityLow                            // 🔽
+	PriorityNone
+	PriorityMedium  // 🔼
+	PriorityHigh    // ⏫
+	PriorityHighest // 🔺
+)
+
 // File represents a parsed markdown file.
 type File struct {
-	ID              int64
-	Path            string
-	Type            FileType
-	Title           string
-	Frontmatter     map[string]interface{}
-	Tags            []string
-	Tasks           []Task
-	Links           []Link
-	Content         string
-	ModifiedAt      time.Time
-	ParsedAt        time.Time
+	ID          int64
+	Path        string
+	Type        FileType
+	Title       string
+	Frontmatter map[string]interface{}
+	Fields      map[string]interface{} // Dataview inline fields from the note body
+	Tags        []string
+	Headings    []Heading       // top-level headings; nested ones are in Children
+	Schedule    []ScheduleBlock // time blocks, for daily notes
+	Tasks       []Task
+	Links       []Link
+	Content     string
+	ModifiedAt  time.Time
+	ParsedAt    time.Time
+	Size        int64  // size in bytes when parsed
+	Hash        string // SHA-256 of the content when parsed, hex encoded
+}
+
+// Heading is a node in a file's heading outline.
+// A section runs from its heading to EndLine, including subsections.
+type Heading struct {
+	Level    int
+	Text     string
+	Line     int
+	EndLine  int
+	BlockIDs []string // "^id" block references defined directly in the section
+	// HasContent is set when the section has non-blank lines of its own,
+	// before any subsection
+	HasContent bool
+	Children   []Heading
+}
+
+// ScheduleBlock is a time block from a daily note schedule line such as
+// "- 09:00-10:30 | Deep work | project".
+type ScheduleBlock struct {
+	Start   time.Time
+	End     time.Time // equal to Start when the line gives no end time
+	Title   string
+	Context string // optional third column, e.g. a project or [[link]]
+	Line    int
 }
 
 // FileType represents the type of a file.
@@ -58,8 +120,25 @@ type Link struct {
 	SourceID int64
 	TargetID int64
 	Type     LinkType
+
+	Target     string // link target as written, without heading or block
+	Heading    string // heading after "#", if any
+	Block      string // block ID after "#^", if any
+	Display    string // alias or link text
+	Line       int
+	TargetPath string // resolved file path; empty when unresolved
+	Status     LinkStatus
 }
 
+// LinkStatus represents the outcome of resolving a link target.
+type LinkStatus string
+
+const (
+	LinkUnresolved LinkStatus = "unresolved"
+	LinkResolved   LinkStatus = "resolved"
+	LinkAmbiguous  LinkStatus = "ambiguous" // several files match; TargetPath is the best guess
+)
+
 // LinkType represents the type of a link.
 type LinkType string
 
@@ -69,17 +148,32 @@ const (
 	LinkTypeEmbed    LinkType = "embed"
 )
 
+// GoalPeriod is the planning horizon of a goal note.
+type GoalPeriod string
+
+const (
+	GoalPeriodNone    GoalPeriod = ""
+	GoalPeriodYear    GoalPeriod = "year"
+	GoalPeriodQuarter GoalPeriod = "quarter"
+	GoalPeriodMonth   GoalPeriod = "month"
+	GoalPeriodWeek    GoalPeriod = "week"
+)
+
 // Goal represents a goal with hierarchical structure.
 type Goal struct {
-	ID          int64
-	FileID      int64
-	Title       string
-	Description string
-	DueDate     *time.Time
-	Progress    float64 // 0.0 - 1.0
-	Children    []Goal
-	ParentID    *int64
-	Pomodoros   int // aggregated from children
+	ID           int64
+	FileID       int64
+	Path         string // note the goal is defined in
+	Line         int    // heading line for heading goals; 0 for a whole note
+	Period       GoalPeriod
+	PeriodStart  *time.Time // first day of the period a periodic note covers
+	Title        string
+	Description  string
+	DueDate      *time.Time
+	Progress     float64 // 0.0 - 1.0
+	Children     []Goal
+	ParentID     *int64
+	Pomodoros    int // aggregated from children
 	OwnPomodoros int
 }
 
@@ -113,18 +207,34 @@ type CourseLesson struct {
 	HasNote  bool
 }
 
+// BookStatus is a book's place in the reading lifecycle.
+type BookStatus string
+
+const (
+	BookToRead    BookStatus = "to-read"
+	BookReading   BookStatus = "reading"
+	BookPaused    BookStatus = "paused"
+	BookFinished  BookStatus = "finished"
+	BookAbandoned BookStatus = "abandoned"
+)
+
 // Book represents a book being read.
 type Book struct {
-	ID           int64
-	FileID       int64
-	Title        string
-	Author       string
-	TotalPages   int
-	CurrentPage  int
-	Chapters     []BookChapter
-	TargetDate   *time.Time
-	Pomodoros    int
-	Notes        int
+	ID          int64
+	FileID      int64
+	Path        string
+	Title       string
+	Author      string
+	Status      BookStatus
+	StartedAt   *time.Time
+	FinishedAt  *time.Time
+	Rating      float64 // 0 when unrated, otherwise 0.5 - 5
+	TotalPages  int
+	CurrentPage int
+	Chapters    []BookChapter
+	TargetDate  *time.Time
+	Pomodoros   int
+	Notes       int
 }
 
 // BookChapter represents a chapter in a book.
@@ -164,13 +274,13 @@ type DailyGoal struct {
 
 // Stats represents aggregated statistics.
 type Stats struct {
-	TotalFocusTime    time.Duration
-	TotalPomodoros    int
-	TasksCompleted    int
-	CurrentStreak     int
-	LongestStreak     int
-	ByCategory        map[string]time.Duration
-	ByDay             map[string]int // date -> pomodoros
+	TotalFocusTime time.Duration
+	TotalPomodoros int
+	TasksCompleted int
+	CurrentStreak  int
+	LongestStreak  int
+	ByCategory     map[string]time.Duration
+	ByDay          map[string]int // date -> pomodoros
 }
 
 // NavItem represents an item in the sidebar navigation.